@@ -19,10 +19,30 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"go.linka.cloud/artifact-registry/pkg/packages/ansible"
 	"go.linka.cloud/artifact-registry/pkg/packages/apk"
+	"go.linka.cloud/artifact-registry/pkg/packages/bazel"
+	"go.linka.cloud/artifact-registry/pkg/packages/cargo"
+	"go.linka.cloud/artifact-registry/pkg/packages/composer"
+	"go.linka.cloud/artifact-registry/pkg/packages/conan"
+	"go.linka.cloud/artifact-registry/pkg/packages/conda"
+	"go.linka.cloud/artifact-registry/pkg/packages/cran"
 	"go.linka.cloud/artifact-registry/pkg/packages/deb"
+	"go.linka.cloud/artifact-registry/pkg/packages/freebsd"
+	"go.linka.cloud/artifact-registry/pkg/packages/generic"
+	"go.linka.cloud/artifact-registry/pkg/packages/golang"
 	"go.linka.cloud/artifact-registry/pkg/packages/helm"
+	"go.linka.cloud/artifact-registry/pkg/packages/maven"
+	"go.linka.cloud/artifact-registry/pkg/packages/nix"
+	"go.linka.cloud/artifact-registry/pkg/packages/npm"
+	"go.linka.cloud/artifact-registry/pkg/packages/nuget"
+	"go.linka.cloud/artifact-registry/pkg/packages/opkg"
+	"go.linka.cloud/artifact-registry/pkg/packages/pacman"
+	"go.linka.cloud/artifact-registry/pkg/packages/pypi"
 	"go.linka.cloud/artifact-registry/pkg/packages/rpm"
+	"go.linka.cloud/artifact-registry/pkg/packages/rubygems"
+	"go.linka.cloud/artifact-registry/pkg/packages/terraform"
+	"go.linka.cloud/artifact-registry/pkg/packages/vagrant"
 )
 
 var PkgGroup = &cobra.Group{ID: "2_packages", Title: "Package Commands:"}
@@ -36,17 +56,23 @@ func newPkgCmd(typ string) *cobra.Command {
 	}
 	pkgCmd.AddCommand(
 		newPkgListCmd(typ),
-		newPkgPushCmd(typ),
 		newPkgPullCmd(typ),
 		newPkgDeleteCmd(typ),
-		newPkgSetupCmd(typ),
 	)
+	// some package types can only be published with their own tooling
+	if cmd := newPkgPushCmd(typ); cmd != nil {
+		pkgCmd.AddCommand(cmd)
+	}
+	// not all package types can be configured on the local machine
+	if cmd := newPkgSetupCmd(typ); cmd != nil {
+		pkgCmd.AddCommand(cmd)
+	}
 	return pkgCmd
 }
 
 func init() {
 	rootCmd.AddGroup(PkgGroup)
-	for _, v := range []string{apk.Name, deb.Name, rpm.Name, helm.Name} {
+	for _, v := range []string{apk.Name, deb.Name, rpm.Name, helm.Name, npm.Name, pypi.Name, golang.Name, maven.Name, cargo.Name, nuget.Name, rubygems.Name, conda.Name, pacman.Name, opkg.Name, freebsd.Name, terraform.Name, generic.Name, ansible.Name, composer.Name, vagrant.Name, conan.Name, bazel.Name, nix.Name, cran.Name} {
 		rootCmd.AddCommand(newPkgCmd(v))
 	}
 }
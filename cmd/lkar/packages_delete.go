@@ -20,10 +20,30 @@ import (
 	"github.com/spf13/cobra"
 
 	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/packages/ansible"
 	"go.linka.cloud/artifact-registry/pkg/packages/apk"
+	"go.linka.cloud/artifact-registry/pkg/packages/bazel"
+	"go.linka.cloud/artifact-registry/pkg/packages/cargo"
+	"go.linka.cloud/artifact-registry/pkg/packages/composer"
+	"go.linka.cloud/artifact-registry/pkg/packages/conan"
+	"go.linka.cloud/artifact-registry/pkg/packages/conda"
+	"go.linka.cloud/artifact-registry/pkg/packages/cran"
 	"go.linka.cloud/artifact-registry/pkg/packages/deb"
+	"go.linka.cloud/artifact-registry/pkg/packages/freebsd"
+	"go.linka.cloud/artifact-registry/pkg/packages/generic"
+	"go.linka.cloud/artifact-registry/pkg/packages/golang"
 	"go.linka.cloud/artifact-registry/pkg/packages/helm"
+	"go.linka.cloud/artifact-registry/pkg/packages/maven"
+	"go.linka.cloud/artifact-registry/pkg/packages/nix"
+	"go.linka.cloud/artifact-registry/pkg/packages/npm"
+	"go.linka.cloud/artifact-registry/pkg/packages/nuget"
+	"go.linka.cloud/artifact-registry/pkg/packages/opkg"
+	"go.linka.cloud/artifact-registry/pkg/packages/pacman"
+	"go.linka.cloud/artifact-registry/pkg/packages/pypi"
 	"go.linka.cloud/artifact-registry/pkg/packages/rpm"
+	"go.linka.cloud/artifact-registry/pkg/packages/rubygems"
+	"go.linka.cloud/artifact-registry/pkg/packages/terraform"
+	"go.linka.cloud/artifact-registry/pkg/packages/vagrant"
 )
 
 func newPkgDeleteCmd(typ string) *cobra.Command {
@@ -46,6 +66,46 @@ func newPkgDeleteCmd(typ string) *cobra.Command {
 				c, err = rpm.NewClient(registry, repository, opts...)
 			case helm.Name:
 				c, err = helm.NewClient(registry, repository, opts...)
+			case golang.Name:
+				c, err = golang.NewClient(registry, repository, opts...)
+			case maven.Name:
+				c, err = maven.NewClient(registry, repository, opts...)
+			case cargo.Name:
+				c, err = cargo.NewClient(registry, repository, opts...)
+			case npm.Name:
+				c, err = npm.NewClient(registry, repository, opts...)
+			case nuget.Name:
+				c, err = nuget.NewClient(registry, repository, opts...)
+			case pypi.Name:
+				c, err = pypi.NewClient(registry, repository, opts...)
+			case rubygems.Name:
+				c, err = rubygems.NewClient(registry, repository, opts...)
+			case conda.Name:
+				c, err = conda.NewClient(registry, repository, opts...)
+			case pacman.Name:
+				c, err = pacman.NewClient(registry, repository, opts...)
+			case opkg.Name:
+				c, err = opkg.NewClient(registry, repository, "", opts...)
+			case freebsd.Name:
+				c, err = freebsd.NewClient(registry, repository, opts...)
+			case terraform.Name:
+				c, err = terraform.NewClient(registry, repository, "", opts...)
+			case generic.Name:
+				c, err = generic.NewClient(registry, repository, "", opts...)
+			case ansible.Name:
+				c, err = ansible.NewClient(registry, repository, opts...)
+			case composer.Name:
+				c, err = composer.NewClient(registry, repository, opts...)
+			case vagrant.Name:
+				c, err = vagrant.NewClient(registry, repository, "", opts...)
+			case conan.Name:
+				c, err = conan.NewClient(registry, repository, "", opts...)
+			case bazel.Name:
+				c, err = bazel.NewClient(registry, repository, opts...)
+			case nix.Name:
+				c, err = nix.NewClient(registry, repository, "", opts...)
+			case cran.Name:
+				c, err = cran.NewClient(registry, repository, opts...)
 			default:
 				return fmt.Errorf("unsupported package type: %s", typ)
 			}
@@ -21,10 +21,30 @@ import (
 	"github.com/spf13/cobra"
 
 	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/packages/ansible"
 	"go.linka.cloud/artifact-registry/pkg/packages/apk"
+	"go.linka.cloud/artifact-registry/pkg/packages/bazel"
+	"go.linka.cloud/artifact-registry/pkg/packages/cargo"
+	"go.linka.cloud/artifact-registry/pkg/packages/composer"
+	"go.linka.cloud/artifact-registry/pkg/packages/conan"
+	"go.linka.cloud/artifact-registry/pkg/packages/conda"
+	"go.linka.cloud/artifact-registry/pkg/packages/cran"
 	"go.linka.cloud/artifact-registry/pkg/packages/deb"
+	"go.linka.cloud/artifact-registry/pkg/packages/freebsd"
+	"go.linka.cloud/artifact-registry/pkg/packages/generic"
+	"go.linka.cloud/artifact-registry/pkg/packages/golang"
 	"go.linka.cloud/artifact-registry/pkg/packages/helm"
+	"go.linka.cloud/artifact-registry/pkg/packages/maven"
+	"go.linka.cloud/artifact-registry/pkg/packages/nix"
+	"go.linka.cloud/artifact-registry/pkg/packages/npm"
+	"go.linka.cloud/artifact-registry/pkg/packages/nuget"
+	"go.linka.cloud/artifact-registry/pkg/packages/opkg"
+	"go.linka.cloud/artifact-registry/pkg/packages/pacman"
+	"go.linka.cloud/artifact-registry/pkg/packages/pypi"
 	"go.linka.cloud/artifact-registry/pkg/packages/rpm"
+	"go.linka.cloud/artifact-registry/pkg/packages/rubygems"
+	"go.linka.cloud/artifact-registry/pkg/packages/terraform"
+	"go.linka.cloud/artifact-registry/pkg/packages/vagrant"
 )
 
 func newPkgPushCmd(typ string) *cobra.Command {
@@ -52,6 +72,101 @@ func newPkgPushCmd(typ string) *cobra.Command {
 		client = func(args []string) (packages.Pusher, error) {
 			return helm.NewClient(registry, repository, opts...)
 		}
+	case golang.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return golang.NewClient(registry, repository, opts...)
+		}
+	case maven.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return maven.NewClient(registry, repository, opts...)
+		}
+	case cargo.Name:
+		// crates are published with cargo publish
+		return nil
+	case npm.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return npm.NewClient(registry, repository, opts...)
+		}
+	case nuget.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return nuget.NewClient(registry, repository, opts...)
+		}
+	case pypi.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return pypi.NewClient(registry, repository, opts...)
+		}
+	case rubygems.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return rubygems.NewClient(registry, repository, opts...)
+		}
+	case conda.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return conda.NewClient(registry, repository, opts...)
+		}
+	case pacman.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return pacman.NewClient(registry, repository, opts...)
+		}
+	case opkg.Name:
+		use = fmt.Sprintf("push [repository] [feed] [path]")
+		index = 2
+		client = func(args []string) (packages.Pusher, error) {
+			return opkg.NewClient(registry, repository, args[1], opts...)
+		}
+	case freebsd.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return freebsd.NewClient(registry, repository, opts...)
+		}
+	case terraform.Name:
+		// the address is either namespace/name/system/version for modules or namespace/type/version/os/arch for providers
+		use = fmt.Sprintf("push [repository] [address] [path]")
+		index = 2
+		client = func(args []string) (packages.Pusher, error) {
+			return terraform.NewClient(registry, repository, args[1], opts...)
+		}
+	case generic.Name:
+		use = fmt.Sprintf("push [repository] [destination] [path]")
+		index = 2
+		client = func(args []string) (packages.Pusher, error) {
+			return generic.NewClient(registry, repository, args[1], opts...)
+		}
+	case ansible.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return ansible.NewClient(registry, repository, opts...)
+		}
+	case composer.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return composer.NewClient(registry, repository, opts...)
+		}
+	case vagrant.Name:
+		// the box is org/box/version/provider with an optional architecture, e.g. org/box/1.0.0/libvirt/amd64
+		use = fmt.Sprintf("push [repository] [box] [path]")
+		index = 2
+		client = func(args []string) (packages.Pusher, error) {
+			return vagrant.NewClient(registry, repository, args[1], opts...)
+		}
+	case conan.Name:
+		// the file is the recipe or package revision file, e.g. zlib/1.2.13/_/_/revisions/{rrev}/files/conanfile.py
+		use = fmt.Sprintf("push [repository] [file] [path]")
+		index = 2
+		client = func(args []string) (packages.Pusher, error) {
+			return conan.NewClient(registry, repository, args[1], opts...)
+		}
+	case bazel.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return bazel.NewClient(registry, repository, opts...)
+		}
+	case nix.Name:
+		// the file is either the nar file or the narinfo, e.g. nar/{filehash}.nar.xz or {hash}.narinfo
+		use = fmt.Sprintf("push [repository] [file] [path]")
+		index = 2
+		client = func(args []string) (packages.Pusher, error) {
+			return nix.NewClient(registry, repository, args[1], opts...)
+		}
+	case cran.Name:
+		client = func(args []string) (packages.Pusher, error) {
+			return cran.NewClient(registry, repository, opts...)
+		}
 	default:
 		panic(fmt.Sprintf("unknown package type %s", typ))
 	}
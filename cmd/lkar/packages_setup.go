@@ -27,6 +27,7 @@ import (
 	"go.linka.cloud/artifact-registry/pkg/packages/apk"
 	"go.linka.cloud/artifact-registry/pkg/packages/deb"
 	"go.linka.cloud/artifact-registry/pkg/packages/helm"
+	"go.linka.cloud/artifact-registry/pkg/packages/pacman"
 	"go.linka.cloud/artifact-registry/pkg/packages/rpm"
 )
 
@@ -62,6 +63,14 @@ func newPkgSetupCmd(typ string) *cobra.Command {
 		client = func(ctx context.Context, scheme, name string, args []string) (packages.Setuper, error) {
 			return helm.NewClient(registry, repository, opts...)
 		}
+	case pacman.Name:
+		use = fmt.Sprintf("setup [repository]")
+		args = 1
+		client = func(ctx context.Context, scheme, name string, args []string) (packages.Setuper, error) {
+			return pacman.NewClient(registry, repository, opts...)
+		}
+	default:
+		return nil
 	}
 	cmd := &cobra.Command{
 		Use:   use,
@@ -19,13 +19,16 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"go.linka.cloud/env"
 	"go.linka.cloud/grpc-toolkit/logger"
 
 	artifact_registry "go.linka.cloud/artifact-registry"
+	"go.linka.cloud/artifact-registry/pkg/packages/deb"
 	"go.linka.cloud/artifact-registry/pkg/registry"
 	"go.linka.cloud/artifact-registry/pkg/server"
 	"go.linka.cloud/artifact-registry/pkg/storage"
@@ -50,6 +53,13 @@ const (
 	EnvProxyClientCA = "ARTIFACT_REGISTRY_PROXY_CLIENT_CA"
 	EnvProxyUser     = "ARTIFACT_REGISTRY_PROXY_USER"
 	EnvProxyPassword = "ARTIFACT_REGISTRY_PROXY_PASSWORD"
+
+	EnvDebOrigin     = "ARTIFACT_REGISTRY_DEB_ORIGIN"
+	EnvDebLabel      = "ARTIFACT_REGISTRY_DEB_LABEL"
+	EnvDebSuites     = "ARTIFACT_REGISTRY_DEB_SUITES"
+	EnvDebValidUntil = "ARTIFACT_REGISTRY_DEB_VALID_UNTIL"
+	EnvDebUploaders  = "ARTIFACT_REGISTRY_DEB_UPLOADERS_KEYRING"
+	EnvDebArchs      = "ARTIFACT_REGISTRY_DEB_ARCHITECTURES"
 )
 
 var (
@@ -80,6 +90,13 @@ var (
 	proxyUser     string
 	proxyPassword string
 
+	debOrigin     string
+	debLabel      string
+	debSuites     string
+	debValidUntil string
+	debUploaders  string
+	debArchs      string
+
 	debug bool
 
 	cmd = &cobra.Command{
@@ -151,7 +168,45 @@ var (
 				logger.C(cmd.Context()).Warnf("using docker.io as backend without proxy is not recommended")
 				logger.C(cmd.Context()).Warnf("the rate limit of 100 requests per 6 hours is very easy to reach using this tool")
 			}
-			if err := server.Run(cmd.Context(), addr, aesKey, backend, domain, repo, cert, key, disableUI, opts...); err != nil {
+			dopts := []deb.Option{
+				deb.WithOrigin(debOrigin),
+				deb.WithLabel(debLabel),
+			}
+			if debSuites != "" {
+				suites := make(map[string]string)
+				for _, v := range strings.Split(debSuites, ",") {
+					dist, suite, ok := strings.Cut(v, "=")
+					if !ok {
+						logger.C(cmd.Context()).Fatalf("invalid deb suite %q: expected distribution=suite", v)
+					}
+					suites[strings.TrimSpace(dist)] = strings.TrimSpace(suite)
+				}
+				dopts = append(dopts, deb.WithSuites(suites))
+			}
+			if debValidUntil != "" {
+				d, err := time.ParseDuration(debValidUntil)
+				if err != nil {
+					logger.C(cmd.Context()).Fatalf("invalid deb release validity: %v", err)
+				}
+				dopts = append(dopts, deb.WithValidUntil(d))
+			}
+			if debUploaders != "" {
+				f, err := os.Open(debUploaders)
+				if err != nil {
+					logger.C(cmd.Context()).Fatal(err)
+				}
+				keys, err := openpgp.ReadArmoredKeyRing(f)
+				f.Close()
+				if err != nil {
+					logger.C(cmd.Context()).Fatalf("invalid deb uploaders keyring: %v", err)
+				}
+				dopts = append(dopts, deb.WithUploaders(keys))
+			}
+			if debArchs != "" {
+				dopts = append(dopts, deb.WithArchitectures(strings.Split(debArchs, ",")...))
+			}
+			ctx := deb.WithOptions(cmd.Context(), dopts...)
+			if err := server.Run(ctx, addr, aesKey, backend, domain, repo, cert, key, disableUI, opts...); err != nil {
 				logger.C(cmd.Context()).Fatal(err)
 			}
 		},
@@ -189,6 +244,13 @@ func main() {
 	cmd.Flags().StringVar(&proxyUser, "proxy-user", env.GetDefault(EnvProxyUser, proxyUser), "proxy registry user [$"+EnvProxyUser+"]")
 	cmd.Flags().StringVar(&proxyPassword, "proxy-password", env.GetDefault(EnvProxyPassword, proxyPassword), "proxy registry password [$"+EnvProxyPassword+"]")
 
+	cmd.Flags().StringVar(&debOrigin, "deb-origin", env.GetDefault(EnvDebOrigin, debOrigin), "deb repositories Release files Origin [$"+EnvDebOrigin+"]")
+	cmd.Flags().StringVar(&debLabel, "deb-label", env.GetDefault(EnvDebLabel, debLabel), "deb repositories Release files Label [$"+EnvDebLabel+"]")
+	cmd.Flags().StringVar(&debSuites, "deb-suites", env.GetDefault(EnvDebSuites, debSuites), "deb distributions suites, e.g. bookworm=stable,trixie=testing [$"+EnvDebSuites+"]")
+	cmd.Flags().StringVar(&debValidUntil, "deb-valid-until", env.GetDefault(EnvDebValidUntil, debValidUntil), "deb repositories Release files validity, e.g. 168h, the repositories must be updated more often [$"+EnvDebValidUntil+"]")
+	cmd.Flags().StringVar(&debArchs, "deb-architectures", env.GetDefault(EnvDebArchs, debArchs), "deb architectures listed in the distributions containing only Architecture: all packages, e.g. amd64,arm64 [$"+EnvDebArchs+"]")
+	cmd.Flags().StringVar(&debUploaders, "deb-uploaders-keyring", env.Get[string](EnvDebUploaders), "armored keyring of the keys trusted to sign the deb .changes uploads [$"+EnvDebUploaders+"]")
+
 	cmd.Flags().BoolVarP(&debug, "debug", "d", false, "enable debug logging")
 
 	if debug {
@@ -1,8 +1,28 @@
 package main
 
 import (
+	_ "go.linka.cloud/artifact-registry/pkg/packages/ansible"
 	_ "go.linka.cloud/artifact-registry/pkg/packages/apk"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/bazel"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/cargo"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/composer"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/conan"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/conda"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/cran"
 	_ "go.linka.cloud/artifact-registry/pkg/packages/deb"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/freebsd"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/generic"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/golang"
 	_ "go.linka.cloud/artifact-registry/pkg/packages/helm"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/maven"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/nix"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/npm"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/nuget"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/opkg"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/pacman"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/pypi"
 	_ "go.linka.cloud/artifact-registry/pkg/packages/rpm"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/rubygems"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/terraform"
+	_ "go.linka.cloud/artifact-registry/pkg/packages/vagrant"
 )
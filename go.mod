@@ -3,6 +3,7 @@ module go.linka.cloud/artifact-registry
 go 1.25
 
 require (
+	github.com/Masterminds/semver/v3 v3.4.0
 	github.com/ProtonMail/go-crypto v1.3.0
 	github.com/blakesmith/ar v0.0.0-20190502131153-809d4375e1fb
 	github.com/distribution/distribution/v3 v3.0.0
@@ -23,6 +24,7 @@ require (
 	go.linka.cloud/env v0.0.0-20230215124643-494dd1a27d3c
 	go.linka.cloud/grpc-toolkit v0.4.4
 	go.linka.cloud/printer v0.0.0-20240221170110-7ea9393f148f
+	golang.org/x/mod v0.29.0
 	golang.org/x/sync v0.18.0
 	golang.org/x/term v0.37.0
 	helm.sh/helm/v3 v3.19.2
@@ -35,7 +37,6 @@ require (
 	github.com/Azure/go-ansiterm v0.0.0-20250102033503-faa5f7b0171c // indirect
 	github.com/DataDog/zstd v1.5.7 // indirect
 	github.com/MakeNowJust/heredoc v1.0.0 // indirect
-	github.com/Masterminds/semver/v3 v3.4.0 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/blang/semver/v4 v4.0.0 // indirect
 	github.com/bombsimon/logrusr/v4 v4.1.0 // indirect
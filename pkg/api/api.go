@@ -219,12 +219,18 @@ func listImageRepositories(ctx context.Context, reg registry.Registry, name stri
 			if !seen {
 				r.Size += v.Size
 			}
-			if v.MediaType == "application/vnd.lk.registry.layer.v1."+typ {
+			switch v.MediaType {
+			case "application/vnd.lk.registry.layer.v1." + typ:
 				if !seen {
 					r.Packages.Size += v.Size
 				}
 				r.Packages.Count++
-			} else {
+			case "application/vnd.lk.registry.file.layer.v1." + typ:
+				// the files are part of a package, e.g. the debian source tarballs
+				if !seen {
+					r.Packages.Size += v.Size
+				}
+			default:
 				if !seen {
 					r.Metadata.Size += v.Size
 				}
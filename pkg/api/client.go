@@ -22,10 +22,30 @@ import (
 
 	hclient "go.linka.cloud/artifact-registry/pkg/http/client"
 	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/packages/ansible"
 	"go.linka.cloud/artifact-registry/pkg/packages/apk"
+	"go.linka.cloud/artifact-registry/pkg/packages/bazel"
+	"go.linka.cloud/artifact-registry/pkg/packages/cargo"
+	"go.linka.cloud/artifact-registry/pkg/packages/composer"
+	"go.linka.cloud/artifact-registry/pkg/packages/conan"
+	"go.linka.cloud/artifact-registry/pkg/packages/conda"
+	"go.linka.cloud/artifact-registry/pkg/packages/cran"
 	"go.linka.cloud/artifact-registry/pkg/packages/deb"
+	"go.linka.cloud/artifact-registry/pkg/packages/freebsd"
+	"go.linka.cloud/artifact-registry/pkg/packages/generic"
+	"go.linka.cloud/artifact-registry/pkg/packages/golang"
 	"go.linka.cloud/artifact-registry/pkg/packages/helm"
+	"go.linka.cloud/artifact-registry/pkg/packages/maven"
+	"go.linka.cloud/artifact-registry/pkg/packages/nix"
+	"go.linka.cloud/artifact-registry/pkg/packages/npm"
+	"go.linka.cloud/artifact-registry/pkg/packages/nuget"
+	"go.linka.cloud/artifact-registry/pkg/packages/opkg"
+	"go.linka.cloud/artifact-registry/pkg/packages/pacman"
+	"go.linka.cloud/artifact-registry/pkg/packages/pypi"
 	"go.linka.cloud/artifact-registry/pkg/packages/rpm"
+	"go.linka.cloud/artifact-registry/pkg/packages/rubygems"
+	"go.linka.cloud/artifact-registry/pkg/packages/terraform"
+	"go.linka.cloud/artifact-registry/pkg/packages/vagrant"
 	"go.linka.cloud/artifact-registry/pkg/storage"
 )
 
@@ -106,6 +126,86 @@ func (c *client) Packages(ctx context.Context, typ string) ([]storage.Artifact,
 		var p []*helm.Package
 		err := json.NewDecoder(res.Body).Decode(&p)
 		return storage.AsArtifact(p), err
+	case golang.Name:
+		var p []*golang.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case maven.Name:
+		var p []*maven.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case cargo.Name:
+		var p []*cargo.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case npm.Name:
+		var p []*npm.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case nuget.Name:
+		var p []*nuget.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case pypi.Name:
+		var p []*pypi.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case rubygems.Name:
+		var p []*rubygems.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case conda.Name:
+		var p []*conda.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case pacman.Name:
+		var p []*pacman.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case opkg.Name:
+		var p []*opkg.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case freebsd.Name:
+		var p []*freebsd.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case terraform.Name:
+		var p []*terraform.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case generic.Name:
+		var p []*generic.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case ansible.Name:
+		var p []*ansible.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case composer.Name:
+		var p []*composer.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case vagrant.Name:
+		var p []*vagrant.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case conan.Name:
+		var p []*conan.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case bazel.Name:
+		var p []*bazel.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case nix.Name:
+		var p []*nix.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
+	case cran.Name:
+		var p []*cran.Package
+		err := json.NewDecoder(res.Body).Decode(&p)
+		return storage.AsArtifact(p), err
 	default:
 		return nil, fmt.Errorf("unexpected package type %q", typ)
 	}
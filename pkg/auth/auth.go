@@ -16,6 +16,9 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
 )
 
 type Basic interface {
@@ -32,3 +35,34 @@ func FromContext(ctx context.Context) Basic {
 	a, _ := ctx.Value(key{}).(Basic)
 	return a
 }
+
+// FromRequest returns the request credentials.
+// Besides basic auth, it accepts a bearer token holding the base64 encoded "user:password" credentials,
+// as sent by the clients supporting only tokens, e.g. terraform.
+func FromRequest(r *http.Request) Basic {
+	if _, _, ok := r.BasicAuth(); ok {
+		return r
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return r
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(token))
+	if err != nil {
+		return r
+	}
+	user, pass, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return r
+	}
+	return basic{user: user, password: pass}
+}
+
+type basic struct {
+	user     string
+	password string
+}
+
+func (b basic) BasicAuth() (username, password string, ok bool) {
+	return b.user, b.password, true
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.linka.cloud/artifact-registry/pkg/cache"
+)
+
+const (
+	// TokenParam is the query parameter holding the token of the signed urls
+	TokenParam = "token"
+	// TokenTTL is the validity duration of the signed urls
+	TokenTTL = 15 * time.Minute
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("expired token")
+)
+
+// credentials holds the credentials the tokens were issued for by token nonce, so that they are never part of the urls
+var credentials = cache.New()
+
+// Sign returns a short-lived token granting read only access to the repository file,
+// it is used to sign the download urls given to the clients that do not send their credentials
+// when downloading the files, e.g. terraform or vagrant.
+// The token is the HMAC of the repository, the file, the method and the expiration keyed with the key,
+// the context credentials being kept by the server until the token expires.
+// The token is empty when the context does not hold any credentials.
+func Sign(ctx context.Context, key []byte, repo, file string) (string, error) {
+	a := FromContext(ctx)
+	if a == nil {
+		return "", nil
+	}
+	user, pass, ok := a.BasicAuth()
+	if !ok {
+		return "", nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(b)
+	exp := strconv.FormatInt(time.Now().Add(TokenTTL).Unix(), 10)
+	credentials.Set(nonce, basic{user: user, password: pass}, cache.WithTTL(TokenTTL))
+	return strings.Join([]string{exp, nonce, mac(key, repo, file, http.MethodGet, exp, nonce)}, "."), nil
+}
+
+// FromToken returns the credentials the token signed with the key was issued for,
+// the token is only valid to read the file of the repository it was issued for.
+func FromToken(key []byte, t, method, repo, file string) (Basic, error) {
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	parts := strings.Split(t, ".")
+	if len(parts) != 3 || !hmac.Equal([]byte(parts[2]), []byte(mac(key, repo, file, method, parts[0], parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+	exp, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().After(time.Unix(exp, 0)) {
+		return nil, ErrExpiredToken
+	}
+	a, ok := credentials.Get(parts[1])
+	if !ok {
+		return nil, ErrExpiredToken
+	}
+	return a.(Basic), nil
+}
+
+func mac(key []byte, parts ...string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(strings.Join(parts, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
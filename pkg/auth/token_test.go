@@ -0,0 +1,106 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	other := make([]byte, 32)
+	_, err = rand.Read(other)
+	require.NoError(t, err)
+
+	const (
+		repo = "user/repo"
+		file = "/vagrant/user/repo/org/box/versions/1.0.0/virtualbox.box"
+	)
+
+	tk, err := Sign(context.Background(), key, repo, file)
+	require.NoError(t, err)
+	assert.Empty(t, tk, "no credentials, no token")
+
+	tk, err = Sign(Context(context.Background(), basic{user: "user", password: "pass"}), key, repo, file)
+	require.NoError(t, err)
+	assert.NotContains(t, tk, "pass")
+	for _, m := range []string{http.MethodGet, http.MethodHead} {
+		a, err := FromToken(key, tk, m, repo, file)
+		require.NoError(t, err)
+		user, pass, ok := a.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", pass)
+	}
+
+	tests := []struct {
+		name   string
+		key    []byte
+		token  string
+		method string
+		repo   string
+		file   string
+		want   error
+	}{
+		{name: "put", method: http.MethodPut, want: ErrInvalidToken},
+		{name: "delete", method: http.MethodDelete, want: ErrInvalidToken},
+		{name: "post", method: http.MethodPost, want: ErrInvalidToken},
+		{name: "other file", file: "/vagrant/user/repo/org/box/versions/1.0.0/libvirt.box", want: ErrInvalidToken},
+		{name: "other repository", repo: "user/other", want: ErrInvalidToken},
+		{name: "other key", key: other, want: ErrInvalidToken},
+		{name: "not a token", token: "not a token", want: ErrInvalidToken},
+		{name: "tampered expiration", token: strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + tk[strings.Index(tk, "."):], want: ErrInvalidToken},
+		{name: "expired", token: testToken(key, repo, file, time.Now().Add(-time.Second), "nonce"), want: ErrExpiredToken},
+		{name: "unknown credentials", token: testToken(key, repo, file, time.Now().Add(time.Minute), "unknown"), want: ErrExpiredToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, tok, m, r, f := key, tk, http.MethodGet, repo, file
+			if tt.key != nil {
+				k = tt.key
+			}
+			if tt.token != "" {
+				tok = tt.token
+			}
+			if tt.method != "" {
+				m = tt.method
+			}
+			if tt.repo != "" {
+				r = tt.repo
+			}
+			if tt.file != "" {
+				f = tt.file
+			}
+			_, err := FromToken(k, tok, m, r, f)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func testToken(key []byte, repo, file string, exp time.Time, nonce string) string {
+	e := strconv.FormatInt(exp.Unix(), 10)
+	return strings.Join([]string{e, nonce, mac(key, repo, file, http.MethodGet, e, nonce)}, ".")
+}
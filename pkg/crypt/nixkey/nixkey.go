@@ -0,0 +1,94 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nixkey implements the Nix ed25519 signing keys and store paths signatures format
+// https://github.com/NixOS/nix/blob/master/src/libutil/signature/local-keys.cc
+package nixkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid nix key")
+
+// GenerateKeypair generates a keypair in the "name:base64" format used by nix,
+// the name defaults to artifact-registry-{fingerprint}
+func GenerateKeypair(name string) (private string, public string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	if name == "" {
+		sum := sha256.Sum256(pub)
+		name = "artifact-registry-" + hex.EncodeToString(sum[:4])
+	}
+	if strings.Contains(name, ":") {
+		return "", "", fmt.Errorf("%w: name must not contain ':'", ErrInvalidKey)
+	}
+	return encode(name, priv), encode(name, pub), nil
+}
+
+// Sign returns the "name:base64" signature of the fingerprint
+func Sign(priv string, fingerprint string) (string, error) {
+	name, key, err := decode(priv, ed25519.PrivateKeySize)
+	if err != nil {
+		return "", err
+	}
+	return encode(name, ed25519.Sign(key, []byte(fingerprint))), nil
+}
+
+// Verify verifies the signature of the fingerprint
+func Verify(pub, sig string, fingerprint string) error {
+	name, key, err := decode(pub, ed25519.PublicKeySize)
+	if err != nil {
+		return err
+	}
+	sname, s, err := decode(sig, ed25519.SignatureSize)
+	if err != nil {
+		return err
+	}
+	if name != sname {
+		return errors.New("signature key name mismatch")
+	}
+	if !ed25519.Verify(key, []byte(fingerprint), s) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func encode(name string, b []byte) string {
+	return name + ":" + base64.StdEncoding.EncodeToString(b)
+}
+
+func decode(s string, size int) (string, []byte, error) {
+	name, v, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok || name == "" {
+		return "", nil, ErrInvalidKey
+	}
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if len(b) != size {
+		return "", nil, fmt.Errorf("%w: unexpected size", ErrInvalidKey)
+	}
+	return name, b, nil
+}
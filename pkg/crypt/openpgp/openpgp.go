@@ -69,3 +69,11 @@ func ArmoredDetachSign(w io.Writer, priv string, message io.Reader) (err error)
 	}
 	return openpgp.ArmoredDetachSign(w, e, message, nil)
 }
+
+func DetachSign(w io.Writer, priv string, message io.Reader) (err error) {
+	e, err := ParseIdentity(priv)
+	if err != nil {
+		return err
+	}
+	return openpgp.DetachSign(w, e, message, nil)
+}
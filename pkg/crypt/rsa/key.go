@@ -24,12 +24,16 @@ import (
 	"fmt"
 )
 
-func PublicKeyAndFingerprintFromPrivateKey(priv string) (pub []byte, fp []byte, err error) {
+func ParsePrivateKey(priv string) (*rsa.PrivateKey, error) {
 	privPem, _ := pem.Decode([]byte(priv))
 	if privPem == nil {
-		return nil, nil, fmt.Errorf("failed to decode private key pem")
+		return nil, fmt.Errorf("failed to decode private key pem")
 	}
-	privKey, err := x509.ParsePKCS1PrivateKey(privPem.Bytes)
+	return x509.ParsePKCS1PrivateKey(privPem.Bytes)
+}
+
+func PublicKeyAndFingerprintFromPrivateKey(priv string) (pub []byte, fp []byte, err error) {
+	privKey, err := ParsePrivateKey(priv)
 	if err != nil {
 		return nil, nil, err
 	}
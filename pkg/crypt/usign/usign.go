@@ -0,0 +1,167 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package usign implements the OpenWrt usign (signify compatible) ed25519 keys and signatures format
+// https://git.openwrt.org/?p=project/usign.git;a=blob;f=main.c
+package usign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	pkAlg  = []byte("Ed")
+	kdfAlg = []byte("BK")
+
+	ErrInvalidKey = errors.New("invalid usign key")
+)
+
+type publicKey struct {
+	PkAlg       [2]byte
+	Fingerprint [8]byte
+	PublicKey   [ed25519.PublicKeySize]byte
+}
+
+type secretKey struct {
+	PkAlg       [2]byte
+	KdfAlg      [2]byte
+	KdfRounds   uint32
+	Salt        [16]byte
+	Checksum    [8]byte
+	Fingerprint [8]byte
+	SecretKey   [ed25519.PrivateKeySize]byte
+}
+
+type signature struct {
+	PkAlg       [2]byte
+	Fingerprint [8]byte
+	Signature   [ed25519.SignatureSize]byte
+}
+
+// GenerateKeypair generates an unencrypted usign keypair
+func GenerateKeypair(comment string) (private string, public string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	s := secretKey{KdfRounds: 0}
+	copy(s.PkAlg[:], pkAlg)
+	copy(s.KdfAlg[:], kdfAlg)
+	if _, err := rand.Read(s.Salt[:]); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(s.Fingerprint[:]); err != nil {
+		return "", "", err
+	}
+	copy(s.SecretKey[:], priv)
+	sum := sha512.Sum512(s.SecretKey[:])
+	copy(s.Checksum[:], sum[:8])
+	p := publicKey{Fingerprint: s.Fingerprint}
+	copy(p.PkAlg[:], pkAlg)
+	copy(p.PublicKey[:], pub)
+	privComment, pubComment := comment, comment
+	if comment == "" {
+		privComment = "private key " + hex.EncodeToString(p.Fingerprint[:])
+		pubComment = "public key " + hex.EncodeToString(p.Fingerprint[:])
+	}
+	if private, err = encode(privComment, s); err != nil {
+		return "", "", err
+	}
+	if public, err = encode(pubComment, p); err != nil {
+		return "", "", err
+	}
+	return private, public, nil
+}
+
+// Fingerprint returns the hex encoded fingerprint of the public key, used as file name by opkg to store the trusted keys
+func Fingerprint(pub string) (string, error) {
+	var p publicKey
+	if err := decode(pub, &p); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(p.Fingerprint[:]), nil
+}
+
+// Sign returns the usign signature file content of the message
+func Sign(priv string, message io.Reader) (string, error) {
+	var s secretKey
+	if err := decode(priv, &s); err != nil {
+		return "", err
+	}
+	if !bytes.Equal(s.PkAlg[:], pkAlg) || !bytes.Equal(s.KdfAlg[:], kdfAlg) || s.KdfRounds != 0 {
+		return "", fmt.Errorf("%w: unsupported algorithm or encrypted key", ErrInvalidKey)
+	}
+	b, err := io.ReadAll(message)
+	if err != nil {
+		return "", err
+	}
+	sig := signature{Fingerprint: s.Fingerprint}
+	copy(sig.PkAlg[:], pkAlg)
+	copy(sig.Signature[:], ed25519.Sign(s.SecretKey[:], b))
+	return encode("signed by key "+hex.EncodeToString(s.Fingerprint[:]), sig)
+}
+
+// Verify verifies the usign signature of the message
+func Verify(pub, sig string, message []byte) error {
+	var (
+		p publicKey
+		s signature
+	)
+	if err := decode(pub, &p); err != nil {
+		return err
+	}
+	if err := decode(sig, &s); err != nil {
+		return err
+	}
+	if p.Fingerprint != s.Fingerprint {
+		return errors.New("signature key fingerprint mismatch")
+	}
+	if !ed25519.Verify(p.PublicKey[:], message, s.Signature[:]) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func encode(comment string, v any) (string, error) {
+	var b bytes.Buffer
+	if err := binary.Write(&b, binary.BigEndian, v); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("untrusted comment: %s\n%s\n", comment, base64.StdEncoding.EncodeToString(b.Bytes())), nil
+}
+
+func decode(s string, v any) error {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "untrusted comment: ") {
+		return ErrInvalidKey
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if len(b) != binary.Size(v) {
+		return fmt.Errorf("%w: unexpected size", ErrInvalidKey)
+	}
+	return binary.Read(bytes.NewReader(b), binary.BigEndian, v)
+}
@@ -0,0 +1,199 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	DownloadDir = "download"
+
+	manifestFile = "MANIFEST.json"
+)
+
+var (
+	ErrInvalidCollection   = errors.New("collection is invalid")
+	ErrMissingManifestFile = errors.New("MANIFEST.json file is missing")
+
+	// namePattern matches the collection namespaces and names
+	// https://docs.ansible.com/ansible/latest/dev_guide/developing_collections_structure.html#galaxy-yml
+	namePattern = regexp.MustCompile(`\A[a-z0-9][a-z0-9_]*\z`)
+)
+
+// CollectionInfo is the collection_info section of the MANIFEST.json file
+type CollectionInfo struct {
+	Namespace     string            `json:"namespace"`
+	Name          string            `json:"name"`
+	Version       string            `json:"version"`
+	Authors       []string          `json:"authors"`
+	Readme        string            `json:"readme"`
+	Tags          []string          `json:"tags"`
+	Description   string            `json:"description"`
+	License       []string          `json:"license"`
+	LicenseFile   string            `json:"license_file"`
+	Dependencies  map[string]string `json:"dependencies"`
+	Repository    string            `json:"repository"`
+	Documentation string            `json:"documentation"`
+	Homepage      string            `json:"homepage"`
+	Issues        string            `json:"issues"`
+}
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	Namespace  string         `json:"namespace"`
+	PkgName    string         `json:"name"`
+	PkgVersion string         `json:"version"`
+	Info       CollectionInfo `json:"info"`
+
+	Published time.Time `json:"published"`
+	PkgSize   int64     `json:"size"`
+	FilePath  string    `json:"filePath"`
+	SHA256    string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.Namespace + "." + p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+func (p *Package) FileName() string {
+	return FileName(p.Namespace, p.PkgName, p.PkgVersion)
+}
+
+// FileName returns the collection artifact name, e.g. my_namespace-my_collection-1.0.0.tar.gz
+func FileName(namespace, name, version string) string {
+	return fmt.Sprintf("%s-%s-%s.tar.gz", namespace, name, version)
+}
+
+func PackagePath(namespace, name, version string) string {
+	return path.Join(DownloadDir, FileName(namespace, name, version))
+}
+
+func NewPackage(r io.Reader) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	info, err := parseCollection(io.NewSectionReader(reader, 0, reader.Size()))
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	_, _, sha256, _ := reader.Sums()
+	pkg := &Package{
+		Namespace:  info.Namespace,
+		PkgName:    info.Name,
+		PkgVersion: info.Version,
+		Info:       *info,
+		Published:  time.Now().UTC(),
+		PkgSize:    reader.Size(),
+		FilePath:   PackagePath(info.Namespace, info.Name, info.Version),
+		SHA256:     hex.EncodeToString(sha256),
+		reader:     reader,
+	}
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+func parseCollection(r io.Reader) (*CollectionInfo, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCollection, err)
+	}
+	defer gzr.Close()
+	tr := tar.NewReader(gzr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrMissingManifestFile
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCollection, err)
+		}
+		if hd.Typeflag != tar.TypeReg || strings.TrimPrefix(hd.Name, "./") != manifestFile {
+			continue
+		}
+		var m struct {
+			CollectionInfo CollectionInfo `json:"collection_info"`
+		}
+		if err := json.NewDecoder(tr).Decode(&m); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCollection, err)
+		}
+		info := &m.CollectionInfo
+		if !namePattern.MatchString(info.Namespace) || !namePattern.MatchString(info.Name) {
+			return nil, fmt.Errorf("%w: invalid namespace or name: %s.%s", ErrInvalidCollection, info.Namespace, info.Name)
+		}
+		if _, err := semver.StrictNewVersion(info.Version); err != nil {
+			return nil, fmt.Errorf("%w: invalid version %q: %v", ErrInvalidCollection, info.Version, err)
+		}
+		if info.Dependencies == nil {
+			info.Dependencies = make(map[string]string)
+		}
+		return info, nil
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCollection(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return b.Bytes()
+}
+
+func testManifest(namespace, name, version string) string {
+	return `{"collection_info": {"namespace": "` + namespace + `", "name": "` + name + `", "version": "` + version + `", "authors": ["John Doe"], "license": ["MIT"], "tags": ["example"], "description": "An example collection"}, "format": 1}`
+}
+
+func TestNewPackage(t *testing.T) {
+	b := testCollection(t, map[string]string{
+		"MANIFEST.json":        testManifest("my_namespace", "my_collection", "1.0.0"),
+		"FILES.json":           `{"files": []}`,
+		"plugins/modules/x.py": "",
+	})
+	pkg, err := NewPackage(bytes.NewReader(b))
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, "my_namespace", pkg.Namespace)
+	assert.Equal(t, "my_collection", pkg.PkgName)
+	assert.Equal(t, "1.0.0", pkg.Version())
+	assert.Equal(t, "download/my_namespace-my_collection-1.0.0.tar.gz", pkg.Path())
+	assert.Equal(t, "my_namespace-my_collection-1.0.0.tar.gz", pkg.FileName())
+	assert.Equal(t, int64(len(b)), pkg.Size())
+	assert.Len(t, pkg.SHA256, 64)
+	assert.Equal(t, []string{"John Doe"}, pkg.Info.Authors)
+	assert.Equal(t, "An example collection", pkg.Info.Description)
+	assert.Equal(t, map[string]string{}, pkg.Info.Dependencies)
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "missing manifest",
+			data:    testCollection(t, map[string]string{"galaxy.yml": "namespace: my_namespace"}),
+			wantErr: ErrMissingManifestFile,
+		},
+		{
+			name:    "nested manifest",
+			data:    testCollection(t, map[string]string{"docs/MANIFEST.json": testManifest("my_namespace", "my_collection", "1.0.0")}),
+			wantErr: ErrMissingManifestFile,
+		},
+		{
+			name:    "invalid namespace",
+			data:    testCollection(t, map[string]string{"MANIFEST.json": testManifest("My-Namespace", "my_collection", "1.0.0")}),
+			wantErr: ErrInvalidCollection,
+		},
+		{
+			name:    "invalid version",
+			data:    testCollection(t, map[string]string{"MANIFEST.json": testManifest("my_namespace", "my_collection", "1.0")}),
+			wantErr: ErrInvalidCollection,
+		},
+		{
+			name:    "not a tarball",
+			data:    []byte("not a collection"),
+			wantErr: ErrInvalidCollection,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(tt.data))
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
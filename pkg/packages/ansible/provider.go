@@ -0,0 +1,457 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	Name = "ansible"
+
+	APIPath = "/api/"
+	V3Path  = "v3/"
+)
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// apiPath returns the galaxy api path, e.g. /ansible/my-repo/api/
+func apiPath(r *http.Request) string {
+	return r.URL.Path[:strings.LastIndex(r.URL.Path, APIPath)+len(APIPath)]
+}
+
+// downloadURL returns the collection artifact absolute url
+func downloadURL(r *http.Request, pkg *Package) string {
+	return fmt.Sprintf("%s://%s%s/%s", packages.Scheme(r), r.Host, strings.TrimSuffix(apiPath(r), APIPath), pkg.FilePath)
+}
+
+func collectionHref(r *http.Request, namespace, name string) string {
+	return fmt.Sprintf("%s%scollections/%s/%s/", apiPath(r), V3Path, namespace, name)
+}
+
+func versionHref(r *http.Request, pkg *Package) string {
+	return fmt.Sprintf("%sversions/%s/", collectionHref(r, pkg.Namespace, pkg.PkgName), pkg.PkgVersion)
+}
+
+// api serves the api root used by ansible-galaxy to discover the available versions
+func (p *provider) api(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(r.Context(), w, http.StatusOK, map[string]any{
+			"description":        "Artifact Registry Galaxy API",
+			"current_version":    "v3",
+			"available_versions": map[string]string{"v3": V3Path},
+		})
+	}
+}
+
+type CollectionResponse struct {
+	Href           string          `json:"href"`
+	Namespace      string          `json:"namespace"`
+	Name           string          `json:"name"`
+	VersionsURL    string          `json:"versions_url"`
+	HighestVersion VersionSummary  `json:"highest_version"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Deprecated     bool            `json:"deprecated"`
+	Metadata       *CollectionInfo `json:"metadata,omitempty"`
+}
+
+type VersionSummary struct {
+	Href      string    `json:"href"`
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+type VersionResponse struct {
+	Href        string            `json:"href"`
+	Namespace   map[string]string `json:"namespace"`
+	Collection  map[string]string `json:"collection"`
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	DownloadURL string            `json:"download_url"`
+	Artifact    Artifact          `json:"artifact"`
+	Metadata    CollectionInfo    `json:"metadata"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Signatures  []any             `json:"signatures"`
+}
+
+type Artifact struct {
+	FileName string `json:"filename"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+}
+
+// Page is the galaxy v3 paginated list response
+type Page[T any] struct {
+	Meta  map[string]int     `json:"meta"`
+	Links map[string]*string `json:"links"`
+	Data  []T                `json:"data"`
+}
+
+// newPage returns the requested page of the items, using the limit and offset query parameters
+func newPage[T any](r *http.Request, items []T) Page[T] {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	offset = max(0, min(offset, len(items)))
+	link := func(offset int) *string {
+		s := fmt.Sprintf("%s?limit=%d&offset=%d", r.URL.Path, limit, offset)
+		return &s
+	}
+	p := Page[T]{
+		Meta:  map[string]int{"count": len(items)},
+		Links: map[string]*string{"first": link(0), "previous": nil, "next": nil, "last": link(max(0, (len(items)-1)/limit*limit))},
+		Data:  items[offset:min(offset+limit, len(items))],
+	}
+	if offset > 0 {
+		p.Links["previous"] = link(max(0, offset-limit))
+	}
+	if offset+limit < len(items) {
+		p.Links["next"] = link(offset + limit)
+	}
+	return p
+}
+
+func newCollectionResponse(r *http.Request, c *Collection) CollectionResponse {
+	h := c.Highest()
+	href := collectionHref(r, c.Namespace, c.Name)
+	return CollectionResponse{
+		Href:           href,
+		Namespace:      c.Namespace,
+		Name:           c.Name,
+		VersionsURL:    href + "versions/",
+		HighestVersion: VersionSummary{Href: versionHref(r, h), Version: h.PkgVersion},
+		CreatedAt:      c.Versions[0].Published,
+		UpdatedAt:      h.Published,
+		Metadata:       &h.Info,
+	}
+}
+
+// https://galaxy.ansible.com/api/v3/swagger-ui/
+func (p *provider) collections(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var cs []*Collection
+		if err := readJSON(ctx, CollectionsIndex, &cs); err != nil && !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		res := make([]CollectionResponse, 0, len(cs))
+		for _, v := range cs {
+			res = append(res, newCollectionResponse(r, v))
+		}
+		writeJSON(ctx, w, http.StatusOK, newPage(r, res))
+	}
+}
+
+func (p *provider) collection(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := readCollection(w, r)
+		if !ok {
+			return
+		}
+		writeJSON(r.Context(), w, http.StatusOK, newCollectionResponse(r, c))
+	}
+}
+
+func (p *provider) versions(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := readCollection(w, r)
+		if !ok {
+			return
+		}
+		res := make([]VersionSummary, 0, len(c.Versions))
+		// the highest versions are listed first
+		for i := len(c.Versions) - 1; i >= 0; i-- {
+			v := c.Versions[i]
+			res = append(res, VersionSummary{Href: versionHref(r, v), Version: v.PkgVersion, CreatedAt: v.Published})
+		}
+		writeJSON(r.Context(), w, http.StatusOK, newPage(r, res))
+	}
+}
+
+func (p *provider) version(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := readCollection(w, r)
+		if !ok {
+			return
+		}
+		version := mux.Vars(r)["version"]
+		for _, v := range c.Versions {
+			if v.PkgVersion != version {
+				continue
+			}
+			writeJSON(r.Context(), w, http.StatusOK, VersionResponse{
+				Href:        versionHref(r, v),
+				Namespace:   map[string]string{"name": v.Namespace},
+				Collection:  map[string]string{"name": v.PkgName, "href": collectionHref(r, v.Namespace, v.PkgName)},
+				Name:        v.PkgName,
+				Version:     v.PkgVersion,
+				DownloadURL: downloadURL(r, v),
+				Artifact:    Artifact{FileName: v.FileName(), SHA256: v.SHA256, Size: v.PkgSize},
+				Metadata:    v.Info,
+				CreatedAt:   v.Published,
+				Signatures:  []any{},
+			})
+			return
+		}
+		writeError(r.Context(), w, http.StatusNotFound, fmt.Errorf("collection %s.%s version %s not found", c.Namespace, c.Name, version))
+	}
+}
+
+// publish handles the multipart upload used by ansible-galaxy collection publish as well as raw uploads.
+// As the collection is imported synchronously, the returned task is always completed.
+func (p *provider) publish(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var (
+			reader io.Reader = r.Body
+			sum    string
+		)
+		if mr, err := r.MultipartReader(); err == nil {
+			for {
+				part, err := mr.NextPart()
+				if err != nil {
+					writeError(ctx, w, http.StatusBadRequest, fmt.Errorf("missing file: %w", err))
+					return
+				}
+				defer part.Close()
+				if part.FormName() == "sha256" {
+					b, _ := io.ReadAll(part)
+					sum = strings.TrimSpace(string(b))
+					continue
+				}
+				if part.FormName() == "file" {
+					reader = part
+					break
+				}
+			}
+		}
+		pkg, err := NewPackage(reader)
+		if err != nil {
+			writeError(ctx, w, http.StatusBadRequest, err)
+			return
+		}
+		defer pkg.Close()
+		if sum != "" && sum != pkg.SHA256 {
+			writeError(ctx, w, http.StatusBadRequest, fmt.Errorf("sha256 mismatch: expected %s, got %s", sum, pkg.SHA256))
+			return
+		}
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		if _, err := s.Stat(ctx, pkg.Path()); err == nil {
+			writeError(ctx, w, http.StatusConflict, fmt.Errorf("collection %s version %s already exists", pkg.Name(), pkg.PkgVersion))
+			return
+		} else if !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.Version()).Infof("uploading collection")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		writeJSON(ctx, w, http.StatusAccepted, map[string]string{
+			"task": fmt.Sprintf("%s%simports/collections/%s/", apiPath(r), V3Path, taskID(pkg)),
+		})
+	}
+}
+
+// task reports the import task of a published collection
+func (p *provider) task(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := mux.Vars(r)["id"]
+		b, err := hex.DecodeString(id)
+		parts := strings.SplitN(string(b), "/", 3)
+		if err != nil || len(parts) != 3 {
+			writeError(ctx, w, http.StatusNotFound, errors.New("task not found"))
+			return
+		}
+		i, err := storage.FromContext(ctx).Stat(ctx, PackagePath(parts[0], parts[1], parts[2]))
+		if err != nil {
+			if storage.IsNotFound(err) {
+				writeError(ctx, w, http.StatusNotFound, errors.New("task not found"))
+				return
+			}
+			storage.Error(w, err)
+			return
+		}
+		var pkg Package
+		if err := json.Unmarshal(i.Meta(), &pkg); err != nil {
+			writeError(ctx, w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(ctx, w, http.StatusOK, map[string]any{
+			"id":          id,
+			"state":       "completed",
+			"created_at":  pkg.Published,
+			"updated_at":  pkg.Published,
+			"started_at":  pkg.Published,
+			"finished_at": pkg.Published,
+			"error":       nil,
+			"messages":    []any{},
+		})
+	}
+}
+
+// taskID encodes the collection reference as its version may contain any separator
+func taskID(pkg *Package) string {
+	return hex.EncodeToString([]byte(strings.Join([]string{pkg.Namespace, pkg.PkgName, pkg.PkgVersion}, "/")))
+}
+
+func readCollection(w http.ResponseWriter, r *http.Request) (*Collection, bool) {
+	ctx := r.Context()
+	namespace, name := mux.Vars(r)["namespace"], mux.Vars(r)["name"]
+	var c Collection
+	if err := readJSON(ctx, CollectionPath(namespace, name), &c); err != nil {
+		if storage.IsNotFound(err) {
+			writeError(ctx, w, http.StatusNotFound, fmt.Errorf("collection %s.%s not found", namespace, name))
+			return nil, false
+		}
+		storage.Error(w, err)
+		return nil, false
+	}
+	if len(c.Versions) == 0 {
+		writeError(ctx, w, http.StatusNotFound, fmt.Errorf("collection %s.%s not found", namespace, name))
+		return nil, false
+	}
+	return &c, true
+}
+
+func readJSON(ctx context.Context, name string, v any) error {
+	rc, err := storage.FromContext(ctx).Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.C(ctx).WithError(err).Error("failed to write response")
+	}
+}
+
+// writeError writes the error in the galaxy v3 format displayed by ansible-galaxy
+func writeError(ctx context.Context, w http.ResponseWriter, code int, err error) {
+	writeJSON(ctx, w, code, map[string]any{
+		"errors": []map[string]string{{
+			"status": strconv.Itoa(code),
+			"code":   strings.ToLower(strings.ReplaceAll(http.StatusText(code), " ", "_")),
+			"title":  http.StatusText(code),
+			"detail": err.Error(),
+		}},
+	})
+}
+
+func (p *provider) Routes() []*packages.Route {
+	collectionPath := APIPath + V3Path + "collections/{namespace}/{name}/"
+	return []*packages.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    APIPath,
+			Handler: p.api,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    APIPath + V3Path + "artifacts/collections/",
+			Handler: p.publish,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    APIPath + V3Path + "imports/collections/{id}/",
+			Handler: p.task,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    APIPath + V3Path + "collections/",
+			Handler: p.collections,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    collectionPath,
+			Handler: p.collection,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    collectionPath + "versions/",
+			Handler: p.versions,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    collectionPath + "versions/{version}/",
+			Handler: p.version,
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   collectionPath + "versions/{version}/",
+			Handler: packages.Delete(func(r *http.Request) string {
+				return PackagePath(mux.Vars(r)["namespace"], mux.Vars(r)["name"], mux.Vars(r)["version"])
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + DownloadDir + "/{filename}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				return DownloadDir + "/" + mux.Vars(r)["filename"]
+			}),
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/" + DownloadDir + "/{filename}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				return DownloadDir + "/" + mux.Vars(r)["filename"]
+			}),
+		},
+	}
+}
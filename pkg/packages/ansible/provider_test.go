@@ -0,0 +1,67 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPage(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4}
+	link := func(s string) *string {
+		return &s
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  Page[int]
+	}{
+		{
+			name: "default limit",
+			want: Page[int]{
+				Meta:  map[string]int{"count": 5},
+				Links: map[string]*string{"first": link("/api?limit=100&offset=0"), "previous": nil, "next": nil, "last": link("/api?limit=100&offset=0")},
+				Data:  items,
+			},
+		},
+		{
+			name:  "middle page",
+			query: "?limit=2&offset=2",
+			want: Page[int]{
+				Meta:  map[string]int{"count": 5},
+				Links: map[string]*string{"first": link("/api?limit=2&offset=0"), "previous": link("/api?limit=2&offset=0"), "next": link("/api?limit=2&offset=4"), "last": link("/api?limit=2&offset=4")},
+				Data:  []int{2, 3},
+			},
+		},
+		{
+			name:  "out of range",
+			query: "?limit=2&offset=10",
+			want: Page[int]{
+				Meta:  map[string]int{"count": 5},
+				Links: map[string]*string{"first": link("/api?limit=2&offset=0"), "previous": link("/api?limit=2&offset=3"), "next": nil, "last": link("/api?limit=2&offset=4")},
+				Data:  []int{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newPage(httptest.NewRequest(http.MethodGet, "/api"+tt.query, nil), items))
+		})
+	}
+}
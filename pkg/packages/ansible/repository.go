@@ -0,0 +1,118 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	CollectionsIndex = "collections.json"
+	CollectionsDir   = "collections"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "ansible"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Ansible Galaxy", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Collection lists the versions of a collection sorted by semantic version
+type Collection struct {
+	Namespace string     `json:"namespace"`
+	Name      string     `json:"name"`
+	Versions  []*Package `json:"versions"`
+}
+
+func (c *Collection) Highest() *Package {
+	return c.Versions[len(c.Versions)-1]
+}
+
+// CollectionPath returns the path of the collection versions index
+func CollectionPath(namespace, name string) string {
+	return path.Join(CollectionsDir, namespace, name+".json")
+}
+
+// Index generates the collections index and the versions index of each collection
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	names := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.Name()
+	}))
+	sort.Strings(names)
+	var collections []*Collection
+	for _, name := range names {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.Name() == name
+		})
+		sort.Slice(pkgs, func(i, j int) bool {
+			return semver.MustParse(pkgs[i].PkgVersion).LessThan(semver.MustParse(pkgs[j].PkgVersion))
+		})
+		c := &Collection{Namespace: pkgs[0].Namespace, Name: pkgs[0].PkgName, Versions: pkgs}
+		b, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, storage.NewFile(CollectionPath(c.Namespace, c.Name), b))
+		// only the highest version is kept in the collections index
+		collections = append(collections, &Collection{Namespace: c.Namespace, Name: c.Name, Versions: []*Package{c.Highest()}})
+	}
+	b, err := json.Marshal(collections)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, storage.NewFile(CollectionsIndex, b)), nil
+}
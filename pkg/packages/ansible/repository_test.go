@@ -0,0 +1,67 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	pkg := func(namespace, name, version string) storage.Artifact {
+		return &Package{Namespace: namespace, PkgName: name, PkgVersion: version, FilePath: PackagePath(namespace, name, version)}
+	}
+	out, err := (&repo{}).Index(context.Background(), "",
+		pkg("my_namespace", "my_collection", "1.10.0"),
+		pkg("my_namespace", "my_collection", "1.2.0"),
+		pkg("my_namespace", "my_collection", "2.0.0-rc.1"),
+		pkg("other", "collection", "0.1.0"),
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	require.Len(t, files, 3)
+
+	var c Collection
+	require.NoError(t, json.Unmarshal(files["collections/my_namespace/my_collection.json"], &c))
+	assert.Equal(t, "my_namespace", c.Namespace)
+	assert.Equal(t, "my_collection", c.Name)
+	var versions []string
+	for _, v := range c.Versions {
+		versions = append(versions, v.PkgVersion)
+	}
+	assert.Equal(t, []string{"1.2.0", "1.10.0", "2.0.0-rc.1"}, versions)
+	assert.Equal(t, "2.0.0-rc.1", c.Highest().PkgVersion)
+
+	var collections []*Collection
+	require.NoError(t, json.Unmarshal(files[CollectionsIndex], &collections))
+	require.Len(t, collections, 2)
+	assert.Equal(t, "my_collection", collections[0].Name)
+	require.Len(t, collections[0].Versions, 1)
+	assert.Equal(t, "2.0.0-rc.1", collections[0].Versions[0].PkgVersion)
+	assert.Equal(t, "collection", collections[1].Name)
+	assert.Contains(t, files, "collections/other/collection.json")
+}
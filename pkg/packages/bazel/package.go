@@ -0,0 +1,280 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bazel
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	ArchivesDir = "archives"
+
+	moduleFile = "MODULE.bazel"
+)
+
+var (
+	ErrInvalidArchive = errors.New("source archive is invalid")
+	ErrMissingModule  = errors.New("MODULE.bazel file is missing")
+	ErrInvalidModule  = errors.New("MODULE.bazel file is invalid")
+
+	// namePattern matches the module names
+	// https://bazel.build/rules/lib/globals/module#module
+	namePattern    = regexp.MustCompile(`\A[a-z]([a-z0-9._-]*[a-z0-9])?\z`)
+	versionPattern = regexp.MustCompile(`\A[a-zA-Z0-9.+-]+\z`)
+
+	moduleCall  = regexp.MustCompile(`(?s)(?:\A|\n)\s*module\s*\((.*?)\)`)
+	nameAttr    = regexp.MustCompile(`(?:\A|[\s,(])name\s*=\s*["']([^"']*)["']`)
+	versionAttr = regexp.MustCompile(`(?:\A|[\s,(])version\s*=\s*["']([^"']*)["']`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName     string `json:"name"`
+	PkgVersion  string `json:"version"`
+	Module      string `json:"module"`
+	ArchiveType string `json:"archiveType"`
+	StripPrefix string `json:"stripPrefix,omitempty"`
+
+	Published time.Time `json:"published"`
+	PkgSize   int64     `json:"size"`
+	FilePath  string    `json:"filePath"`
+	SHA256    string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// Integrity returns the archive subresource integrity, e.g. sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=
+func (p *Package) Integrity() string {
+	b, _ := hex.DecodeString(p.SHA256)
+	return "sha256-" + base64.StdEncoding.EncodeToString(b)
+}
+
+// ArchivePath returns the source archive path, e.g. archives/rules_foo/1.0.0/rules_foo-1.0.0.tar.gz
+func ArchivePath(name, version, typ string) string {
+	return path.Join(ArchivesDir, name, version, fmt.Sprintf("%s-%s.%s", name, version, typ))
+}
+
+// NewPackage creates a package from a tar.gz or zip source archive. The MODULE.bazel file is read from the archive
+// root when not provided, and the version from the MODULE.bazel file when empty.
+func NewPackage(r io.Reader, module []byte, version string) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := newPackage(reader, module, version)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return pkg, nil
+}
+
+func newPackage(reader *buffer.HashedBuffer, module []byte, version string) (*Package, error) {
+	typ, files, err := readArchive(reader)
+	if err != nil {
+		return nil, err
+	}
+	prefix := stripPrefix(files)
+	if module == nil {
+		b, ok := files[path.Join(prefix, moduleFile)]
+		if !ok {
+			return nil, ErrMissingModule
+		}
+		module = b
+	}
+	name, v, err := ParseModule(module)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case version == "":
+		version = v
+	case v != "" && v != version:
+		return nil, fmt.Errorf("%w: version %q does not match %q", ErrInvalidModule, v, version)
+	}
+	if !versionPattern.MatchString(version) {
+		return nil, fmt.Errorf("%w: invalid version %q", ErrInvalidModule, version)
+	}
+	// bazel expects the served MODULE.bazel file to declare the module version
+	if v == "" {
+		i := moduleCall.FindSubmatchIndex(module)[2]
+		module = append(module[:i:i], append([]byte(fmt.Sprintf("version = %q, ", version)), module[i:]...)...)
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	_, _, sum, _ := reader.Sums()
+	return &Package{
+		PkgName:     name,
+		PkgVersion:  version,
+		Module:      string(module),
+		ArchiveType: typ,
+		StripPrefix: prefix,
+		Published:   time.Now().UTC(),
+		PkgSize:     reader.Size(),
+		FilePath:    ArchivePath(name, version, typ),
+		SHA256:      hex.EncodeToString(sum),
+		reader:      reader,
+	}, nil
+}
+
+// ParseModule returns the module name and version declared by the module() directive
+func ParseModule(b []byte) (name, version string, err error) {
+	m := moduleCall.FindSubmatch(b)
+	if m == nil {
+		return "", "", fmt.Errorf("%w: missing module directive", ErrInvalidModule)
+	}
+	if n := nameAttr.FindSubmatch(m[1]); n != nil {
+		name = string(n[1])
+	}
+	if !namePattern.MatchString(name) {
+		return "", "", fmt.Errorf("%w: invalid name %q", ErrInvalidModule, name)
+	}
+	if v := versionAttr.FindSubmatch(m[1]); v != nil {
+		version = string(v[1])
+	}
+	return name, version, nil
+}
+
+// readArchive returns the archive type and the MODULE.bazel files it contains by path,
+// the other files being listed with a nil content
+func readArchive(r *buffer.HashedBuffer) (string, map[string][]byte, error) {
+	files := make(map[string][]byte)
+	add := func(name string, open func() (io.ReadCloser, error)) error {
+		name = strings.TrimPrefix(path.Clean("/"+name), "/")
+		if path.Base(name) != moduleFile {
+			files[name] = nil
+			return nil
+		}
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		files[name] = b
+		return nil
+	}
+	if zr, err := zip.NewReader(r, r.Size()); err == nil {
+		for _, v := range zr.File {
+			if v.FileInfo().IsDir() {
+				continue
+			}
+			if err := add(v.Name, v.Open); err != nil {
+				return "", nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+			}
+		}
+		return "zip", files, nil
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: expected a tar.gz or zip archive", ErrInvalidArchive)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := add(h.Name, func() (io.ReadCloser, error) {
+			return io.NopCloser(tr), nil
+		}); err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+	}
+	return "tar.gz", files, nil
+}
+
+// stripPrefix returns the top level directory when all the archive files are in the same one,
+// e.g. rules_foo-1.0.0 for the github source archives
+func stripPrefix(files map[string][]byte) string {
+	var prefix string
+	for k := range files {
+		dir, _, ok := strings.Cut(k, "/")
+		if !ok || (prefix != "" && dir != prefix) {
+			return ""
+		}
+		prefix = dir
+	}
+	return prefix
+}
@@ -0,0 +1,177 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bazel
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTarball(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return b.Bytes()
+}
+
+func testZip(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func TestNewPackage(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		module     string
+		version    string
+		wantModule string
+		wantType   string
+		wantPrefix string
+		wantPath   string
+	}{
+		{
+			name: "github tarball",
+			data: testTarball(t, map[string]string{
+				"rules_foo-1.0.0/MODULE.bazel": "module(\n    name = \"rules_foo\",\n    version = \"1.0.0\",\n)\n",
+				"rules_foo-1.0.0/BUILD.bazel":  "",
+			}),
+			wantModule: "module(\n    name = \"rules_foo\",\n    version = \"1.0.0\",\n)\n",
+			wantType:   "tar.gz",
+			wantPrefix: "rules_foo-1.0.0",
+			wantPath:   "archives/rules_foo/1.0.0/rules_foo-1.0.0.tar.gz",
+		},
+		{
+			name: "zip without version",
+			data: testZip(t, map[string]string{
+				"MODULE.bazel":         `module(name = "rules_foo")`,
+				"foo/BUILD.bazel":      "",
+				"foo/bar/MODULE.bazel": `module(name = "rules_bar")`,
+			}),
+			version:    "1.1.0",
+			wantModule: `module(version = "1.1.0", name = "rules_foo")`,
+			wantType:   "zip",
+			wantPath:   "archives/rules_foo/1.1.0/rules_foo-1.1.0.zip",
+		},
+		{
+			name:       "module file",
+			data:       testTarball(t, map[string]string{"BUILD.bazel": ""}),
+			module:     `module(name = 'rules_foo', version = '2.0.0-rc.1')`,
+			wantModule: `module(name = 'rules_foo', version = '2.0.0-rc.1')`,
+			wantType:   "tar.gz",
+			wantPath:   "archives/rules_foo/2.0.0-rc.1/rules_foo-2.0.0-rc.1.tar.gz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var module []byte
+			if tt.module != "" {
+				module = []byte(tt.module)
+			}
+			pkg, err := NewPackage(bytes.NewReader(tt.data), module, tt.version)
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, "rules_foo", pkg.Name())
+			assert.Equal(t, tt.wantModule, pkg.Module)
+			assert.Equal(t, tt.wantType, pkg.ArchiveType)
+			assert.Equal(t, tt.wantPrefix, pkg.StripPrefix)
+			assert.Equal(t, tt.wantPath, pkg.Path())
+			assert.Equal(t, int64(len(tt.data)), pkg.Size())
+			assert.Len(t, pkg.SHA256, 64)
+		})
+	}
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		version string
+		wantErr error
+	}{
+		{
+			name:    "not an archive",
+			data:    []byte("not an archive"),
+			wantErr: ErrInvalidArchive,
+		},
+		{
+			name:    "missing module",
+			data:    testTarball(t, map[string]string{"BUILD.bazel": "", "foo/MODULE.bazel": `module(name = "rules_foo")`}),
+			wantErr: ErrMissingModule,
+		},
+		{
+			name:    "missing module directive",
+			data:    testTarball(t, map[string]string{"MODULE.bazel": `bazel_dep(name = "rules_cc", version = "0.0.9")`}),
+			wantErr: ErrInvalidModule,
+		},
+		{
+			name:    "invalid name",
+			data:    testTarball(t, map[string]string{"MODULE.bazel": `module(name = "Rules_Foo", version = "1.0.0")`}),
+			wantErr: ErrInvalidModule,
+		},
+		{
+			name:    "version mismatch",
+			data:    testTarball(t, map[string]string{"MODULE.bazel": `module(name = "rules_foo", version = "1.0.0")`}),
+			version: "1.1.0",
+			wantErr: ErrInvalidModule,
+		},
+		{
+			name:    "missing version",
+			data:    testTarball(t, map[string]string{"MODULE.bazel": `module(name = "rules_foo")`}),
+			wantErr: ErrInvalidModule,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(tt.data), nil, tt.version)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+		})
+	}
+}
+
+func TestParseModule(t *testing.T) {
+	name, version, err := ParseModule([]byte("bazel_dep(name = \"rules_cc\", version = \"0.0.9\")\n\nmodule(\n    compatibility_level = 1,\n    version = \"0.1.0\",\n    name = \"rules_foo\",\n)\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "rules_foo", name)
+	assert.Equal(t, "0.1.0", version)
+}
+
+func TestIntegrity(t *testing.T) {
+	p := &Package{SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}
+	assert.Equal(t, "sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", p.Integrity())
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bazel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "bazel"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// source serves the module source.json file with the archive url resolved against the registry url
+func (p *provider) source(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		file := path.Join(ModuleDir(mux.Vars(r)["name"], mux.Vars(r)["version"]), SourceFile)
+		rc, err := storage.FromContext(ctx).Open(ctx, file)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		defer rc.Close()
+		var s Source
+		if err := json.NewDecoder(rc).Decode(&s); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		s.Resolve(fmt.Sprintf("%s://%s%s", packages.Scheme(r), r.Host, strings.TrimSuffix(r.URL.Path, "/"+file)))
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s); err != nil {
+			logger.C(ctx).WithError(err).Error("failed to write response")
+		}
+	}
+}
+
+// push uploads a source archive, either as the request body or as the "file" form field.
+// The MODULE.bazel file may be sent as the "module" form field when it is not part of the archive,
+// and the version as query parameter when it is not declared in the MODULE.bazel file, e.g. ?version=1.0.0
+func (p *provider) push(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var reader io.ReadCloser = r.Body
+		if file, _, err := r.FormFile("file"); err == nil {
+			reader = file
+		}
+		defer reader.Close()
+		var module []byte
+		if file, _, err := r.FormFile("module"); err == nil {
+			defer file.Close()
+			b, err := io.ReadAll(file)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			module = b
+		}
+		pkg, err := NewPackage(reader, module, r.URL.Query().Get("version"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		// the registry content must not change once published as bazel verifies the archive integrity
+		if _, err := s.Stat(ctx, pkg.Path()); err == nil {
+			http.Error(w, fmt.Sprintf("module %s version %s already exists", pkg.PkgName, pkg.PkgVersion), http.StatusConflict)
+			return
+		} else if !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.Version()).Infof("uploading module")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func archivePath(r *http.Request) string {
+	vars := mux.Vars(r)
+	return path.Join(ArchivesDir, vars["name"], vars["version"], vars["file"])
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/" + RegistryFile,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return RegistryFile
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + ModulesDir + "/{name}/" + MetadataFile,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return path.Join(ModulesDir, mux.Vars(r)["name"], MetadataFile)
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + ModulesDir + "/{name}/{version}/" + moduleFile,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return path.Join(ModuleDir(mux.Vars(r)["name"], mux.Vars(r)["version"]), moduleFile)
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + ModulesDir + "/{name}/{version}/" + SourceFile,
+			Handler: p.source,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/push",
+			Handler: p.push,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + ArchivesDir + "/{name}/{version}/{file}",
+			Handler: packages.Pull(archivePath),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/" + ArchivesDir + "/{name}/{version}/{file}",
+			Handler: packages.Delete(archivePath),
+		},
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bazel
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	RegistryFile = "bazel_registry.json"
+	ModulesDir   = "modules"
+	MetadataFile = "metadata.json"
+	SourceFile   = "source.json"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "bazel"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Bazel Registry", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Registry is the registry root bazel_registry.json file
+// https://bazel.build/external/registry#index_registry
+type Registry struct {
+	Mirrors []string `json:"mirrors"`
+}
+
+// Metadata is the modules/{name}/metadata.json file
+type Metadata struct {
+	Homepage       string            `json:"homepage"`
+	Maintainers    []any             `json:"maintainers"`
+	Versions       []string          `json:"versions"`
+	YankedVersions map[string]string `json:"yanked_versions"`
+}
+
+// Source is the modules/{name}/{version}/source.json file
+type Source struct {
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	Integrity   string `json:"integrity"`
+	StripPrefix string `json:"strip_prefix,omitempty"`
+}
+
+// Resolve makes the archive url absolute
+func (s *Source) Resolve(base string) {
+	s.URL = base + "/" + s.URL
+}
+
+// ModuleDir returns the module version directory, e.g. modules/rules_foo/1.0.0
+func ModuleDir(name, version string) string {
+	return path.Join(ModulesDir, name, version)
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	names := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.PkgName
+	}))
+	sort.Strings(names)
+	for _, name := range names {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.PkgName == name
+		})
+		sort.Slice(pkgs, func(i, j int) bool {
+			return less(pkgs[i].PkgVersion, pkgs[j].PkgVersion)
+		})
+		for _, v := range pkgs {
+			dir := ModuleDir(v.PkgName, v.PkgVersion)
+			b, err := json.Marshal(Source{
+				Type: "archive",
+				// the url is relative to the registry url
+				URL:         v.FilePath,
+				Integrity:   v.Integrity(),
+				StripPrefix: v.StripPrefix,
+			})
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, storage.NewFile(path.Join(dir, moduleFile), []byte(v.Module)), storage.NewFile(path.Join(dir, SourceFile), b))
+		}
+		b, err := json.Marshal(Metadata{
+			Maintainers: []any{},
+			Versions: slices.Map(pkgs, func(p *Package) string {
+				return p.PkgVersion
+			}),
+			YankedVersions: map[string]string{},
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, storage.NewFile(path.Join(ModulesDir, name, MetadataFile), b))
+	}
+	b, err := json.Marshal(Registry{Mirrors: []string{}})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, storage.NewFile(RegistryFile, b)), nil
+}
+
+// less compares the versions as semantic versions when possible
+func less(a, b string) bool {
+	va, erra := semver.NewVersion(a)
+	vb, errb := semver.NewVersion(b)
+	if erra != nil || errb != nil {
+		return a < b
+	}
+	return va.LessThan(vb)
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bazel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPackage(name, version, prefix string) *Package {
+	return &Package{
+		PkgName:     name,
+		PkgVersion:  version,
+		Module:      `module(name = "` + name + `", version = "` + version + `")`,
+		ArchiveType: "tar.gz",
+		StripPrefix: prefix,
+		FilePath:    ArchivePath(name, version, "tar.gz"),
+		SHA256:      "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}
+}
+
+func TestIndex(t *testing.T) {
+	out, err := (&repo{}).Index(context.Background(), "",
+		testPackage("rules_foo", "1.10.0", "rules_foo-1.10.0"),
+		testPackage("rules_foo", "1.2.0", ""),
+		testPackage("rules_bar", "0.1.0", ""),
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	require.Len(t, files, 9)
+	assert.JSONEq(t, `{"mirrors": []}`, string(files[RegistryFile]))
+
+	var m Metadata
+	require.NoError(t, json.Unmarshal(files["modules/rules_foo/metadata.json"], &m))
+	assert.Equal(t, Metadata{
+		Maintainers:    []any{},
+		Versions:       []string{"1.2.0", "1.10.0"},
+		YankedVersions: map[string]string{},
+	}, m)
+
+	assert.Equal(t, `module(name = "rules_foo", version = "1.10.0")`, string(files["modules/rules_foo/1.10.0/MODULE.bazel"]))
+	var s Source
+	require.NoError(t, json.Unmarshal(files["modules/rules_foo/1.10.0/source.json"], &s))
+	assert.Equal(t, Source{
+		Type:        "archive",
+		URL:         "archives/rules_foo/1.10.0/rules_foo-1.10.0.tar.gz",
+		Integrity:   "sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+		StripPrefix: "rules_foo-1.10.0",
+	}, s)
+	s.Resolve("https://bazel.example.org/repo")
+	assert.Equal(t, "https://bazel.example.org/repo/archives/rules_foo/1.10.0/rules_foo-1.10.0.tar.gz", s.URL)
+
+	assert.NotContains(t, string(files["modules/rules_foo/1.2.0/source.json"]), "strip_prefix")
+	assert.Contains(t, files, "modules/rules_bar/0.1.0/source.json")
+
+	out, err = (&repo{}).Index(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestLess(t *testing.T) {
+	assert.True(t, less("1.2.0", "1.10.0"))
+	assert.True(t, less("1.0.0-rc.1", "1.0.0"))
+	assert.False(t, less("1.10.0", "1.2.0"))
+	// non semantic versions are compared as strings
+	assert.True(t, less("1.10.bcr.1", "1.2.bcr.1"))
+}
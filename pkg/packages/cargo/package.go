@@ -0,0 +1,260 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cargo
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+var (
+	ErrInvalidName     = errors.New("crate name is invalid")
+	ErrInvalidVersion  = errors.New("crate version is invalid")
+	ErrInvalidCrate    = errors.New("crate archive is invalid")
+	ErrInvalidMetadata = errors.New("crate metadata is invalid")
+
+	// https://doc.rust-lang.org/cargo/reference/manifest.html#the-name-field
+	namePattern = regexp.MustCompile(`\A[a-zA-Z][a-zA-Z0-9_-]{0,63}\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName     string              `json:"name"`
+	PkgVersion  string              `json:"version"`
+	Deps        []Dependency        `json:"deps"`
+	Features    map[string][]string `json:"features"`
+	Links       *string             `json:"links,omitempty"`
+	RustVersion string              `json:"rustVersion,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Yanked      bool                `json:"yanked"`
+	Published   time.Time           `json:"published"`
+
+	PkgSize  int64  `json:"size"`
+	FilePath string `json:"filePath"`
+
+	SHA256 string `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+// Dependency is a crate dependency as stored in the index
+// https://doc.rust-lang.org/cargo/reference/registry-index.html#json-schema
+type Dependency struct {
+	Name            string   `json:"name"`
+	Req             string   `json:"req"`
+	Features        []string `json:"features"`
+	Optional        bool     `json:"optional"`
+	DefaultFeatures bool     `json:"default_features"`
+	Target          *string  `json:"target"`
+	Kind            string   `json:"kind"`
+	Registry        *string  `json:"registry"`
+	Package         *string  `json:"package"`
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// CratePath returns the path of the crate file in the repository
+func CratePath(name, version string) string {
+	return path.Join("crates", name, fmt.Sprintf("%s-%s.crate", name, version))
+}
+
+// IndexPath returns the path of the crate's index file using the 1/2/3/prefix directory scheme
+// https://doc.rust-lang.org/cargo/reference/registry-index.html#index-files
+func IndexPath(name string) string {
+	name = strings.ToLower(name)
+	switch len(name) {
+	case 1:
+		return path.Join("1", name)
+	case 2:
+		return path.Join("2", name)
+	case 3:
+		return path.Join("3", name[:1], name)
+	default:
+		return path.Join(name[:2], name[2:4], name)
+	}
+}
+
+// https://doc.rust-lang.org/cargo/reference/registry-web-api.html#publish
+type publishMetadata struct {
+	Name string `json:"name"`
+	Vers string `json:"vers"`
+	Deps []struct {
+		Name               string   `json:"name"`
+		VersionReq         string   `json:"version_req"`
+		Features           []string `json:"features"`
+		Optional           bool     `json:"optional"`
+		DefaultFeatures    bool     `json:"default_features"`
+		Target             *string  `json:"target"`
+		Kind               string   `json:"kind"`
+		Registry           *string  `json:"registry"`
+		ExplicitNameInToml *string  `json:"explicit_name_in_toml"`
+	} `json:"deps"`
+	Features    map[string][]string `json:"features"`
+	Description *string             `json:"description"`
+	Links       *string             `json:"links"`
+	RustVersion *string             `json:"rust_version"`
+}
+
+// NewPackage parses a cargo publish request body: the json metadata and the crate file,
+// each one prefixed by its 32 bits little endian length.
+func NewPackage(r io.Reader) (*Package, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	var m publishMetadata
+	if err := json.NewDecoder(io.LimitReader(r, int64(n))).Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCrate, err)
+	}
+	if !namePattern.MatchString(m.Name) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidName, m.Name)
+	}
+	v, err := semver.StrictNewVersion(m.Vers)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVersion, err)
+	}
+	reader, err := buffer.CreateHashedBufferFromReader(io.LimitReader(r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if reader.Size() != int64(n) {
+		return nil, fmt.Errorf("%w: unexpected end of file", ErrInvalidCrate)
+	}
+	if err := checkCrate(reader, reader.Size(), m.Name, v.Original()); err != nil {
+		return nil, err
+	}
+	pkg := &Package{
+		PkgName:    m.Name,
+		PkgVersion: v.Original(),
+		Features:   m.Features,
+		Links:      m.Links,
+		Published:  time.Now().UTC(),
+		PkgSize:    reader.Size(),
+		FilePath:   CratePath(m.Name, v.Original()),
+		reader:     reader,
+	}
+	if m.Description != nil {
+		pkg.Description = *m.Description
+	}
+	if m.RustVersion != nil {
+		pkg.RustVersion = *m.RustVersion
+	}
+	if pkg.Features == nil {
+		pkg.Features = make(map[string][]string)
+	}
+	for _, v := range m.Deps {
+		d := Dependency{
+			Name:            v.Name,
+			Req:             v.VersionReq,
+			Features:        v.Features,
+			Optional:        v.Optional,
+			DefaultFeatures: v.DefaultFeatures,
+			Target:          v.Target,
+			Kind:            v.Kind,
+			Registry:        v.Registry,
+		}
+		// the index uses the renamed dependency name and the original one as package
+		if v.ExplicitNameInToml != nil && *v.ExplicitNameInToml != "" {
+			d.Name = *v.ExplicitNameInToml
+			d.Package = &v.Name
+		}
+		if d.Features == nil {
+			d.Features = []string{}
+		}
+		pkg.Deps = append(pkg.Deps, d)
+	}
+	_, _, sha256, _ := reader.Sums()
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+// checkCrate verifies that the crate is a gzipped tarball containing the {name}-{version}/Cargo.toml file
+func checkCrate(r io.ReaderAt, size int64, name, version string) error {
+	gzr, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCrate, err)
+	}
+	tr := tar.NewReader(gzr)
+	want := fmt.Sprintf("%s-%s/Cargo.toml", name, version)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%w: %s not found", ErrInvalidCrate, want)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCrate, err)
+		}
+		if hd.Name == want {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cargo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCrate(t *testing.T, files ...string) []byte {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for _, name := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}))
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return b.Bytes()
+}
+
+func testPublishBody(metadata string, crate []byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, uint32(len(metadata)))
+	b.WriteString(metadata)
+	binary.Write(&b, binary.LittleEndian, uint32(len(crate)))
+	b.Write(crate)
+	return b.Bytes()
+}
+
+func TestIndexPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "a", want: "1/a"},
+		{name: "ab", want: "2/ab"},
+		{name: "abc", want: "3/a/abc"},
+		{name: "Cargo", want: "ca/rg/cargo"},
+		{name: "serde_json", want: "se/rd/serde_json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IndexPath(tt.name))
+		})
+	}
+}
+
+func TestNewPackage(t *testing.T) {
+	const metadata = `{
+		"name": "example",
+		"vers": "1.0.0",
+		"deps": [
+			{"name": "serde", "version_req": "^1.0", "features": ["derive"], "default_features": true, "kind": "normal"},
+			{"name": "rand_core", "version_req": "^0.6", "optional": true, "kind": "dev", "explicit_name_in_toml": "rand"}
+		],
+		"features": {"default": ["serde"]},
+		"description": "An example crate",
+		"rust_version": "1.70"
+	}`
+	body := testPublishBody(metadata, testCrate(t, "example-1.0.0/Cargo.toml", "example-1.0.0/src/lib.rs"))
+	pkg, err := NewPackage(bytes.NewReader(body))
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, "example", pkg.Name())
+	assert.Equal(t, "1.0.0", pkg.Version())
+	assert.Equal(t, "crates/example/example-1.0.0.crate", pkg.Path())
+	assert.Equal(t, "An example crate", pkg.Description)
+	assert.Equal(t, "1.70", pkg.RustVersion)
+	assert.Equal(t, map[string][]string{"default": {"serde"}}, pkg.Features)
+	require.Len(t, pkg.Deps, 2)
+	assert.Equal(t, Dependency{Name: "serde", Req: "^1.0", Features: []string{"derive"}, DefaultFeatures: true, Kind: "normal"}, pkg.Deps[0])
+	// renamed dependencies are indexed with their alias and the original crate name as package
+	assert.Equal(t, "rand", pkg.Deps[1].Name)
+	require.NotNil(t, pkg.Deps[1].Package)
+	assert.Equal(t, "rand_core", *pkg.Deps[1].Package)
+	assert.Equal(t, []string{}, pkg.Deps[1].Features)
+	assert.Len(t, pkg.SHA256, 64)
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	crate := testCrate(t, "example-1.0.0/Cargo.toml")
+	tests := []struct {
+		name    string
+		body    []byte
+		wantErr error
+	}{
+		{
+			name:    "empty",
+			wantErr: ErrInvalidMetadata,
+		},
+		{
+			name:    "invalid metadata",
+			body:    testPublishBody("{", crate),
+			wantErr: ErrInvalidMetadata,
+		},
+		{
+			name:    "invalid name",
+			body:    testPublishBody(`{"name":"1example","vers":"1.0.0"}`, crate),
+			wantErr: ErrInvalidName,
+		},
+		{
+			name:    "invalid version",
+			body:    testPublishBody(`{"name":"example","vers":"1.0"}`, crate),
+			wantErr: ErrInvalidVersion,
+		},
+		{
+			name:    "truncated crate",
+			body:    testPublishBody(`{"name":"example","vers":"1.0.0"}`, crate)[:40],
+			wantErr: ErrInvalidCrate,
+		},
+		{
+			name:    "missing manifest",
+			body:    testPublishBody(`{"name":"example","vers":"1.0.0"}`, testCrate(t, "other-1.0.0/Cargo.toml")),
+			wantErr: ErrInvalidCrate,
+		},
+		{
+			name:    "not a tarball",
+			body:    testPublishBody(`{"name":"example","vers":"1.0.0"}`, []byte("not a crate")),
+			wantErr: ErrInvalidCrate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(tt.body))
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cargo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "cargo"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// Config is the sparse registry configuration
+// https://doc.rust-lang.org/cargo/reference/registry-index.html#index-configuration
+type Config struct {
+	DL           string `json:"dl"`
+	API          string `json:"api"`
+	AuthRequired bool   `json:"auth-required"`
+}
+
+// config serves the registry configuration with the urls resolved against the request url.
+// As cargo sends the token as is in the Authorization header, the registry credentials
+// must be configured as a basic auth header value, e.g. cargo login "Basic $(echo -n user:pass | base64)".
+// Cargo only sends its token for the config after an unauthorized response, so a request carrying
+// credentials means that the repository is not anonymously readable and the token is required everywhere.
+func (p *provider) config(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		base := fmt.Sprintf("%s://%s%s", packages.Scheme(r), r.Host, strings.TrimSuffix(r.URL.Path, "/config.json"))
+		_, _, ok := r.BasicAuth()
+		writeJSON(w, http.StatusOK, Config{
+			DL:           base + "/crates/{crate}/{crate}-{version}.crate",
+			API:          base,
+			AuthRequired: ok,
+		})
+	}
+}
+
+func (p *provider) index(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := path.Join(mux.Vars(r)["prefix"], mux.Vars(r)["name"])
+		if IndexPath(mux.Vars(r)["name"]) != name {
+			http.NotFound(w, r)
+			return
+		}
+		if err := storage.FromContext(r.Context()).ServeFile(w, r, path.Join(IndexDir, name)); err != nil {
+			storage.Error(w, err)
+		}
+	}
+}
+
+// https://doc.rust-lang.org/cargo/reference/registry-web-api.html#publish
+func (p *provider) publish(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		pkg, err := NewPackage(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		defer pkg.Close()
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		as, err := s.Artifacts(ctx)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		for _, v := range storage.MustAs[*Package](as) {
+			if normalize(v.PkgName) != normalize(pkg.PkgName) {
+				continue
+			}
+			if v.PkgName != pkg.PkgName {
+				writeError(w, http.StatusConflict, fmt.Errorf("crate %s conflicts with the existing crate %s", pkg.PkgName, v.PkgName))
+				return
+			}
+			// versions differing only by their build metadata are the same version
+			if strings.Split(v.PkgVersion, "+")[0] == strings.Split(pkg.PkgVersion, "+")[0] {
+				writeError(w, http.StatusConflict, fmt.Errorf("crate version %s@%s already exists", v.PkgName, v.PkgVersion))
+				return
+			}
+		}
+		logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.Version()).Infof("publishing crate")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"warnings": map[string][]string{
+				"invalid_categories": {},
+				"invalid_badges":     {},
+				"other":              {},
+			},
+		})
+	}
+}
+
+// yank marks the crate version as yanked or not by rewriting its artifact
+// https://doc.rust-lang.org/cargo/reference/registry-web-api.html#yank
+func (p *provider) yank(yanked bool) packages.HandlerFunc {
+	return func(_ string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			s := storage.FromContext(ctx)
+			i, err := s.Stat(ctx, CratePath(mux.Vars(r)["name"], mux.Vars(r)["version"]))
+			if err != nil {
+				storage.Error(w, err)
+				return
+			}
+			var pkg Package
+			if err := json.Unmarshal(i.Meta(), &pkg); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if pkg.Yanked != yanked {
+				if pkg.reader, err = s.Open(ctx, pkg.Path()); err != nil {
+					storage.Error(w, err)
+					return
+				}
+				defer pkg.Close()
+				pkg.Yanked = yanked
+				logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.Version(), "yanked", yanked).Infof("updating crate")
+				if err := s.Write(ctx, &pkg); err != nil {
+					storage.Error(w, err)
+					return
+				}
+			}
+			writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+		}
+	}
+}
+
+func cratePath(r *http.Request) string {
+	return path.Join("crates", mux.Vars(r)["name"], mux.Vars(r)["file"])
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/config.json",
+			Handler: p.config,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/api/v1/crates/new",
+			Handler: p.publish,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/api/v1/crates/{name}/{version}/yank",
+			Handler: p.yank(true),
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/api/v1/crates/{name}/{version}/unyank",
+			Handler: p.yank(false),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/crates/{name}/{file}",
+			Handler: packages.Pull(cratePath),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/crates/{name}/{file}",
+			Handler: packages.Delete(cratePath),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/{prefix:1|2|3/[^/]|[^/]{2}/[^/]{2}}/{name}",
+			Handler: p.index,
+		},
+	}
+}
+
+// normalize returns the name used to detect conflicting crate names
+func normalize(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error in the format displayed by cargo
+// https://doc.rust-lang.org/cargo/reference/registry-web-api.html#web-api
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]any{
+		"errors": []map[string]string{{"detail": err.Error()}},
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cargo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	IndexDir = "index"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "cargo"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Cargo Registry", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// IndexEntry is a crate version entry of the index
+// https://doc.rust-lang.org/cargo/reference/registry-index.html#json-schema
+type IndexEntry struct {
+	Name        string              `json:"name"`
+	Vers        string              `json:"vers"`
+	Deps        []Dependency        `json:"deps"`
+	Cksum       string              `json:"cksum"`
+	Features    map[string][]string `json:"features"`
+	Features2   map[string][]string `json:"features2,omitempty"`
+	Yanked      bool                `json:"yanked"`
+	Links       *string             `json:"links"`
+	V           int                 `json:"v"`
+	RustVersion string              `json:"rust_version,omitempty"`
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	paths := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return IndexPath(p.PkgName)
+	}))
+	sort.Strings(paths)
+	for _, p := range paths {
+		pkgs := slices.Filter(pkgs, func(v *Package) bool {
+			return IndexPath(v.PkgName) == p
+		})
+		// entries are listed in publication order
+		sort.Slice(pkgs, func(i, j int) bool {
+			return pkgs[i].Published.Before(pkgs[j].Published)
+		})
+		var buf bytes.Buffer
+		for _, v := range pkgs {
+			if err := json.NewEncoder(&buf).Encode(newIndexEntry(v)); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, storage.NewFile(path.Join(IndexDir, p), buf.Bytes()))
+	}
+	return out, nil
+}
+
+func newIndexEntry(p *Package) IndexEntry {
+	e := IndexEntry{
+		Name:        p.PkgName,
+		Vers:        p.PkgVersion,
+		Deps:        p.Deps,
+		Cksum:       p.SHA256,
+		Features:    make(map[string][]string),
+		Yanked:      p.Yanked,
+		Links:       p.Links,
+		V:           1,
+		RustVersion: p.RustVersion,
+	}
+	if e.Deps == nil {
+		e.Deps = []Dependency{}
+	}
+	// features using the "dep:" or "?/" syntax are not understood by older cargo versions
+	for k, v := range p.Features {
+		if !isFeature2(v) {
+			e.Features[k] = v
+			continue
+		}
+		if e.Features2 == nil {
+			e.Features2 = make(map[string][]string)
+		}
+		e.Features2[k] = v
+		e.V = 2
+	}
+	return e
+}
+
+func isFeature2(features []string) bool {
+	for _, v := range features {
+		if strings.HasPrefix(v, "dep:") || strings.Contains(v, "?/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cargo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	out, err := (&repo{}).Index(context.Background(), "",
+		&Package{PkgName: "example", PkgVersion: "1.1.0", Published: now.Add(time.Hour), SHA256: "sum-1.1.0", Yanked: true, Features: map[string][]string{
+			"default": {"std"},
+			"std":     {},
+			"serde":   {"dep:serde", "other?/serde"},
+		}},
+		&Package{PkgName: "example", PkgVersion: "1.0.0", Published: now, SHA256: "sum-1.0.0", RustVersion: "1.70"},
+		&Package{PkgName: "abc", PkgVersion: "0.1.0", Published: now, SHA256: "sum-0.1.0"},
+	)
+	require.NoError(t, err)
+	files := make(map[string][]IndexEntry)
+	for _, v := range out {
+		s := bufio.NewScanner(v)
+		for s.Scan() {
+			var e IndexEntry
+			require.NoError(t, json.Unmarshal(s.Bytes(), &e))
+			files[v.Path()] = append(files[v.Path()], e)
+		}
+		require.NoError(t, s.Err())
+	}
+	require.Len(t, files, 2)
+	assert.Equal(t, []IndexEntry{{Name: "abc", Vers: "0.1.0", Deps: []Dependency{}, Cksum: "sum-0.1.0", Features: map[string][]string{}, V: 1}}, files[IndexDir+"/3/a/abc"])
+
+	entries := files[IndexDir+"/ex/am/example"]
+	require.Len(t, entries, 2)
+	// entries are in publication order
+	assert.Equal(t, IndexEntry{Name: "example", Vers: "1.0.0", Deps: []Dependency{}, Cksum: "sum-1.0.0", Features: map[string][]string{}, V: 1, RustVersion: "1.70"}, entries[0])
+	assert.Equal(t, IndexEntry{
+		Name:      "example",
+		Vers:      "1.1.0",
+		Deps:      []Dependency{},
+		Cksum:     "sum-1.1.0",
+		Features:  map[string][]string{"default": {"std"}, "std": {}},
+		Features2: map[string][]string{"serde": {"dep:serde", "other?/serde"}},
+		Yanked:    true,
+		V:         2,
+	}, entries[1])
+}
+
+func TestIndexEmpty(t *testing.T) {
+	out, err := (&repo{}).Index(context.Background(), "", []storage.Artifact{}...)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
@@ -0,0 +1,256 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package composer
+
+import (
+	"archive/zip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	DistsDir = "dists"
+
+	composerFile = "composer.json"
+)
+
+var (
+	ErrInvalidPackage      = errors.New("composer package is invalid")
+	ErrMissingComposerFile = errors.New("composer.json file is missing")
+	ErrInvalidVersion      = errors.New("version is invalid")
+
+	// namePattern matches the package names
+	// https://getcomposer.org/doc/04-schema.md#name
+	namePattern = regexp.MustCompile(`\A[a-z0-9]([_.-]?[a-z0-9]+)*/[a-z0-9](([_.]|-{1,2})?[a-z0-9]+)*\z`)
+
+	versionPattern = regexp.MustCompile(`(?i)\Av?(\d+)(?:\.(\d+|x|\*))?(?:\.(\d+|x|\*))?(?:\.(\d+|x|\*))?(?:[._-]?(stable|beta|b|rc|alpha|a|patch|pl|p)((?:[.-]?\d+)*))?(?:[.-]?(dev))?\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName           string          `json:"name"`
+	PkgVersion        string          `json:"version"`
+	VersionNormalized string          `json:"versionNormalized"`
+	Composer          json.RawMessage `json:"composer"`
+
+	Published time.Time `json:"published"`
+	PkgSize   int64     `json:"size"`
+	FilePath  string    `json:"filePath"`
+	SHA1      string    `json:"sha1"`
+	SHA256    string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// IsDev reports whether the version is a development one, which is listed in the ~dev metadata file
+func (p *Package) IsDev() bool {
+	return IsDev(p.PkgVersion)
+}
+
+func IsDev(version string) bool {
+	v := strings.ToLower(version)
+	return strings.HasPrefix(v, "dev-") || strings.HasSuffix(v, "-dev")
+}
+
+// PackagePath returns the dist archive path, e.g. dists/vendor/package/1.0.0.zip
+func PackagePath(name, version string) string {
+	return path.Join(DistsDir, name, strings.ReplaceAll(version, "/", "-")+".zip")
+}
+
+// NewPackage creates a package from a zip archive, the version is read from the composer.json file
+// when not provided
+func NewPackage(r io.Reader, version string) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readComposerFile(reader, reader.Size())
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	var m struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	if !namePattern.MatchString(m.Name) {
+		reader.Close()
+		return nil, fmt.Errorf("%w: invalid name %q", ErrInvalidPackage, m.Name)
+	}
+	if version == "" {
+		version = m.Version
+	}
+	normalized, err := NormalizeVersion(version)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	_, sha1, sha256, _ := reader.Sums()
+	pkg := &Package{
+		PkgName:           m.Name,
+		PkgVersion:        version,
+		VersionNormalized: normalized,
+		Composer:          b,
+		Published:         time.Now().UTC(),
+		PkgSize:           reader.Size(),
+		FilePath:          PackagePath(m.Name, version),
+		SHA1:              hex.EncodeToString(sha1),
+		SHA256:            hex.EncodeToString(sha256),
+		reader:            reader,
+	}
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+// readComposerFile returns the composer.json file found at the archive root or in its top level directory
+func readComposerFile(r io.ReaderAt, size int64) ([]byte, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	var file *zip.File
+	for _, v := range zr.File {
+		name := strings.TrimPrefix(v.Name, "./")
+		if path.Base(name) != composerFile || strings.Count(name, "/") > 1 {
+			continue
+		}
+		if file == nil || strings.Count(name, "/") < strings.Count(file.Name, "/") {
+			file = v
+		}
+	}
+	if file == nil {
+		return nil, ErrMissingComposerFile
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	if !json.Valid(b) {
+		return nil, fmt.Errorf("%w: invalid %s", ErrInvalidPackage, composerFile)
+	}
+	return b, nil
+}
+
+// NormalizeVersion returns the version in composer normalized format, e.g. 1.2.0.0 for v1.2,
+// 1.0.0.0-RC1 for 1.0-rc1 or dev-main for the main branch
+// https://github.com/composer/semver/blob/main/src/VersionParser.php
+func NormalizeVersion(version string) (string, error) {
+	if version == "" {
+		return "", fmt.Errorf("%w: version is required", ErrInvalidVersion)
+	}
+	if strings.HasPrefix(strings.ToLower(version), "dev-") {
+		return "dev-" + version[4:], nil
+	}
+	m := versionPattern.FindStringSubmatch(version)
+	if m == nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidVersion, version)
+	}
+	dev := m[7] != ""
+	parts := make([]string, 4)
+	for i := range parts {
+		switch v := m[i+1]; {
+		case v == "":
+			parts[i] = "0"
+			if dev {
+				parts[i] = "9999999"
+			}
+		case v == "x" || v == "X" || v == "*":
+			if !dev {
+				return "", fmt.Errorf("%w: %q", ErrInvalidVersion, version)
+			}
+			parts[i] = "9999999"
+		default:
+			parts[i] = v
+		}
+	}
+	out := strings.Join(parts, ".")
+	if s := strings.ToLower(m[5]); s != "" && s != "stable" {
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "rc":
+			s = "RC"
+		case "p", "pl":
+			s = "patch"
+		}
+		out += "-" + s + strings.TrimLeft(m[6], ".-")
+	}
+	if dev {
+		out += "-dev"
+	}
+	return out, nil
+}
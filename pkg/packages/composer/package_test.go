@@ -0,0 +1,182 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package composer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testZip(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func TestNewPackage(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		version      string
+		wantVersion  string
+		wantNorm     string
+		wantPath     string
+		wantDev      bool
+		wantComposer string
+	}{
+		{
+			name:         "root composer file",
+			files:        map[string]string{"composer.json": `{"name": "vendor/package", "version": "v1.2"}`, "src/a.php": ""},
+			wantVersion:  "v1.2",
+			wantNorm:     "1.2.0.0",
+			wantPath:     "dists/vendor/package/v1.2.zip",
+			wantComposer: `{"name": "vendor/package", "version": "v1.2"}`,
+		},
+		{
+			name:         "top level directory",
+			files:        map[string]string{"package-1.0.0/composer.json": `{"name": "vendor/package"}`, "package-1.0.0/vendor/other/composer.json": `{}`},
+			version:      "1.0.0-rc1",
+			wantVersion:  "1.0.0-rc1",
+			wantNorm:     "1.0.0.0-RC1",
+			wantPath:     "dists/vendor/package/1.0.0-rc1.zip",
+			wantComposer: `{"name": "vendor/package"}`,
+		},
+		{
+			name:         "dev branch",
+			files:        map[string]string{"composer.json": `{"name": "vendor/package", "version": "1.0.0"}`},
+			version:      "dev-feature/x",
+			wantVersion:  "dev-feature/x",
+			wantNorm:     "dev-feature/x",
+			wantPath:     "dists/vendor/package/dev-feature-x.zip",
+			wantDev:      true,
+			wantComposer: `{"name": "vendor/package", "version": "1.0.0"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testZip(t, tt.files)
+			pkg, err := NewPackage(bytes.NewReader(b), tt.version)
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, "vendor/package", pkg.Name())
+			assert.Equal(t, tt.wantVersion, pkg.Version())
+			assert.Equal(t, tt.wantNorm, pkg.VersionNormalized)
+			assert.Equal(t, tt.wantPath, pkg.Path())
+			assert.Equal(t, tt.wantDev, pkg.IsDev())
+			assert.Equal(t, int64(len(b)), pkg.Size())
+			assert.Len(t, pkg.SHA1, 40)
+			assert.Len(t, pkg.SHA256, 64)
+			assert.JSONEq(t, tt.wantComposer, string(pkg.Composer))
+		})
+	}
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		version string
+		wantErr error
+	}{
+		{
+			name:    "not a zip",
+			data:    []byte("not a zip"),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "missing composer file",
+			data:    testZip(t, map[string]string{"a/b/composer.json": `{"name": "vendor/package"}`}),
+			wantErr: ErrMissingComposerFile,
+		},
+		{
+			name:    "invalid composer file",
+			data:    testZip(t, map[string]string{"composer.json": `{`}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "invalid name",
+			data:    testZip(t, map[string]string{"composer.json": `{"name": "Package"}`}),
+			version: "1.0.0",
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "missing version",
+			data:    testZip(t, map[string]string{"composer.json": `{"name": "vendor/package"}`}),
+			wantErr: ErrInvalidVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(tt.data), tt.version)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+		})
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{version: "1", want: "1.0.0.0"},
+		{version: "v1.2.3", want: "1.2.3.0"},
+		{version: "1.2.3.4", want: "1.2.3.4"},
+		{version: "1.0.0-rc1", want: "1.0.0.0-RC1"},
+		{version: "1.0-b2", want: "1.0.0.0-beta2"},
+		{version: "1.0.0-alpha.1", want: "1.0.0.0-alpha1"},
+		{version: "1.0.0-pl3", want: "1.0.0.0-patch3"},
+		{version: "1.0.0-stable", want: "1.0.0.0"},
+		{version: "1.x-dev", want: "1.9999999.9999999.9999999-dev"},
+		{version: "2.1-dev", want: "2.1.9999999.9999999-dev"},
+		{version: "dev-main", want: "dev-main"},
+		{version: "DEV-Main", want: "dev-Main"},
+		{version: "1.x", wantErr: true},
+		{version: "main", wantErr: true},
+		{version: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := NormalizeVersion(tt.version)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidVersion)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsDev(t *testing.T) {
+	assert.True(t, IsDev("dev-main"))
+	assert.True(t, IsDev("1.x-dev"))
+	assert.True(t, IsDev("1.0-DEV"))
+	assert.False(t, IsDev("1.0.0"))
+	assert.False(t, IsDev("1.0.0-rc1"))
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "composer"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// baseURL returns the repository url from the request url and the route path
+func baseURL(r *http.Request, suffix string) string {
+	return fmt.Sprintf("%s://%s%s", packages.Scheme(r), r.Host, strings.TrimSuffix(r.URL.Path, suffix))
+}
+
+func (p *provider) packages(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var i Packages
+		if err := readJSON(ctx, PackagesFile, &i); err != nil {
+			if !storage.IsNotFound(err) {
+				storage.Error(w, err)
+				return
+			}
+			// an empty repository is still a valid one
+			i = Packages{Packages: []string{}, MetadataURL: path.Join(MetadataDir, "%package%.json"), AvailablePackages: []string{}}
+		}
+		i.Resolve(baseURL(r, "/"+PackagesFile))
+		writeJSON(ctx, w, i)
+	}
+}
+
+// metadata serves the package metadata
+// https://getcomposer.org/doc/05-repositories.md#metadata-url
+func (p *provider) metadata(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		name := path.Join(mux.Vars(r)["vendor"], mux.Vars(r)["package"])
+		file := path.Join(MetadataDir, name+".json")
+		var m Metadata
+		if err := readJSON(ctx, file, &m); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		m.Resolve(baseURL(r, "/"+file))
+		writeJSON(ctx, w, m)
+	}
+}
+
+func readJSON(ctx context.Context, name string, v any) error {
+	rc, err := storage.FromContext(ctx).Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.C(ctx).WithError(err).Error("failed to write response")
+	}
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + PackagesFile,
+			Handler: p.packages,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + MetadataDir + "/{vendor}/{package}.json",
+			Handler: p.metadata,
+		},
+		{
+			// the version may be passed as query parameter when missing from the composer.json file, e.g. ?version=1.0.0
+			Method: http.MethodPut,
+			Path:   "/push",
+			Handler: packages.Push(func(r *http.Request, reader io.Reader, key string) (storage.Artifact, error) {
+				return NewPackage(reader, r.URL.Query().Get("version"))
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + DistsDir + "/{vendor}/{package}/{file}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				return path.Join(DistsDir, mux.Vars(r)["vendor"], mux.Vars(r)["package"], mux.Vars(r)["file"])
+			}),
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/" + DistsDir + "/{vendor}/{package}/{file}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				return path.Join(DistsDir, mux.Vars(r)["vendor"], mux.Vars(r)["package"], mux.Vars(r)["file"])
+			}),
+		},
+	}
+}
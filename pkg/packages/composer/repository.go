@@ -0,0 +1,169 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	PackagesFile = "packages.json"
+	MetadataDir  = "p2"
+	DevSuffix    = "~dev"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "composer"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Composer Repository", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Packages is the repository root packages.json file
+// https://getcomposer.org/doc/05-repositories.md#packages
+type Packages struct {
+	Packages          []string `json:"packages"`
+	MetadataURL       string   `json:"metadata-url"`
+	AvailablePackages []string `json:"available-packages"`
+}
+
+// Resolve makes the metadata url absolute
+func (p *Packages) Resolve(base string) {
+	p.MetadataURL = base + "/" + p.MetadataURL
+}
+
+// Metadata is the content of the p2/{vendor}/{package}.json files
+type Metadata struct {
+	Packages map[string][]map[string]any `json:"packages"`
+}
+
+// Resolve makes the dist urls absolute
+func (m *Metadata) Resolve(base string) {
+	for _, vs := range m.Packages {
+		for _, v := range vs {
+			if d, ok := v["dist"].(map[string]any); ok {
+				d["url"] = base + "/" + d["url"].(string)
+			}
+		}
+	}
+}
+
+// MetadataPath returns the path of the package metadata file, e.g. p2/vendor/package.json or p2/vendor/package~dev.json
+func MetadataPath(name string, dev bool) string {
+	if dev {
+		name += DevSuffix
+	}
+	return path.Join(MetadataDir, name+".json")
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	names := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.PkgName
+	}))
+	sort.Strings(names)
+	for _, name := range names {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.PkgName == name
+		})
+		sort.Slice(pkgs, func(i, j int) bool {
+			return pkgs[i].Published.After(pkgs[j].Published)
+		})
+		for _, dev := range []bool{false, true} {
+			m := Metadata{Packages: map[string][]map[string]any{name: {}}}
+			for _, v := range pkgs {
+				if v.IsDev() != dev {
+					continue
+				}
+				e, err := v.metadata()
+				if err != nil {
+					return nil, err
+				}
+				m.Packages[name] = append(m.Packages[name], e)
+			}
+			b, err := json.Marshal(m)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, storage.NewFile(MetadataPath(name, dev), b))
+		}
+	}
+	b, err := json.Marshal(Packages{
+		Packages:          []string{},
+		MetadataURL:       path.Join(MetadataDir, "%package%.json"),
+		AvailablePackages: names,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, storage.NewFile(PackagesFile, b)), nil
+}
+
+// metadata returns the composer.json content completed with the version and dist information
+func (p *Package) metadata() (map[string]any, error) {
+	m := make(map[string]any)
+	if err := json.Unmarshal(p.Composer, &m); err != nil {
+		return nil, err
+	}
+	m["name"] = p.PkgName
+	m["version"] = p.PkgVersion
+	m["version_normalized"] = p.VersionNormalized
+	m["time"] = p.Published
+	m["dist"] = map[string]any{
+		"type":      "zip",
+		"url":       p.FilePath,
+		"shasum":    p.SHA1,
+		"reference": p.SHA256,
+	}
+	return m, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package composer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPackage(name, version string, published time.Time) *Package {
+	normalized, _ := NormalizeVersion(version)
+	return &Package{
+		PkgName:           name,
+		PkgVersion:        version,
+		VersionNormalized: normalized,
+		Composer:          json.RawMessage(`{"name": "` + name + `", "description": "A package", "require": {"php": ">=8.1"}}`),
+		Published:         published,
+		FilePath:          PackagePath(name, version),
+		SHA1:              "sha1-" + version,
+		SHA256:            "sha256-" + version,
+	}
+}
+
+func TestIndex(t *testing.T) {
+	now := time.Now().UTC()
+	out, err := (&repo{}).Index(context.Background(), "",
+		testPackage("vendor/a", "1.0.0", now.Add(-2*time.Hour)),
+		testPackage("vendor/a", "1.1.0", now.Add(-time.Hour)),
+		testPackage("vendor/a", "dev-main", now),
+		testPackage("vendor/b", "0.1.0", now),
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	assert.Len(t, files, 5)
+
+	var p Packages
+	require.NoError(t, json.Unmarshal(files[PackagesFile], &p))
+	assert.Equal(t, Packages{
+		Packages:          []string{},
+		MetadataURL:       "p2/%package%.json",
+		AvailablePackages: []string{"vendor/a", "vendor/b"},
+	}, p)
+	p.Resolve("https://example.org/composer/repo")
+	assert.Equal(t, "https://example.org/composer/repo/p2/%package%.json", p.MetadataURL)
+
+	var m Metadata
+	require.NoError(t, json.Unmarshal(files[MetadataPath("vendor/a", false)], &m))
+	require.Len(t, m.Packages["vendor/a"], 2)
+	v := m.Packages["vendor/a"][0]
+	assert.Equal(t, "1.1.0", v["version"])
+	assert.Equal(t, "1.1.0.0", v["version_normalized"])
+	assert.Equal(t, "A package", v["description"])
+	assert.Equal(t, map[string]any{"php": ">=8.1"}, v["require"])
+	assert.Equal(t, map[string]any{
+		"type":      "zip",
+		"url":       "dists/vendor/a/1.1.0.zip",
+		"shasum":    "sha1-1.1.0",
+		"reference": "sha256-1.1.0",
+	}, v["dist"])
+	assert.Equal(t, "1.0.0", m.Packages["vendor/a"][1]["version"])
+	m.Resolve("https://example.org/composer/repo")
+	assert.Equal(t, "https://example.org/composer/repo/dists/vendor/a/1.1.0.zip", v["dist"].(map[string]any)["url"])
+
+	m = Metadata{}
+	require.NoError(t, json.Unmarshal(files[MetadataPath("vendor/a", true)], &m))
+	require.Len(t, m.Packages["vendor/a"], 1)
+	assert.Equal(t, "dev-main", m.Packages["vendor/a"][0]["version"])
+
+	m = Metadata{}
+	require.NoError(t, json.Unmarshal(files["p2/vendor/b~dev.json"], &m))
+	assert.Equal(t, map[string][]map[string]any{"vendor/b": {}}, m.Packages)
+
+	out, err = (&repo{}).Index(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestMetadataPath(t *testing.T) {
+	assert.Equal(t, "p2/vendor/package.json", MetadataPath("vendor/package", false))
+	assert.Equal(t, "p2/vendor/package~dev.json", MetadataPath("vendor/package", true))
+}
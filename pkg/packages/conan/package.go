@@ -0,0 +1,289 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RevisionsDir = "revisions"
+	FilesDir     = "files"
+	PackagesDir  = "packages"
+
+	// ManifestFile is uploaded last by the conan client, a revision is only listed once its manifest exists
+	ManifestFile = "conanmanifest.txt"
+	InfoFile     = "conaninfo.txt"
+
+	// NoValue is the value used in the urls for the references without user and channel
+	NoValue = "_"
+)
+
+var (
+	ErrInvalidReference = errors.New("conan reference is invalid")
+	ErrInvalidRevision  = errors.New("conan revision is invalid")
+	ErrInvalidFile      = errors.New("conan file is invalid")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// namePattern matches the reference components
+	// https://docs.conan.io/2/reference/conanfile/attributes.html#name
+	namePattern     = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9_+.-]{1,100}\z`)
+	revisionPattern = regexp.MustCompile(`\A[a-zA-Z0-9]{1,64}\z`)
+	filePattern     = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9_.+-]*\z`)
+)
+
+// Reference is a recipe reference, e.g. zlib/1.2.13 or zlib/1.2.13@user/channel
+type Reference struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	User    string `json:"user,omitempty"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// NewReference creates a reference from the url components, where the user and channel may be "_"
+func NewReference(name, version, user, channel string) (Reference, error) {
+	ref := Reference{Name: name, Version: version}
+	if user != NoValue {
+		ref.User = user
+	}
+	if channel != NoValue {
+		ref.Channel = channel
+	}
+	return ref, ref.Validate()
+}
+
+func (r Reference) Validate() error {
+	for _, v := range []string{r.Name, r.Version} {
+		if !namePattern.MatchString(v) {
+			return fmt.Errorf("%w: %q", ErrInvalidReference, r)
+		}
+	}
+	if (r.User == "") != (r.Channel == "") {
+		return fmt.Errorf("%w: %q: user and channel must be set together", ErrInvalidReference, r)
+	}
+	for _, v := range []string{r.User, r.Channel} {
+		if v != "" && !namePattern.MatchString(v) {
+			return fmt.Errorf("%w: %q", ErrInvalidReference, r)
+		}
+	}
+	return nil
+}
+
+func (r Reference) String() string {
+	if r.User == "" {
+		return r.Name + "/" + r.Version
+	}
+	return r.Name + "/" + r.Version + "@" + r.User + "/" + r.Channel
+}
+
+// Dir returns the reference directory, e.g. zlib/1.2.13/_/_
+func (r Reference) Dir() string {
+	return path.Join(r.Name, r.Version, defaults(r.User), defaults(r.Channel))
+}
+
+func defaults(v string) string {
+	if v == "" {
+		return NoValue
+	}
+	return v
+}
+
+// RecipeFilePath returns the recipe revision file path, e.g. zlib/1.2.13/_/_/revisions/{rrev}/files/conanfile.py
+func RecipeFilePath(ref Reference, rrev, file string) string {
+	return path.Join(ref.Dir(), RevisionsDir, rrev, FilesDir, file)
+}
+
+// PackageFilePath returns the package revision file path,
+// e.g. zlib/1.2.13/_/_/revisions/{rrev}/packages/{package_id}/revisions/{prev}/files/conan_package.tgz
+func PackageFilePath(ref Reference, rrev, pkgID, prev, file string) string {
+	return path.Join(ref.Dir(), RevisionsDir, rrev, PackagesDir, pkgID, RevisionsDir, prev, FilesDir, file)
+}
+
+// Info holds the package binary configuration read from the conaninfo.txt file
+type Info struct {
+	Settings map[string]string `json:"settings"`
+	Options  map[string]string `json:"options"`
+	Requires []string          `json:"requires"`
+}
+
+var _ storage.Artifact = (*Package)(nil)
+
+// Package is a recipe or package revision file
+type Package struct {
+	Ref             Reference `json:"reference"`
+	RecipeRevision  string    `json:"recipeRevision"`
+	PackageID       string    `json:"packageId,omitempty"`
+	PackageRevision string    `json:"packageRevision,omitempty"`
+	FileName        string    `json:"fileName"`
+	Info            *Info     `json:"info,omitempty"`
+
+	Published time.Time `json:"published"`
+	PkgSize   int64     `json:"size"`
+	FilePath  string    `json:"filePath"`
+	SHA256    string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.Ref.Name
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.Ref.Version
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// IsRecipe reports whether the file belongs to the recipe revision rather than to a package revision
+func (p *Package) IsRecipe() bool {
+	return p.PackageID == ""
+}
+
+// NewPackage creates a recipe revision file when the package id is empty, or a package revision file otherwise.
+// The file content is verified against the sha1 checksum sent by the conan client when not empty.
+func NewPackage(r io.Reader, ref Reference, rrev, pkgID, prev, file, sha1sum string) (*Package, error) {
+	if err := ref.Validate(); err != nil {
+		return nil, err
+	}
+	for _, v := range []string{rrev, pkgID, prev} {
+		if v != "" && !revisionPattern.MatchString(v) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRevision, v)
+		}
+	}
+	if rrev == "" || (pkgID == "") != (prev == "") {
+		return nil, ErrInvalidRevision
+	}
+	if !filePattern.MatchString(file) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFile, file)
+	}
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	_, sha1, sha256, _ := reader.Sums()
+	if sha1sum != "" && !strings.EqualFold(sha1sum, hex.EncodeToString(sha1)) {
+		reader.Close()
+		return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, file)
+	}
+	pkg := &Package{
+		Ref:             ref,
+		RecipeRevision:  rrev,
+		PackageID:       pkgID,
+		PackageRevision: prev,
+		FileName:        file,
+		Published:       time.Now().UTC(),
+		PkgSize:         reader.Size(),
+		SHA256:          hex.EncodeToString(sha256),
+		reader:          reader,
+	}
+	if pkg.IsRecipe() {
+		pkg.FilePath = RecipeFilePath(ref, rrev, file)
+	} else {
+		pkg.FilePath = PackageFilePath(ref, rrev, pkgID, prev, file)
+	}
+	if !pkg.IsRecipe() && file == InfoFile {
+		b, err := io.ReadAll(reader)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		pkg.Info = ParseInfo(b)
+		if _, err := reader.Seek(0, io.SeekStart); err != nil {
+			reader.Close()
+			return nil, err
+		}
+	}
+	return pkg, nil
+}
+
+// ParseInfo parses the settings, options and requires sections of a conaninfo.txt file, e.g.
+//
+//	[settings]
+//	os=Linux
+//	[requires]
+//	zlib/1.2.Z
+func ParseInfo(b []byte) *Info {
+	i := &Info{Settings: map[string]string{}, Options: map[string]string{}, Requires: []string{}}
+	var section string
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		switch section {
+		case "settings", "options":
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			if section == "settings" {
+				i.Settings[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			} else {
+				i.Options[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		case "requires":
+			i.Requires = append(i.Requires, line)
+		}
+	}
+	return i
+}
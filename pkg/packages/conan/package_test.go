@@ -0,0 +1,184 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conan
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      []string
+		wantErr  bool
+		wantStr  string
+		wantDir  string
+		wantUser string
+	}{
+		{
+			name:    "no user and channel",
+			ref:     []string{"zlib", "1.2.13", "_", "_"},
+			wantStr: "zlib/1.2.13",
+			wantDir: "zlib/1.2.13/_/_",
+		},
+		{
+			name:     "user and channel",
+			ref:      []string{"zlib", "1.2.13", "user", "stable"},
+			wantStr:  "zlib/1.2.13@user/stable",
+			wantDir:  "zlib/1.2.13/user/stable",
+			wantUser: "user",
+		},
+		{
+			name:    "user without channel",
+			ref:     []string{"zlib", "1.2.13", "user", "_"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			ref:     []string{"z", "1.2.13", "_", "_"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			ref:     []string{"zlib", "1.2/13", "_", "_"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := NewReference(tt.ref[0], tt.ref[1], tt.ref[2], tt.ref[3])
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidReference)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStr, ref.String())
+			assert.Equal(t, tt.wantDir, ref.Dir())
+			assert.Equal(t, tt.wantUser, ref.User)
+		})
+	}
+}
+
+func TestNewPackage(t *testing.T) {
+	ref := Reference{Name: "zlib", Version: "1.2.13"}
+	info := "[settings]\n    arch=x86_64\n    os=Linux\n[options]\n    shared=False\n[requires]\n    bzip2/1.0.Z\n"
+	sum := sha1.Sum([]byte(info))
+
+	pkg, err := NewPackage(strings.NewReader(info), ref, "rrev1", "pkgid1", "prev1", InfoFile, strings.ToUpper(hex.EncodeToString(sum[:])))
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.False(t, pkg.IsRecipe())
+	assert.Equal(t, "zlib", pkg.Name())
+	assert.Equal(t, "1.2.13", pkg.Version())
+	assert.Equal(t, "zlib/1.2.13/_/_/revisions/rrev1/packages/pkgid1/revisions/prev1/files/conaninfo.txt", pkg.Path())
+	assert.Equal(t, int64(len(info)), pkg.Size())
+	assert.Equal(t, &Info{
+		Settings: map[string]string{"arch": "x86_64", "os": "Linux"},
+		Options:  map[string]string{"shared": "False"},
+		Requires: []string{"bzip2/1.0.Z"},
+	}, pkg.Info)
+	b, err := io.ReadAll(pkg)
+	require.NoError(t, err)
+	assert.Equal(t, info, string(b))
+
+	rec, err := NewPackage(strings.NewReader("from conan import ConanFile"), ref, "rrev1", "", "", "conanfile.py", "")
+	require.NoError(t, err)
+	defer rec.Close()
+	assert.True(t, rec.IsRecipe())
+	assert.Nil(t, rec.Info)
+	assert.Equal(t, "zlib/1.2.13/_/_/revisions/rrev1/files/conanfile.py", rec.Path())
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	ref := Reference{Name: "zlib", Version: "1.2.13"}
+	tests := []struct {
+		name    string
+		ref     Reference
+		rrev    string
+		pkgID   string
+		prev    string
+		file    string
+		sha1    string
+		wantErr error
+	}{
+		{
+			name:    "invalid reference",
+			ref:     Reference{Name: "zlib", Version: "1.2.13", User: "user"},
+			rrev:    "rrev1",
+			file:    "conanfile.py",
+			wantErr: ErrInvalidReference,
+		},
+		{
+			name:    "missing recipe revision",
+			ref:     ref,
+			file:    "conanfile.py",
+			wantErr: ErrInvalidRevision,
+		},
+		{
+			name:    "invalid revision",
+			ref:     ref,
+			rrev:    "../rrev1",
+			file:    "conanfile.py",
+			wantErr: ErrInvalidRevision,
+		},
+		{
+			name:    "package id without revision",
+			ref:     ref,
+			rrev:    "rrev1",
+			pkgID:   "pkgid1",
+			file:    "conan_package.tgz",
+			wantErr: ErrInvalidRevision,
+		},
+		{
+			name:    "invalid file",
+			ref:     ref,
+			rrev:    "rrev1",
+			file:    "../conanfile.py",
+			wantErr: ErrInvalidFile,
+		},
+		{
+			name:    "checksum mismatch",
+			ref:     ref,
+			rrev:    "rrev1",
+			file:    "conanfile.py",
+			sha1:    strings.Repeat("0", 40),
+			wantErr: ErrChecksumMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(strings.NewReader("content"), tt.ref, tt.rrev, tt.pkgID, tt.prev, tt.file, tt.sha1)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+		})
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	info := ParseInfo([]byte("# comment\n[settings]\nos = Windows\ninvalid\n\n[options]\nfPIC=True\n[full_requires]\nzlib/1.2.13\n"))
+	assert.Equal(t, &Info{
+		Settings: map[string]string{"os": "Windows"},
+		Options:  map[string]string{"fPIC": "True"},
+		Requires: []string{},
+	}, info)
+}
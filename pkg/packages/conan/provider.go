@@ -0,0 +1,529 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conan
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/auth"
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	Name = "conan"
+
+	// RecipePrefix is the recipe routes prefix
+	// https://github.com/conan-io/conan/blob/release/2.0/conans/client/rest/rest_client_v2.py
+	RecipePrefix   = "/v2/conans/{name}/{version}/{user}/{channel}"
+	RevisionPrefix = RecipePrefix + "/revisions/{rrev}"
+	PackagePrefix  = RevisionPrefix + "/packages/{pkgid}"
+)
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// ping advertises the revisions support, which is required by conan 2
+func (p *provider) ping(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Conan-Server-Capabilities", "revisions")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// authenticate returns the token used by the conan client for the following requests.
+// As the registry has no token of its own, the token is the base64 encoded "user:password" credentials,
+// which are accepted as bearer token.
+func (p *provider) authenticate(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="conan"`)
+			http.Error(w, "missing credentials", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, base64.StdEncoding.EncodeToString([]byte(user+":"+pass)))
+	}
+}
+
+// checkCredentials verifies the credentials by accessing the repository
+func (p *provider) checkCredentials(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		a := auth.FromContext(ctx)
+		if a == nil {
+			http.Error(w, "missing credentials", http.StatusUnauthorized)
+			return
+		}
+		user, _, ok := a.BasicAuth()
+		if !ok {
+			http.Error(w, "missing credentials", http.StatusUnauthorized)
+			return
+		}
+		if _, err := storage.FromContext(ctx).Artifacts(ctx); err != nil && !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, user)
+	}
+}
+
+// search returns the references matching the q pattern, e.g. zlib/* or zlib*
+func (p *provider) search(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var refs []string
+		if err := readJSON(ctx, ReferencesFile, &refs); err != nil && !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		q := r.URL.Query().Get("q")
+		re, err := pattern(q, !strings.EqualFold(r.URL.Query().Get("ignorecase"), "false"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		results := []string{}
+		for _, v := range refs {
+			// a pattern without slash is matched against the recipe name
+			if re.MatchString(v) || (!strings.Contains(q, "/") && re.MatchString(strings.Split(v, "/")[0])) {
+				results = append(results, v)
+			}
+		}
+		writeJSON(ctx, w, map[string][]string{"results": results})
+	}
+}
+
+// pattern converts a fnmatch pattern to a regular expression
+func pattern(q string, ignoreCase bool) (*regexp.Regexp, error) {
+	if q == "" {
+		q = "*"
+	}
+	s := regexp.QuoteMeta(q)
+	s = strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(s)
+	if ignoreCase {
+		s = "(?i)" + s
+	}
+	return regexp.Compile(`\A` + s + `\z`)
+}
+
+type revision struct {
+	Reference string `json:"reference,omitempty"`
+	Revision  string `json:"revision"`
+	Time      string `json:"time"`
+}
+
+// isoTime formats the revision time as expected by the conan client
+func isoTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000-07:00")
+}
+
+func (p *provider) recipeRevisions(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		rec, err := recipe(r)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		revs := make([]revision, 0, len(rec.Revisions))
+		for _, v := range rec.Revisions {
+			revs = append(revs, revision{Revision: v.Revision, Time: isoTime(v.Time)})
+		}
+		writeJSON(ctx, w, map[string]any{"reference": rec.Reference.String(), "revisions": revs})
+	}
+}
+
+func (p *provider) recipeLatest(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec, err := recipe(r)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		v := rec.Revisions[0]
+		writeJSON(r.Context(), w, revision{Reference: rec.Reference.String(), Revision: v.Revision, Time: isoTime(v.Time)})
+	}
+}
+
+func (p *provider) recipeFiles(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rev, err := recipeRevision(r)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		writeFiles(r.Context(), w, rev.Files)
+	}
+}
+
+// searchPackages returns the recipe revision packages info by package id
+func (p *provider) searchPackages(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rev, err := recipeRevision(r)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		out := make(map[string]*Info, len(rev.Packages))
+		for _, v := range rev.Packages {
+			out[v.ID] = v.Info
+		}
+		writeJSON(r.Context(), w, out)
+	}
+}
+
+func (p *provider) packageRevisions(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, err := binary(r)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		revs := make([]revision, 0, len(b.Revisions))
+		for _, v := range b.Revisions {
+			revs = append(revs, revision{Revision: v.Revision, Time: isoTime(v.Time)})
+		}
+		writeJSON(r.Context(), w, map[string]any{"reference": packageReference(r), "revisions": revs})
+	}
+}
+
+func (p *provider) packageLatest(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, err := binary(r)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		v := b.Revisions[0]
+		writeJSON(r.Context(), w, revision{Reference: packageReference(r), Revision: v.Revision, Time: isoTime(v.Time)})
+	}
+}
+
+func (p *provider) packageFiles(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, err := binary(r)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		rev, ok := b.Revision(mux.Vars(r)["prev"])
+		if !ok {
+			http.Error(w, "package revision not found", http.StatusNotFound)
+			return
+		}
+		writeFiles(r.Context(), w, rev.Files)
+	}
+}
+
+// upload stores a recipe or package revision file
+func (p *provider) upload(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		defer r.Body.Close()
+		vars := mux.Vars(r)
+		ref, err := reference(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pkg, err := NewPackage(r.Body, ref, vars["rrev"], vars["pkgid"], vars["prev"], vars["file"], r.Header.Get("X-Checksum-Sha1"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		logger.C(ctx).WithFields("reference", ref.String(), "filepath", pkg.Path()).Infof("uploading file")
+		if err := storage.FromContext(ctx).Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// remove deletes all the files matching the request reference, recipe revision, package id and package revision,
+// or only the recipe revision packages files
+func (p *provider) remove(packagesOnly bool) packages.HandlerFunc {
+	return func(_ string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			vars := mux.Vars(r)
+			ref, err := reference(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s := storage.FromContext(ctx)
+			as, err := s.Artifacts(ctx)
+			if err != nil {
+				storage.Error(w, err)
+				return
+			}
+			var paths []string
+			for _, v := range storage.MustAs[*Package](as) {
+				if v.Ref != ref ||
+					(vars["rrev"] != "" && v.RecipeRevision != vars["rrev"]) ||
+					(packagesOnly && v.IsRecipe()) ||
+					(vars["pkgid"] != "" && v.PackageID != vars["pkgid"]) ||
+					(vars["prev"] != "" && v.PackageRevision != vars["prev"]) {
+					continue
+				}
+				paths = append(paths, v.Path())
+			}
+			if len(paths) == 0 {
+				if !packagesOnly {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			if err := s.Delete(ctx, paths...); err != nil {
+				storage.Error(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+}
+
+func reference(r *http.Request) (Reference, error) {
+	vars := mux.Vars(r)
+	return NewReference(vars["name"], vars["version"], vars["user"], vars["channel"])
+}
+
+// packageReference returns the package reference, e.g. zlib/1.2.13#rrev:pkgid
+func packageReference(r *http.Request) string {
+	ref, _ := reference(r)
+	return fmt.Sprintf("%s#%s:%s", ref, mux.Vars(r)["rrev"], mux.Vars(r)["pkgid"])
+}
+
+// recipe returns the request reference index, which always has at least one revision
+func recipe(r *http.Request) (*Recipe, error) {
+	ref, err := reference(r)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, os.ErrNotExist)
+	}
+	var rec Recipe
+	if err := readJSON(r.Context(), RecipePath(ref), &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func recipeRevision(r *http.Request) (*RecipeRevision, error) {
+	rec, err := recipe(r)
+	if err != nil {
+		return nil, err
+	}
+	rev, ok := rec.Revision(mux.Vars(r)["rrev"])
+	if !ok {
+		return nil, fmt.Errorf("recipe revision %s: %w", mux.Vars(r)["rrev"], os.ErrNotExist)
+	}
+	return rev, nil
+}
+
+// binary returns the request package, which always has at least one revision
+func binary(r *http.Request) (*PackageBinary, error) {
+	rev, err := recipeRevision(r)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := rev.Package(mux.Vars(r)["pkgid"])
+	if !ok {
+		return nil, fmt.Errorf("package %s: %w", mux.Vars(r)["pkgid"], os.ErrNotExist)
+	}
+	return b, nil
+}
+
+func filePath(r *http.Request) string {
+	vars := mux.Vars(r)
+	ref, _ := reference(r)
+	if vars["pkgid"] == "" {
+		return RecipeFilePath(ref, vars["rrev"], vars["file"])
+	}
+	return PackageFilePath(ref, vars["rrev"], vars["pkgid"], vars["prev"], vars["file"])
+}
+
+func writeFiles(ctx context.Context, w http.ResponseWriter, files []string) {
+	m := make(map[string]struct{}, len(files))
+	for _, v := range files {
+		m[v] = struct{}{}
+	}
+	writeJSON(ctx, w, map[string]any{"files": m})
+}
+
+func readJSON(ctx context.Context, name string, v any) error {
+	rc, err := storage.FromContext(ctx).Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.C(ctx).WithError(err).Error("failed to write response")
+	}
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/v1/ping",
+			Handler: p.ping,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/ping",
+			Handler: p.ping,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/users/authenticate",
+			Handler: p.authenticate,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/users/check_credentials",
+			Handler: p.checkCredentials,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v2/conans/search",
+			Handler: p.search,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    PackagePrefix + "/revisions/{prev}/files/{file}",
+			Handler: packages.Pull(filePath),
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    PackagePrefix + "/revisions/{prev}/files/{file}",
+			Handler: p.upload,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    PackagePrefix + "/revisions/{prev}/files/{file}",
+			Handler: packages.Delete(filePath),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    PackagePrefix + "/revisions/{prev}/files",
+			Handler: p.packageFiles,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    PackagePrefix + "/revisions/{prev}",
+			Handler: p.remove(false),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    PackagePrefix + "/revisions",
+			Handler: p.packageRevisions,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    PackagePrefix + "/latest",
+			Handler: p.packageLatest,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    PackagePrefix,
+			Handler: p.remove(false),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    RevisionPrefix + "/" + PackagesDir,
+			Handler: p.remove(true),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    RevisionPrefix + "/search",
+			Handler: p.searchPackages,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    RevisionPrefix + "/files/{file}",
+			Handler: packages.Pull(filePath),
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    RevisionPrefix + "/files/{file}",
+			Handler: p.upload,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    RevisionPrefix + "/files/{file}",
+			Handler: packages.Delete(filePath),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    RevisionPrefix + "/files",
+			Handler: p.recipeFiles,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    RevisionPrefix,
+			Handler: p.remove(false),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    RecipePrefix + "/revisions",
+			Handler: p.recipeRevisions,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    RecipePrefix + "/latest",
+			Handler: p.recipeLatest,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    RecipePrefix,
+			Handler: p.remove(false),
+		},
+	}
+}
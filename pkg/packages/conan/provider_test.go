@@ -0,0 +1,68 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		q          string
+		ignoreCase bool
+		match      []string
+		noMatch    []string
+	}{
+		{
+			q:     "",
+			match: []string{"zlib/1.2.13", "bzip2/1.0.8@user/stable"},
+		},
+		{
+			q:       "zlib*",
+			match:   []string{"zlib/1.2.13"},
+			noMatch: []string{"ZLIB/1.2.13", "bzip2/1.0.8"},
+		},
+		{
+			q:          "zlib*",
+			ignoreCase: true,
+			match:      []string{"ZLIB/1.2.13"},
+		},
+		{
+			q:       "zlib/1.2.1?",
+			match:   []string{"zlib/1.2.13"},
+			noMatch: []string{"zlib/1.2.1", "zlib/1.2.133"},
+		},
+		{
+			q:       "zlib/1.2.13+",
+			match:   []string{"zlib/1.2.13+"},
+			noMatch: []string{"zlib/1.2.133"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q, func(t *testing.T) {
+			re, err := pattern(tt.q, tt.ignoreCase)
+			require.NoError(t, err)
+			for _, v := range tt.match {
+				assert.True(t, re.MatchString(v), v)
+			}
+			for _, v := range tt.noMatch {
+				assert.False(t, re.MatchString(v), v)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conan
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"time"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	ReferencesFile = "references.json"
+	RecipeFile     = "recipe.json"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "conan"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Conan Repository", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Recipe is the reference index holding its revisions, from the latest to the oldest one
+type Recipe struct {
+	Reference Reference         `json:"reference"`
+	Revisions []*RecipeRevision `json:"revisions"`
+}
+
+type RecipeRevision struct {
+	Revision string           `json:"revision"`
+	Time     time.Time        `json:"time"`
+	Files    []string         `json:"files"`
+	Packages []*PackageBinary `json:"packages"`
+}
+
+// PackageBinary is a package id with its revisions, from the latest to the oldest one
+type PackageBinary struct {
+	ID        string             `json:"id"`
+	Info      *Info              `json:"info"`
+	Revisions []*PackageRevision `json:"revisions"`
+}
+
+type PackageRevision struct {
+	Revision string    `json:"revision"`
+	Time     time.Time `json:"time"`
+	Files    []string  `json:"files"`
+}
+
+// Revision returns the recipe revision if it exists
+func (r *Recipe) Revision(rrev string) (*RecipeRevision, bool) {
+	for _, v := range r.Revisions {
+		if v.Revision == rrev {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Package returns the package binary if it exists
+func (r *RecipeRevision) Package(id string) (*PackageBinary, bool) {
+	for _, v := range r.Packages {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Revision returns the package revision if it exists
+func (p *PackageBinary) Revision(prev string) (*PackageRevision, bool) {
+	for _, v := range p.Revisions {
+		if v.Revision == prev {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// RecipePath returns the reference index path, e.g. zlib/1.2.13/_/_/recipe.json
+func RecipePath(ref Reference) string {
+	return path.Join(ref.Dir(), RecipeFile)
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	refs := []string{}
+	for _, ref := range slices.Distinct(slices.Map(pkgs, func(p *Package) Reference {
+		return p.Ref
+	})) {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.Ref == ref
+		})
+		rec := Recipe{Reference: ref, Revisions: recipeRevisions(pkgs)}
+		if len(rec.Revisions) == 0 {
+			continue
+		}
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, storage.NewFile(RecipePath(ref), b))
+		refs = append(refs, ref.String())
+	}
+	sort.Strings(refs)
+	b, err := json.Marshal(refs)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, storage.NewFile(ReferencesFile, b)), nil
+}
+
+// recipeRevisions returns the reference recipe revisions. As the conan client uploads the manifest last,
+// the revisions and package revisions without manifest are partial uploads and are not listed.
+func recipeRevisions(pkgs []*Package) []*RecipeRevision {
+	var out []*RecipeRevision
+	for _, rrev := range slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.RecipeRevision
+	})) {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.RecipeRevision == rrev
+		})
+		files := slices.Filter(pkgs, func(p *Package) bool {
+			return p.IsRecipe()
+		})
+		m, ok := manifest(files)
+		if !ok {
+			continue
+		}
+		rev := &RecipeRevision{Revision: rrev, Time: m.Published, Files: fileNames(files), Packages: []*PackageBinary{}}
+		for _, id := range slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+			return p.PackageID
+		})) {
+			if id == "" {
+				continue
+			}
+			pkgs := slices.Filter(pkgs, func(p *Package) bool {
+				return p.PackageID == id
+			})
+			if b := packageBinary(id, pkgs); b != nil {
+				rev.Packages = append(rev.Packages, b)
+			}
+		}
+		sort.Slice(rev.Packages, func(i, j int) bool {
+			return rev.Packages[i].ID < rev.Packages[j].ID
+		})
+		out = append(out, rev)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Time.After(out[j].Time)
+	})
+	return out
+}
+
+// packageBinary returns the package with its revisions, the package info being the one of its latest revision
+func packageBinary(id string, pkgs []*Package) *PackageBinary {
+	b := &PackageBinary{ID: id}
+	infos := make(map[string]*Info)
+	for _, prev := range slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.PackageRevision
+	})) {
+		files := slices.Filter(pkgs, func(p *Package) bool {
+			return p.PackageRevision == prev
+		})
+		m, ok := manifest(files)
+		if !ok {
+			continue
+		}
+		for _, v := range files {
+			if v.Info != nil {
+				infos[prev] = v.Info
+			}
+		}
+		b.Revisions = append(b.Revisions, &PackageRevision{Revision: prev, Time: m.Published, Files: fileNames(files)})
+	}
+	if len(b.Revisions) == 0 {
+		return nil
+	}
+	sort.Slice(b.Revisions, func(i, j int) bool {
+		return b.Revisions[i].Time.After(b.Revisions[j].Time)
+	})
+	b.Info = infos[b.Revisions[0].Revision]
+	if b.Info == nil {
+		b.Info = &Info{Settings: map[string]string{}, Options: map[string]string{}, Requires: []string{}}
+	}
+	return b
+}
+
+func manifest(pkgs []*Package) (*Package, bool) {
+	for _, v := range pkgs {
+		if v.FileName == ManifestFile {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func fileNames(pkgs []*Package) []string {
+	out := slices.Map(pkgs, func(p *Package) string {
+		return p.FileName
+	})
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conan
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFile(ref Reference, rrev, pkgID, prev, file string, published time.Time) *Package {
+	p := &Package{
+		Ref:             ref,
+		RecipeRevision:  rrev,
+		PackageID:       pkgID,
+		PackageRevision: prev,
+		FileName:        file,
+		Published:       published,
+	}
+	if pkgID == "" {
+		p.FilePath = RecipeFilePath(ref, rrev, file)
+	} else {
+		p.FilePath = PackageFilePath(ref, rrev, pkgID, prev, file)
+	}
+	return p
+}
+
+func TestIndex(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	zlib := Reference{Name: "zlib", Version: "1.2.13"}
+	bzip := Reference{Name: "bzip2", Version: "1.0.8", User: "user", Channel: "stable"}
+	partial := Reference{Name: "openssl", Version: "3.0.0"}
+	info := testFile(zlib, "rrev2", "pkgid1", "prev2", InfoFile, now.Add(-time.Minute))
+	info.Info = &Info{Settings: map[string]string{"os": "Linux"}, Options: map[string]string{}, Requires: []string{}}
+	out, err := (&repo{}).Index(context.Background(), "",
+		testFile(zlib, "rrev1", "", "", "conanfile.py", now.Add(-time.Hour)),
+		testFile(zlib, "rrev1", "", "", ManifestFile, now.Add(-time.Hour)),
+		testFile(zlib, "rrev2", "", "", "conanfile.py", now.Add(-2*time.Minute)),
+		testFile(zlib, "rrev2", "", "", ManifestFile, now.Add(-2*time.Minute)),
+		testFile(zlib, "rrev2", "pkgid1", "prev1", "conan_package.tgz", now.Add(-90*time.Second)),
+		testFile(zlib, "rrev2", "pkgid1", "prev1", ManifestFile, now.Add(-90*time.Second)),
+		info,
+		testFile(zlib, "rrev2", "pkgid1", "prev2", ManifestFile, now.Add(-time.Minute)),
+		// partial package revision upload
+		testFile(zlib, "rrev2", "pkgid2", "prev1", "conan_package.tgz", now),
+		testFile(bzip, "rrev1", "", "", ManifestFile, now),
+		// partial recipe revision upload
+		testFile(partial, "rrev1", "", "", "conanfile.py", now),
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	require.Len(t, files, 3)
+
+	var refs []string
+	require.NoError(t, json.Unmarshal(files[ReferencesFile], &refs))
+	assert.Equal(t, []string{"bzip2/1.0.8@user/stable", "zlib/1.2.13"}, refs)
+
+	var rec Recipe
+	require.NoError(t, json.Unmarshal(files[RecipePath(zlib)], &rec))
+	assert.Equal(t, zlib, rec.Reference)
+	require.Len(t, rec.Revisions, 2)
+	assert.Equal(t, "rrev2", rec.Revisions[0].Revision)
+	assert.True(t, now.Add(-2*time.Minute).Equal(rec.Revisions[0].Time))
+	assert.Equal(t, []string{"conanfile.py", ManifestFile}, rec.Revisions[0].Files)
+	assert.Equal(t, "rrev1", rec.Revisions[1].Revision)
+	assert.Empty(t, rec.Revisions[1].Packages)
+
+	rrev, ok := rec.Revision("rrev2")
+	require.True(t, ok)
+	require.Len(t, rrev.Packages, 1)
+	_, ok = rrev.Package("pkgid2")
+	assert.False(t, ok)
+	b, ok := rrev.Package("pkgid1")
+	require.True(t, ok)
+	assert.Equal(t, info.Info, b.Info)
+	require.Len(t, b.Revisions, 2)
+	assert.Equal(t, "prev2", b.Revisions[0].Revision)
+	assert.Equal(t, []string{InfoFile, ManifestFile}, b.Revisions[0].Files)
+	prev, ok := b.Revision("prev1")
+	require.True(t, ok)
+	assert.Equal(t, []string{"conan_package.tgz", ManifestFile}, prev.Files)
+	_, ok = rec.Revision("rrev3")
+	assert.False(t, ok)
+
+	rec = Recipe{}
+	require.NoError(t, json.Unmarshal(files["bzip2/1.0.8/user/stable/recipe.json"], &rec))
+	require.Len(t, rec.Revisions, 1)
+	assert.Equal(t, []string{ManifestFile}, rec.Revisions[0].Files)
+
+	out, err = (&repo{}).Index(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
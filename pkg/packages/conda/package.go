@@ -0,0 +1,348 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conda
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	ExtConda  = ".conda"
+	ExtTarBz2 = ".tar.bz2"
+
+	NoArch = "noarch"
+
+	indexFile = "info/index.json"
+)
+
+var (
+	ErrInvalidPackage  = errors.New("conda package is invalid")
+	ErrMissingMetadata = errors.New("conda package metadata not found")
+	ErrInvalidName     = errors.New("conda package name is invalid")
+	ErrInvalidSubdir   = errors.New("conda package subdir is invalid")
+
+	// https://docs.conda.io/projects/conda-build/en/stable/resources/package-spec.html#package-naming-conventions
+	namePattern    = regexp.MustCompile(`\A[a-z0-9_][a-z0-9_.-]*\z`)
+	versionPattern = regexp.MustCompile(`\A[^-\s]+\z`)
+	subdirPattern  = regexp.MustCompile(`\A(noarch|[a-z0-9]+-[a-z0-9_]+)\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	Info      Record    `json:"info"`
+	FileName  string    `json:"fileName"`
+	Published time.Time `json:"published"`
+	FilePath  string    `json:"filePath"`
+
+	reader io.ReadCloser
+}
+
+// Record is the package record as found in the info/index.json file and listed in the repodata.json file
+// https://docs.conda.io/projects/conda-build/en/stable/resources/package-spec.html#info-index-json
+type Record struct {
+	Name          string   `json:"name"`
+	Version       string   `json:"version"`
+	Build         string   `json:"build"`
+	BuildNumber   int      `json:"build_number"`
+	Depends       []string `json:"depends"`
+	Constrains    []string `json:"constrains,omitempty"`
+	License       string   `json:"license,omitempty"`
+	LicenseFamily string   `json:"license_family,omitempty"`
+	Subdir        string   `json:"subdir"`
+	Arch          *string  `json:"arch,omitempty"`
+	Platform      *string  `json:"platform,omitempty"`
+	// Noarch is either the noarch type ("generic" or "python") or a boolean for legacy packages
+	Noarch        any    `json:"noarch,omitempty"`
+	Features      string `json:"features,omitempty"`
+	TrackFeatures string `json:"track_features,omitempty"`
+	Timestamp     int64  `json:"timestamp,omitempty"`
+	MD5           string `json:"md5"`
+	SHA256        string `json:"sha256"`
+	Size          int64  `json:"size"`
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.Info.Name
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return p.Info.Subdir
+}
+
+func (p *Package) Version() string {
+	return p.Info.Version
+}
+
+func (p *Package) Size() int64 {
+	return p.Info.Size
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.Info.SHA256)
+}
+
+// NewPackage parses a conda package, either in the .conda (zip) or in the legacy .tar.bz2 format
+// https://docs.conda.io/projects/conda/en/stable/user-guide/concepts/packages.html#conda-file-format
+func NewPackage(r io.Reader) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	magic := make([]byte, 4)
+	if _, err := reader.ReadAt(magic, 0); err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	var (
+		rec Record
+		ext string
+	)
+	switch {
+	case bytes.Equal(magic, []byte("PK\x03\x04")):
+		ext = ExtConda
+		err = readConda(reader, reader.Size(), &rec)
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		ext = ExtTarBz2
+		err = readInfo(bzip2.NewReader(io.NewSectionReader(reader, 0, reader.Size())), &rec)
+	default:
+		err = fmt.Errorf("%w: unsupported format", ErrInvalidPackage)
+	}
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	if err := rec.validate(); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	md5, _, sha256, _ := reader.Sums()
+	rec.MD5 = hex.EncodeToString(md5)
+	rec.SHA256 = hex.EncodeToString(sha256)
+	rec.Size = reader.Size()
+	name := fmt.Sprintf("%s-%s-%s%s", rec.Name, rec.Version, rec.Build, ext)
+	pkg := &Package{
+		Info:      rec,
+		FileName:  name,
+		Published: time.Now().UTC(),
+		FilePath:  path.Join(rec.Subdir, name),
+		reader:    reader,
+	}
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+func (r *Record) validate() error {
+	if !namePattern.MatchString(r.Name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, r.Name)
+	}
+	if !versionPattern.MatchString(r.Version) {
+		return fmt.Errorf("%w: invalid version %q", ErrInvalidPackage, r.Version)
+	}
+	if !versionPattern.MatchString(r.Build) {
+		return fmt.Errorf("%w: invalid build %q", ErrInvalidPackage, r.Build)
+	}
+	if r.Subdir == "" && r.Noarch != nil {
+		r.Subdir = NoArch
+	}
+	if !subdirPattern.MatchString(r.Subdir) {
+		return fmt.Errorf("%w: %q", ErrInvalidSubdir, r.Subdir)
+	}
+	if r.Depends == nil {
+		r.Depends = []string{}
+	}
+	return nil
+}
+
+// readConda reads the index.json from the info-{name}-{version}-{build}.tar.zst archive of the .conda package
+func readConda(r io.ReaderAt, size int64, rec *Record) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, "info-") || !strings.HasSuffix(f.Name, ".tar.zst") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		defer rc.Close()
+		zsr, err := zstd.NewReader(rc)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		defer zsr.Close()
+		return readInfo(zsr, rec)
+	}
+	return ErrMissingMetadata
+}
+
+func readInfo(r io.Reader, rec *Record) error {
+	tr := tar.NewReader(r)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return ErrMissingMetadata
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		if path.Clean(hd.Name) != indexFile {
+			continue
+		}
+		if err := json.NewDecoder(tr).Decode(rec); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		return nil
+	}
+}
+
+// CompareVersions compares two conda versions, it is a simplified implementation of conda's VersionOrder:
+// the epoch and the dot, dash or underscore separated components are compared, where strings sort before
+// numbers except "dev" which sorts first and "post" which sorts last, e.g. 1.0dev < 1.0a1 < 1.0 < 1.0post1
+// https://docs.conda.io/projects/conda/en/stable/user-guide/concepts/pkg-specs.html#version-ordering
+func CompareVersions(a, b string) int {
+	ea, va := splitVersion(a)
+	eb, vb := splitVersion(b)
+	if ea != eb {
+		if ea < eb {
+			return -1
+		}
+		return 1
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var ca, cb []any
+		if i < len(va) {
+			ca = va[i]
+		}
+		if i < len(vb) {
+			cb = vb[i]
+		}
+		for j := 0; j < len(ca) || j < len(cb); j++ {
+			var x, y any = 0, 0
+			if j < len(ca) {
+				x = ca[j]
+			}
+			if j < len(cb) {
+				y = cb[j]
+			}
+			if c := compareElement(x, y); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+var versionElement = regexp.MustCompile(`[0-9]+|[a-z]+`)
+
+func splitVersion(v string) (epoch int, parts [][]any) {
+	v = strings.ToLower(v)
+	if e, rest, ok := strings.Cut(v, "!"); ok {
+		epoch, _ = strconv.Atoi(e)
+		v = rest
+	}
+	// the local version is ignored
+	v, _, _ = strings.Cut(v, "+")
+	for _, p := range strings.FieldsFunc(v, func(r rune) bool { return r == '.' || r == '-' || r == '_' }) {
+		var c []any
+		for _, e := range versionElement.FindAllString(p, -1) {
+			if n, err := strconv.Atoi(e); err == nil {
+				c = append(c, n)
+				continue
+			}
+			// components starting with a string are considered to start with 0
+			if len(c) == 0 {
+				c = append(c, 0)
+			}
+			c = append(c, e)
+		}
+		parts = append(parts, c)
+	}
+	return epoch, parts
+}
+
+func compareElement(a, b any) int {
+	rank := func(v any) int {
+		switch v {
+		case "dev":
+			return 0
+		case "post":
+			return 3
+		}
+		if _, ok := v.(string); ok {
+			return 1
+		}
+		return 2
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+	switch x := a.(type) {
+	case int:
+		y := b.(int)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case string:
+		return strings.Compare(x, b.(string))
+	}
+	return 0
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conda
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTarBz2 is a legacy package containing only its info/index.json:
+// {"name":"legacy","version":"0.1","build":"0","build_number":0,"subdir":"linux-64"}
+const testTarBz2 = `QlpoOTFBWSZTWde0/k4AAJP7lMyAAEBQB/+QIAD/t59qBAAAAIAIIACSDUExNGp6TIxGE9RgmTMk
+Ekk1B6h6QPSGgeoAB6h2OcX/kIKXQEiT5MZ++KF5AhhMpO+WnCvjAsLhRxXoMDUc3rVUk++zhKeS
+1hVbNbrJEd5N26E4oNWXTw7jdQz3CfqDmfKhyxpzHBXl4aI2uB4h+K9ZswkKO13oyYMYsSDuOzyo
+SuHMVpCEA/i7kinChIa9p/Jw`
+
+func testConda(t *testing.T, info string, index string) []byte {
+	var tb bytes.Buffer
+	zw, err := zstd.NewWriter(&tb)
+	require.NoError(t, err)
+	tw := tar.NewWriter(zw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "info/index.json", Mode: 0644, Size: int64(len(index)), Typeflag: tar.TypeReg}))
+	_, err = tw.Write([]byte(index))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for name, content := range map[string][]byte{
+		"metadata.json": []byte(`{"conda_pkg_format_version": 2}`),
+		info:            tb.Bytes(),
+	} {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func TestNewPackage(t *testing.T) {
+	bz2, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(testTarBz2, "\n", ""))
+	require.NoError(t, err)
+	tests := []struct {
+		name     string
+		data     []byte
+		wantPath string
+		want     Record
+		wantErr  error
+	}{
+		{
+			name:     "conda",
+			data:     testConda(t, "info-example-1.0-py_0.tar.zst", `{"name":"example","version":"1.0","build":"py_0","build_number":0,"depends":["python >=3.8"],"noarch":"python","license":"MIT"}`),
+			wantPath: "noarch/example-1.0-py_0.conda",
+			want:     Record{Name: "example", Version: "1.0", Build: "py_0", Depends: []string{"python >=3.8"}, Noarch: "python", License: "MIT", Subdir: NoArch},
+		},
+		{
+			name:     "tar.bz2",
+			data:     bz2,
+			wantPath: "linux-64/legacy-0.1-0.tar.bz2",
+			want:     Record{Name: "legacy", Version: "0.1", Build: "0", Depends: []string{}, Subdir: "linux-64"},
+		},
+		{
+			name:    "missing info archive",
+			data:    testConda(t, "pkg-example-1.0-py_0.tar.zst", `{}`),
+			wantErr: ErrMissingMetadata,
+		},
+		{
+			name:    "invalid name",
+			data:    testConda(t, "info-example-1.0-0.tar.zst", `{"name":"Example","version":"1.0","build":"0","subdir":"linux-64"}`),
+			wantErr: ErrInvalidName,
+		},
+		{
+			name:    "invalid version",
+			data:    testConda(t, "info-example-1.0-0.tar.zst", `{"name":"example","version":"1.0-1","build":"0","subdir":"linux-64"}`),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "invalid subdir",
+			data:    testConda(t, "info-example-1.0-0.tar.zst", `{"name":"example","version":"1.0","build":"0","subdir":"../linux-64"}`),
+			wantErr: ErrInvalidSubdir,
+		},
+		{
+			name:    "unsupported format",
+			data:    []byte("not a package"),
+			wantErr: ErrInvalidPackage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage(bytes.NewReader(tt.data))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, tt.wantPath, pkg.Path())
+			assert.Len(t, pkg.Info.MD5, 32)
+			assert.Len(t, pkg.Info.SHA256, 64)
+			assert.Equal(t, int64(len(tt.data)), pkg.Info.Size)
+			tt.want.MD5, tt.want.SHA256, tt.want.Size = pkg.Info.MD5, pkg.Info.SHA256, pkg.Info.Size
+			assert.Equal(t, tt.want, pkg.Info)
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0", b: "1.0.0", want: 0},
+		{a: "1.1", b: "1.10", want: -1},
+		{a: "1.0dev", b: "1.0a1", want: -1},
+		{a: "1.0a1", b: "1.0", want: -1},
+		{a: "1.0", b: "1.0post1", want: -1},
+		{a: "1.0rc1", b: "1.0", want: -1},
+		{a: "2.0", b: "1!1.0", want: -1},
+		{a: "1.0+local", b: "1.0", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, CompareVersions(tt.a, tt.b))
+			assert.Equal(t, -tt.want, CompareVersions(tt.b, tt.a))
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conda
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	RepoDataFile        = "repodata.json"
+	CurrentRepoDataFile = "current_repodata.json"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "conda"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Conda Registry", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// RepoData is the subdir's packages index
+// https://docs.conda.io/projects/conda-build/en/stable/concepts/generating-index.html#repodata-json
+type RepoData struct {
+	Info struct {
+		Subdir string `json:"subdir"`
+	} `json:"info"`
+	Packages        map[string]Record `json:"packages"`
+	PackagesConda   map[string]Record `json:"packages.conda"`
+	Removed         []string          `json:"removed"`
+	RepoDataVersion int               `json:"repodata_version"`
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	// conda requires the noarch subdir to exist even if all the packages are platform specific
+	subdirs := slices.Distinct(append(slices.Map(pkgs, func(p *Package) string {
+		return p.Info.Subdir
+	}), NoArch))
+	sort.Strings(subdirs)
+	for _, s := range subdirs {
+		pkgs := slices.Filter(pkgs, func(v *Package) bool {
+			return v.Info.Subdir == s
+		})
+		for _, v := range []struct {
+			name string
+			pkgs []*Package
+		}{
+			{name: RepoDataFile, pkgs: pkgs},
+			{name: CurrentRepoDataFile, pkgs: latest(pkgs)},
+		} {
+			b, err := json.Marshal(newRepoData(s, v.pkgs))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, storage.NewFile(path.Join(s, v.name), b))
+		}
+	}
+	return out, nil
+}
+
+func newRepoData(subdir string, pkgs []*Package) *RepoData {
+	d := &RepoData{
+		Packages:        make(map[string]Record),
+		PackagesConda:   make(map[string]Record),
+		Removed:         []string{},
+		RepoDataVersion: 1,
+	}
+	d.Info.Subdir = subdir
+	for _, v := range pkgs {
+		if strings.HasSuffix(v.FileName, ExtConda) {
+			d.PackagesConda[v.FileName] = v.Info
+		} else {
+			d.Packages[v.FileName] = v.Info
+		}
+	}
+	return d
+}
+
+// latest returns all the builds of the latest version of each package
+// as listed in the current_repodata.json file
+func latest(pkgs []*Package) (out []*Package) {
+	versions := make(map[string]string)
+	for _, v := range pkgs {
+		if l, ok := versions[v.Info.Name]; !ok || CompareVersions(v.Info.Version, l) > 0 {
+			versions[v.Info.Name] = v.Info.Version
+		}
+	}
+	return slices.Filter(pkgs, func(v *Package) bool {
+		return CompareVersions(v.Info.Version, versions[v.Info.Name]) == 0
+	})
+}
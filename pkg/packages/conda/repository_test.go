@@ -0,0 +1,81 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conda
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	pkg := func(subdir, name, version, build, ext string) storage.Artifact {
+		file := name + "-" + version + "-" + build + ext
+		return &Package{
+			Info:     Record{Name: name, Version: version, Build: build, Subdir: subdir, Depends: []string{}},
+			FileName: file,
+			FilePath: subdir + "/" + file,
+		}
+	}
+	out, err := (&repo{}).Index(context.Background(), "",
+		pkg("linux-64", "example", "1.0", "h0_0", ExtConda),
+		pkg("linux-64", "example", "1.10", "h0_0", ExtConda),
+		pkg("linux-64", "example", "1.10", "h0_1", ExtTarBz2),
+		pkg("linux-64", "other", "0.1", "0", ExtTarBz2),
+	)
+	require.NoError(t, err)
+	files := make(map[string]*RepoData)
+	for _, v := range out {
+		var d RepoData
+		require.NoError(t, json.NewDecoder(v).Decode(&d))
+		files[v.Path()] = &d
+	}
+	// the noarch subdir is always indexed
+	assert.Len(t, files, 4)
+	for _, v := range []string{"noarch/repodata.json", "noarch/current_repodata.json"} {
+		d := files[v]
+		require.NotNil(t, d, v)
+		assert.Equal(t, NoArch, d.Info.Subdir)
+		assert.Empty(t, d.Packages)
+		assert.Empty(t, d.PackagesConda)
+		assert.Equal(t, []string{}, d.Removed)
+	}
+
+	d := files["linux-64/repodata.json"]
+	require.NotNil(t, d)
+	assert.Equal(t, "linux-64", d.Info.Subdir)
+	assert.Equal(t, 1, d.RepoDataVersion)
+	assert.ElementsMatch(t, []string{"example-1.0-h0_0.conda", "example-1.10-h0_0.conda"}, keys(d.PackagesConda))
+	assert.ElementsMatch(t, []string{"example-1.10-h0_1.tar.bz2", "other-0.1-0.tar.bz2"}, keys(d.Packages))
+	assert.Equal(t, "1.10", d.PackagesConda["example-1.10-h0_0.conda"].Version)
+
+	// only the builds of the latest versions are listed in the current repodata
+	d = files["linux-64/current_repodata.json"]
+	require.NotNil(t, d)
+	assert.ElementsMatch(t, []string{"example-1.10-h0_0.conda"}, keys(d.PackagesConda))
+	assert.ElementsMatch(t, []string{"example-1.10-h0_1.tar.bz2", "other-0.1-0.tar.bz2"}, keys(d.Packages))
+}
+
+func keys(m map[string]Record) (out []string) {
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
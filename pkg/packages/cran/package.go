@@ -0,0 +1,236 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cran
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	ContribDir = "src/contrib"
+	ArchiveDir = "Archive"
+
+	descriptionFile = "DESCRIPTION"
+)
+
+var (
+	ErrInvalidPackage     = errors.New("R package is invalid")
+	ErrMissingDescription = errors.New("DESCRIPTION file is missing")
+
+	// https://cran.r-project.org/doc/manuals/r-release/R-exts.html#The-DESCRIPTION-file
+	namePattern    = regexp.MustCompile(`\A[a-zA-Z][a-zA-Z0-9.]*[a-zA-Z0-9]\z`)
+	versionPattern = regexp.MustCompile(`\A\d+([.-]\d+)+\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName     string            `json:"name"`
+	PkgVersion  string            `json:"version"`
+	Description map[string]string `json:"description"`
+
+	Published time.Time `json:"published"`
+	PkgSize   int64     `json:"size"`
+	FilePath  string    `json:"filePath"`
+	MD5       string    `json:"md5"`
+	SHA256    string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// FileName returns the source package file name, e.g. foo_1.0.0.tar.gz
+func FileName(name, version string) string {
+	return fmt.Sprintf("%s_%s.tar.gz", name, version)
+}
+
+// NewPackage creates a package from a source package tarball built with R CMD build
+func NewPackage(r io.Reader) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	d, err := readDescription(reader)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	name, version := d["Package"], d["Version"]
+	if !namePattern.MatchString(name) {
+		reader.Close()
+		return nil, fmt.Errorf("%w: invalid name %q", ErrInvalidPackage, name)
+	}
+	if !versionPattern.MatchString(version) {
+		reader.Close()
+		return nil, fmt.Errorf("%w: invalid version %q", ErrInvalidPackage, version)
+	}
+	if _, ok := d["Built"]; ok {
+		reader.Close()
+		return nil, fmt.Errorf("%w: %s is a binary package", ErrInvalidPackage, name)
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	md5, _, sha256, _ := reader.Sums()
+	return &Package{
+		PkgName:     name,
+		PkgVersion:  version,
+		Description: d,
+		Published:   time.Now().UTC(),
+		PkgSize:     reader.Size(),
+		FilePath:    path.Join(ContribDir, FileName(name, version)),
+		MD5:         hex.EncodeToString(md5),
+		SHA256:      hex.EncodeToString(sha256),
+		reader:      reader,
+	}, nil
+}
+
+// readDescription returns the {package}/DESCRIPTION file fields
+func readDescription(r io.Reader) (map[string]string, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrMissingDescription
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		parts := strings.Split(strings.TrimPrefix(path.Clean(h.Name), "./"), "/")
+		if len(parts) != 2 || parts[1] != descriptionFile || h.Typeflag != tar.TypeReg {
+			continue
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		return ParseDCF(b)
+	}
+}
+
+// ParseDCF parses a Debian Control File formatted content, where the continuation lines start with a whitespace
+// and are folded into a single line
+func ParseDCF(b []byte) (map[string]string, error) {
+	m := make(map[string]string)
+	var key string
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if key == "" {
+				return nil, fmt.Errorf("%w: unexpected continuation line %q", ErrInvalidPackage, line)
+			}
+			m[key] += " " + strings.TrimSpace(line)
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid line %q", ErrInvalidPackage, line)
+		}
+		key = k
+		m[key] = strings.TrimSpace(v)
+	}
+	return m, s.Err()
+}
+
+// CompareVersions compares the R package versions, which are sequences of numbers separated by dots or dashes
+func CompareVersions(a, b string) int {
+	split := func(v string) []string {
+		return strings.FieldsFunc(v, func(r rune) bool {
+			return r == '.' || r == '-'
+		})
+	}
+	pa, pb := split(a), split(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		if i >= len(pa) {
+			return -1
+		}
+		if i >= len(pb) {
+			return 1
+		}
+		na, _ := strconv.Atoi(pa[i])
+		nb, _ := strconv.Atoi(pb[i])
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
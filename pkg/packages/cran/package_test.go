@@ -0,0 +1,135 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cran
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDescription = `Package: foo
+Type: Package
+Title: An Example Package
+Version: 1.0-2
+Description: An example package,
+    on two lines.
+License: MIT + file LICENSE
+Imports: stats,
+	utils
+NeedsCompilation: no
+`
+
+func testTarball(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return b.Bytes()
+}
+
+func TestNewPackage(t *testing.T) {
+	b := testTarball(t, map[string]string{
+		"foo/R/foo.R":              "",
+		"foo/inst/bar/DESCRIPTION": "Package: bar",
+		"./foo/DESCRIPTION":        testDescription,
+	})
+	pkg, err := NewPackage(bytes.NewReader(b))
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, "foo", pkg.Name())
+	assert.Equal(t, "1.0-2", pkg.Version())
+	assert.Equal(t, "src/contrib/foo_1.0-2.tar.gz", pkg.Path())
+	assert.Equal(t, int64(len(b)), pkg.Size())
+	assert.Len(t, pkg.MD5, 32)
+	assert.Len(t, pkg.SHA256, 64)
+	assert.Equal(t, "An example package, on two lines.", pkg.Description["Description"])
+	assert.Equal(t, "stats, utils", pkg.Description["Imports"])
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "not a tarball",
+			data:    []byte("not a tarball"),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "missing description",
+			data:    testTarball(t, map[string]string{"DESCRIPTION": testDescription, "foo/inst/DESCRIPTION/x": ""}),
+			wantErr: ErrMissingDescription,
+		},
+		{
+			name:    "invalid description",
+			data:    testTarball(t, map[string]string{"foo/DESCRIPTION": "  continuation\nPackage: foo"}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "invalid name",
+			data:    testTarball(t, map[string]string{"foo/DESCRIPTION": "Package: 1foo\nVersion: 1.0"}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "invalid version",
+			data:    testTarball(t, map[string]string{"foo/DESCRIPTION": "Package: foo\nVersion: 1"}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "binary package",
+			data:    testTarball(t, map[string]string{"foo/DESCRIPTION": testDescription + "Built: R 4.3.1; ; 2023-08-01 10:00:00 UTC; unix\n"}),
+			wantErr: ErrInvalidPackage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(tt.data))
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0-2", b: "1.0.2", want: 0},
+		{a: "1.0-10", b: "1.0-9", want: 1},
+		{a: "1.0", b: "1.0-1", want: -1},
+		{a: "2.0", b: "10.0", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, CompareVersions(tt.a, tt.b))
+			assert.Equal(t, -tt.want, CompareVersions(tt.b, tt.a))
+		})
+	}
+}
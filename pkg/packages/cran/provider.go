@@ -0,0 +1,160 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cran
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "cran"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+func (p *provider) push(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		reader := r.Body
+		if file, _, err := r.FormFile("file"); err == nil {
+			reader = file
+		}
+		defer reader.Close()
+		pkg, err := NewPackage(reader)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		if _, err := s.Stat(ctx, pkg.Path()); err == nil {
+			http.Error(w, fmt.Sprintf("package %s version %s already exists", pkg.PkgName, pkg.PkgVersion), http.StatusConflict)
+			return
+		} else if !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.Version()).Infof("uploading package")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// download serves the latest version of a package from src/contrib, and its previous versions
+// from src/contrib/Archive/{name} as done by CRAN
+func (p *provider) download(archived bool) packages.HandlerFunc {
+	return func(_ string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			s := storage.FromContext(ctx)
+			as, err := s.Artifacts(ctx)
+			if err != nil {
+				storage.Error(w, err)
+				return
+			}
+			pkgs := storage.MustAs[*Package](as)
+			file := filePath(r)
+			for _, v := range pkgs {
+				if v.FilePath != file {
+					continue
+				}
+				if (archived && mux.Vars(r)["name"] != v.PkgName) || IsLatest(v, pkgs) == archived {
+					break
+				}
+				if err := s.ServeFile(w, r, file); err != nil {
+					storage.Error(w, err)
+				}
+				return
+			}
+			packages.NotFound(w, r)
+		}
+	}
+}
+
+func filePath(r *http.Request) string {
+	return path.Join(ContribDir, mux.Vars(r)["file"])
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/" + ContribDir + "/" + PackagesFile,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return path.Join(ContribDir, PackagesFile)
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + ContribDir + "/" + PackagesFile + GzipExt,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return path.Join(ContribDir, PackagesFile+GzipExt)
+			}),
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/push",
+			Handler: p.push,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + ContribDir + "/" + ArchiveDir + "/{name}/{file}",
+			Handler: p.download(true),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/" + ContribDir + "/" + ArchiveDir + "/{name}/{file}",
+			Handler: packages.Delete(filePath),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + ContribDir + "/{file}",
+			Handler: p.download(false),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/" + ContribDir + "/{file}",
+			Handler: packages.Delete(filePath),
+		},
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cran
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	PackagesFile = "PACKAGES"
+	GzipExt      = ".gz"
+)
+
+// packagesFields are the DESCRIPTION fields written in the PACKAGES file, as done by tools::write_PACKAGES
+var packagesFields = []string{
+	"Package",
+	"Version",
+	"Priority",
+	"Depends",
+	"Imports",
+	"LinkingTo",
+	"Suggests",
+	"Enhances",
+	"License",
+	"License_is_FOSS",
+	"License_restricts_use",
+	"OS_type",
+	"Archs",
+	"MD5sum",
+	"NeedsCompilation",
+}
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "cran"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "CRAN Repository", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Index writes the src/contrib/PACKAGES files listing the latest version of each package,
+// the previous versions being only available from the src/contrib/Archive directory
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	latest := make(map[string]*Package)
+	for _, v := range pkgs {
+		if l, ok := latest[v.PkgName]; !ok || CompareVersions(v.PkgVersion, l.PkgVersion) > 0 {
+			latest[v.PkgName] = v
+		}
+	}
+	names := make([]string, 0, len(latest))
+	for k := range latest {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		p := latest[name]
+		for _, k := range packagesFields {
+			v := p.Description[k]
+			if k == "MD5sum" {
+				v = p.MD5
+			}
+			if v == "" {
+				continue
+			}
+			fmt.Fprintf(&b, "%s: %s\n", k, v)
+		}
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write(b.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return []storage.Artifact{
+		storage.NewFile(path.Join(ContribDir, PackagesFile), b.Bytes()),
+		storage.NewFile(path.Join(ContribDir, PackagesFile+GzipExt), gz.Bytes()),
+	}, nil
+}
+
+// IsLatest reports whether the package is the latest version of its name
+func IsLatest(p *Package, pkgs []*Package) bool {
+	for _, v := range pkgs {
+		if v.PkgName == p.PkgName && CompareVersions(v.PkgVersion, p.PkgVersion) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cran
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPackage(name, version string, description map[string]string) *Package {
+	d := map[string]string{"Package": name, "Version": version, "Title": "A package"}
+	for k, v := range description {
+		d[k] = v
+	}
+	return &Package{
+		PkgName:     name,
+		PkgVersion:  version,
+		Description: d,
+		FilePath:    "src/contrib/" + FileName(name, version),
+		MD5:         "md5-" + name + "-" + version,
+	}
+}
+
+func TestIndex(t *testing.T) {
+	foo := testPackage("foo", "1.0-10", map[string]string{"Imports": "stats, utils", "License": "MIT", "NeedsCompilation": "no"})
+	pkgs := []*Package{
+		testPackage("foo", "1.0-9", nil),
+		foo,
+		testPackage("bar", "0.1.0", map[string]string{"Depends": "R (>= 4.0)"}),
+	}
+	out, err := (&repo{}).Index(context.Background(), "", foo, pkgs[0], pkgs[2])
+	require.NoError(t, err)
+	require.Len(t, out, 2)
+	assert.Equal(t, "src/contrib/PACKAGES", out[0].Path())
+	b, err := io.ReadAll(out[0])
+	require.NoError(t, err)
+	want := "Package: bar\nVersion: 0.1.0\nDepends: R (>= 4.0)\nMD5sum: md5-bar-0.1.0\n\n" +
+		"Package: foo\nVersion: 1.0-10\nImports: stats, utils\nLicense: MIT\nMD5sum: md5-foo-1.0-10\nNeedsCompilation: no\n"
+	assert.Equal(t, want, string(b))
+
+	assert.Equal(t, "src/contrib/PACKAGES.gz", out[1].Path())
+	gr, err := gzip.NewReader(out[1])
+	require.NoError(t, err)
+	b, err = io.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(b))
+
+	d, err := ParseDCF(bytes.Split(b, []byte("\n\n"))[1])
+	require.NoError(t, err)
+	assert.Equal(t, "1.0-10", d["Version"])
+
+	assert.True(t, IsLatest(foo, pkgs))
+	assert.False(t, IsLatest(pkgs[0], pkgs))
+	assert.True(t, IsLatest(pkgs[2], pkgs))
+
+	out, err = (&repo{}).Index(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
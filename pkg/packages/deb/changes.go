@@ -0,0 +1,241 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+)
+
+var (
+	ErrInvalidSignature    = errors.New("changes file is not signed by a trusted key")
+	ErrInvalidDistribution = errors.New("changes file distribution is invalid")
+	ErrMissingChangesFiles = errors.New("changes file does not reference any file")
+	ErrInvalidChangesFile  = errors.New("file does not match the changes file")
+	ErrInvalidFileName     = errors.New("invalid file name")
+	ErrUnsupportedUdeb     = errors.New("udeb packages are not supported")
+)
+
+// Changes is the upload control file describing a source package upload
+// https://manpages.debian.org/bookworm/dpkg-dev/deb-changes.5.en.html
+type Changes struct {
+	Source       string
+	Version      string
+	Distribution string
+	Files        []*ChangesFile
+}
+
+// ChangesFile is a file referenced by a changes file
+type ChangesFile struct {
+	Name   string
+	Size   int64
+	MD5    string
+	SHA256 string
+}
+
+// Verify checks the file content against the checksums listed in the changes file
+func (f *ChangesFile) Verify(r io.Reader) error {
+	m, s := md5.New(), sha256.New()
+	n, err := io.Copy(io.MultiWriter(m, s), r)
+	if err != nil {
+		return err
+	}
+	if n != f.Size || hex.EncodeToString(m.Sum(nil)) != f.MD5 || (f.SHA256 != "" && hex.EncodeToString(s.Sum(nil)) != f.SHA256) {
+		return ErrInvalidChangesFile
+	}
+	return nil
+}
+
+// ParseChangesFile parses the changes file and verifies its signature using the keyring.
+// The signature is not verified if the keyring is empty.
+func ParseChangesFile(b []byte, keyring openpgp.EntityList) (*Changes, error) {
+	block, _ := clearsign.Decode(b)
+	if len(keyring) != 0 {
+		if block == nil {
+			return nil, ErrInvalidSignature
+		}
+		if _, err := block.VerifySignature(keyring, nil); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+	}
+	if block != nil {
+		b = block.Plaintext
+	}
+
+	c := &Changes{}
+	key := ""
+	files := make(map[string]*ChangesFile)
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			var sum, size, name string
+			parts := strings.Fields(trimmed)
+			switch {
+			// md5 size section priority name
+			case key == "Files" && len(parts) == 5:
+				sum, size, name = parts[0], parts[1], parts[4]
+			// sha256 size name
+			case key == "Checksums-Sha256" && len(parts) == 3:
+				sum, size, name = parts[0], parts[1], parts[2]
+			case key == "Files" || key == "Checksums-Sha256":
+				return nil, fmt.Errorf("invalid %s entry: %q", key, trimmed)
+			default:
+				continue
+			}
+			n, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s entry: %q", key, trimmed)
+			}
+			if !validFileName(name) {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidFileName, name)
+			}
+			f, ok := files[name]
+			if !ok {
+				f = &ChangesFile{Name: name, Size: n}
+				files[name] = f
+				c.Files = append(c.Files, f)
+			}
+			if f.Size != n {
+				return nil, fmt.Errorf("%s: %w", name, ErrInvalidChangesFile)
+			}
+			if key == "Files" {
+				f.MD5 = sum
+			} else {
+				f.SHA256 = sum
+			}
+			continue
+		}
+
+		parts := strings.SplitN(trimmed, ":", 2)
+		if len(parts) < 2 {
+			continue
+		}
+
+		key = parts[0]
+		value := strings.TrimSpace(parts[1])
+		switch key {
+		case "Source":
+			// the source version may follow the name, e.g. "hello (2.10-3)"
+			c.Source = strings.Fields(value + " ")[0]
+		case "Version":
+			c.Version = value
+		case "Distribution":
+			c.Distribution = value
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if !namePattern.MatchString(c.Source) {
+		return nil, ErrInvalidName
+	}
+	if !versionPattern.MatchString(c.Version) {
+		return nil, ErrInvalidVersion
+	}
+	// only a single distribution is supported
+	if c.Distribution == "" || strings.ContainsAny(c.Distribution, " \t/") || c.Distribution == "." || c.Distribution == ".." {
+		return nil, ErrInvalidDistribution
+	}
+	if len(c.Files) == 0 {
+		return nil, ErrMissingChangesFiles
+	}
+	for _, v := range c.Files {
+		if v.MD5 == "" {
+			return nil, fmt.Errorf("%s: %w", v.Name, ErrInvalidChangesFile)
+		}
+		// the installer packages belong to the debian-installer sections which are not indexed
+		if filepath.Ext(v.Name) == ".udeb" {
+			return nil, fmt.Errorf("%s: %w", v.Name, ErrUnsupportedUdeb)
+		}
+	}
+	return c, nil
+}
+
+// Packages verifies the files referenced by the changes file and returns the binary and source packages
+// it contains, the other files, like the .buildinfo, are ignored.
+func (c *Changes) Packages(open SourceFileOpener, component string) (_ []*Package, err error) {
+	var pkgs []*Package
+	defer func() {
+		if err != nil {
+			for _, v := range pkgs {
+				v.Close()
+			}
+		}
+	}()
+	for _, v := range c.Files {
+		if err := c.verify(v, open); err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+	}
+	for _, v := range c.Files {
+		var pkg *Package
+		switch filepath.Ext(v.Name) {
+		case ".deb":
+			rc, err := open(v.Name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.Name, err)
+			}
+			pkg, err = NewPackage(rc, c.Distribution, component)
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.Name, err)
+			}
+		case ".dsc":
+			rc, err := open(v.Name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.Name, err)
+			}
+			pkg, err = NewSourcePackage(rc, open, c.Distribution, component)
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v.Name, err)
+			}
+		default:
+			continue
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+func (c *Changes) verify(f *ChangesFile, open SourceFileOpener) error {
+	rc, err := open(f.Name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return f.Verify(rc)
+}
@@ -0,0 +1,199 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testChanges = `Format: 1.8
+Source: hello (2.10-3)
+Binary: hello
+Architecture: source amd64
+Version: 2.10-3
+Distribution: stable
+Checksums-Sha256:
+ b6cf5c0d0f2b9d6b5c4e3a2e1f0c8b7a6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a 1183 hello_2.10-3.dsc
+Files:
+ a3f5d1c0e7b2a4c6d8e0f1a2b3c4d5e6 1183 devel optional hello_2.10-3.dsc
+ f1e2d3c4b5a6978877665544332211aa 53012 devel optional hello_2.10-3_amd64.deb
+`
+
+func testSign(t *testing.T, e *openpgp.Entity, text string) []byte {
+	var b bytes.Buffer
+	w, err := clearsign.Encode(&b, e.PrivateKey, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(text))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func TestParseChangesFile(t *testing.T) {
+	uploader, err := openpgp.NewEntity("Uploader", "", "uploader@example.org", nil)
+	require.NoError(t, err)
+	other, err := openpgp.NewEntity("Other", "", "other@example.org", nil)
+	require.NoError(t, err)
+	keyring := openpgp.EntityList{uploader}
+	tests := []struct {
+		name    string
+		changes []byte
+		keyring openpgp.EntityList
+		wantErr error
+		fn      func(t *testing.T, c *Changes)
+	}{
+		{
+			name:    "unsigned without keyring",
+			changes: []byte(testChanges),
+			fn: func(t *testing.T, c *Changes) {
+				assert.Equal(t, "hello", c.Source)
+				assert.Equal(t, "2.10-3", c.Version)
+				assert.Equal(t, "stable", c.Distribution)
+				require.Len(t, c.Files, 2)
+				assert.Equal(t, &ChangesFile{
+					Name:   "hello_2.10-3.dsc",
+					Size:   1183,
+					MD5:    "a3f5d1c0e7b2a4c6d8e0f1a2b3c4d5e6",
+					SHA256: "b6cf5c0d0f2b9d6b5c4e3a2e1f0c8b7a6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a",
+				}, c.Files[0])
+				assert.Equal(t, &ChangesFile{
+					Name: "hello_2.10-3_amd64.deb",
+					Size: 53012,
+					MD5:  "f1e2d3c4b5a6978877665544332211aa",
+				}, c.Files[1])
+			},
+		},
+		{
+			name:    "signed by an uploader",
+			changes: testSign(t, uploader, testChanges),
+			keyring: keyring,
+			fn: func(t *testing.T, c *Changes) {
+				assert.Equal(t, "hello", c.Source)
+				assert.Len(t, c.Files, 2)
+			},
+		},
+		{
+			name:    "unsigned",
+			changes: []byte(testChanges),
+			keyring: keyring,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "unknown uploader",
+			changes: testSign(t, other, testChanges),
+			keyring: keyring,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "bad signature",
+			changes: bytes.Replace(testSign(t, uploader, testChanges), []byte("Distribution: stable"), []byte("Distribution: unstable"), 1),
+			keyring: keyring,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "file in a sub directory",
+			changes: []byte(strings.Replace(testChanges, " hello_2.10-3_amd64.deb", " ../hello_2.10-3_amd64.deb", 1)),
+			wantErr: ErrInvalidFileName,
+		},
+		{
+			name:    "absolute file path",
+			changes: []byte(strings.Replace(testChanges, " hello_2.10-3_amd64.deb", " /tmp/hello_2.10-3_amd64.deb", 1)),
+			wantErr: ErrInvalidFileName,
+		},
+		{
+			name:    "size mismatch",
+			changes: []byte(strings.Replace(testChanges, " 1183 hello_2.10-3.dsc", " 1184 hello_2.10-3.dsc", 1)),
+			wantErr: ErrInvalidChangesFile,
+		},
+		{
+			name:    "multiple distributions",
+			changes: []byte(strings.Replace(testChanges, "Distribution: stable", "Distribution: stable unstable", 1)),
+			wantErr: ErrInvalidDistribution,
+		},
+		{
+			name:    "installer package",
+			changes: []byte(testChanges + " 0123456789abcdef0123456789abcdef 41234 debian-installer optional hello-udeb_2.10-3_amd64.udeb\n"),
+			wantErr: ErrUnsupportedUdeb,
+		},
+		{
+			name:    "parent file name",
+			changes: []byte(strings.Replace(testChanges, " hello_2.10-3_amd64.deb", " ..", 1)),
+			wantErr: ErrInvalidFileName,
+		},
+		{
+			name:    "no files",
+			changes: []byte(testChanges[:strings.Index(testChanges, "Checksums-Sha256:")]),
+			wantErr: ErrMissingChangesFiles,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseChangesFile(tt.changes, tt.keyring)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.fn(t, c)
+		})
+	}
+}
+
+func TestChangesPackages(t *testing.T) {
+	deb := testDeb(t, testControl("hello", "amd64"), "usr/bin/hello")
+	m, s := testSums(string(deb))
+	c := &Changes{
+		Source:       "hello",
+		Version:      "1.0.0",
+		Distribution: "stable",
+		Files: []*ChangesFile{
+			{Name: "hello_1.0.0_amd64.deb", Size: int64(len(deb)), MD5: m, SHA256: s},
+			{Name: "hello_1.0.0_amd64.buildinfo", Size: 9, MD5: "2f24b2a4a9d7b4f1b8d3bd8b2ba1f3b0"},
+		},
+	}
+	files := map[string][]byte{
+		"hello_1.0.0_amd64.deb":       deb,
+		"hello_1.0.0_amd64.buildinfo": []byte("buildinfo"),
+	}
+	open := func(name string) (io.ReadCloser, error) {
+		b, ok := files[name]
+		if !ok {
+			return nil, ErrMissingSourceFile
+		}
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	_, err := c.Packages(open, "main")
+	assert.ErrorIs(t, err, ErrInvalidChangesFile)
+
+	bm, _ := testSums("buildinfo")
+	c.Files[1].MD5 = bm
+	pkgs, err := c.Packages(open, "main")
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	defer pkgs[0].Close()
+	assert.Equal(t, "pool/stable/main/hello_1.0.0_amd64.deb", pkgs[0].Path())
+
+	delete(files, "hello_1.0.0_amd64.buildinfo")
+	_, err = c.Packages(open, "main")
+	assert.ErrorIs(t, err, ErrMissingSourceFile)
+}
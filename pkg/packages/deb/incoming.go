@@ -0,0 +1,187 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"path"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+// incomingTTL is the time the uploaded files are kept while waiting for the .changes file referencing them
+const incomingTTL = time.Hour
+
+var _ storage.Repository = (*incomingRepo)(nil)
+
+// incomingRepo stores the files uploaded with dput until the .changes file referencing them is uploaded.
+// It is stored in the registry alongside the deb repository, so that the files of an upload
+// can be sent to any server instance.
+type incomingRepo struct{}
+
+func (r *incomingRepo) Name() string {
+	return Name + "-incoming"
+}
+
+// GenerateKeypair returns a random key as the staged files are not signed,
+// it is only used to mark the storage as initialized.
+func (r *incomingRepo) GenerateKeypair() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(b), "", nil
+}
+
+func (r *incomingRepo) KeyNames() (string, string) {
+	return "incoming.key", "incoming.pub"
+}
+
+func (r *incomingRepo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v incomingFile
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Index does not generate anything as the staged files are not served.
+func (r *incomingRepo) Index(_ context.Context, _ string, _ ...storage.Artifact) ([]storage.Artifact, error) {
+	return nil, nil
+}
+
+var _ storage.Artifact = (*incomingFile)(nil)
+
+// incomingFile is a file uploaded with dput waiting for the .changes file referencing it
+type incomingFile struct {
+	FileName string    `json:"name"`
+	FilePath string    `json:"filePath"`
+	FileSize int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	Uploaded time.Time `json:"uploaded"`
+
+	reader io.ReadCloser
+}
+
+// newIncomingFile buffers the file uploaded by the user
+func newIncomingFile(user, name string, r io.Reader) (*incomingFile, error) {
+	buf, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	_, _, sha256, _ := buf.Sums()
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		buf.Close()
+		return nil, err
+	}
+	return &incomingFile{
+		FileName: name,
+		FilePath: incomingPath(user, name),
+		FileSize: buf.Size(),
+		SHA256:   hex.EncodeToString(sha256),
+		Uploaded: time.Now().UTC(),
+		reader:   buf,
+	}, nil
+}
+
+// incomingPath returns the path of the file staged by the user,
+// the uploads of different users are kept apart
+func incomingPath(user, name string) string {
+	h := sha256.Sum256([]byte(user))
+	return path.Join(hex.EncodeToString(h[:]), name)
+}
+
+func (f *incomingFile) Read(b []byte) (n int, err error) {
+	if f.reader == nil {
+		return 0, io.EOF
+	}
+	return f.reader.Read(b)
+}
+
+func (f *incomingFile) Close() error {
+	if f.reader == nil {
+		return nil
+	}
+	return f.reader.Close()
+}
+
+// Name is empty so that the staged files are not tagged in their own repository,
+// they are only kept until the .changes file referencing them is processed.
+func (f *incomingFile) Name() string {
+	return ""
+}
+
+func (f *incomingFile) Path() string {
+	return f.FilePath
+}
+
+func (f *incomingFile) Arch() string {
+	return ""
+}
+
+func (f *incomingFile) Version() string {
+	return ""
+}
+
+func (f *incomingFile) Size() int64 {
+	return f.FileSize
+}
+
+func (f *incomingFile) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, f.SHA256)
+}
+
+// expired reports whether the file was not published in time
+func (f *incomingFile) expired() bool {
+	return time.Since(f.Uploaded) > incomingTTL
+}
+
+// cleanIncoming removes the staged files at the given paths along with the ones which were not published in time
+func cleanIncoming(ctx context.Context, s storage.Storage, paths ...string) error {
+	as, err := s.Artifacts(ctx)
+	if err != nil {
+		if storage.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	var names []string
+	for _, v := range storage.MustAs[*incomingFile](as) {
+		if v.expired() || len(slices.Filter(paths, func(p string) bool { return p == v.Path() })) != 0 {
+			names = append(names, v.Path())
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return s.Delete(ctx, names...)
+}
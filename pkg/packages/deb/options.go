@@ -0,0 +1,139 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+const defaultOrigin = "Artifact Registry"
+
+type optionsKey struct{}
+
+// WithOptions returns a context holding the deb repositories options.
+func WithOptions(ctx context.Context, opts ...Option) context.Context {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return context.WithValue(ctx, optionsKey{}, o)
+}
+
+// Options returns the deb repositories options from the context.
+func Options(ctx context.Context) options {
+	o, _ := ctx.Value(optionsKey{}).(options)
+	return o
+}
+
+type options struct {
+	origin     string
+	label      string
+	suites     map[string]string
+	validUntil time.Duration
+	uploaders  openpgp.EntityList
+	archs      []string
+}
+
+// Origin is the Origin field of the Release files.
+func (o options) Origin() string {
+	if o.origin == "" {
+		return defaultOrigin
+	}
+	return o.origin
+}
+
+// Label is the Label field of the Release files.
+func (o options) Label() string {
+	if o.label == "" {
+		return defaultOrigin
+	}
+	return o.label
+}
+
+// Suite is the Suite field of the distribution's Release file, it defaults to the distribution name.
+func (o options) Suite(distribution string) string {
+	if v, ok := o.suites[distribution]; ok && v != "" {
+		return v
+	}
+	return distribution
+}
+
+// ValidUntil is the validity duration of the Release files, zero means that they do not expire.
+func (o options) ValidUntil() time.Duration {
+	return o.validUntil
+}
+
+// Uploaders are the keys trusted to sign the uploaded .changes files.
+// The signature is not verified when no key is configured.
+func (o options) Uploaders() openpgp.EntityList {
+	return o.uploaders
+}
+
+// Architectures are the architectures listed in the distributions containing only
+// architecture independent packages, it defaults to DefaultArchitectures.
+func (o options) Architectures() []string {
+	if len(o.archs) == 0 {
+		return DefaultArchitectures
+	}
+	return o.archs
+}
+
+type Option func(o *options)
+
+func WithOrigin(origin string) Option {
+	return func(o *options) {
+		o.origin = origin
+	}
+}
+
+func WithLabel(label string) Option {
+	return func(o *options) {
+		o.label = label
+	}
+}
+
+// WithSuites maps the distributions to their suite, e.g. "bookworm" to "stable".
+func WithSuites(suites map[string]string) Option {
+	return func(o *options) {
+		o.suites = suites
+	}
+}
+
+// WithValidUntil sets the validity of the Release files.
+// As the Release files are only generated when the repository content changes,
+// the clients will refuse to update from a repository which was not updated during this period.
+func WithValidUntil(d time.Duration) Option {
+	return func(o *options) {
+		o.validUntil = d
+	}
+}
+
+// WithUploaders sets the keys trusted to sign the uploaded .changes files.
+func WithUploaders(keys openpgp.EntityList) Option {
+	return func(o *options) {
+		o.uploaders = keys
+	}
+}
+
+// WithArchitectures sets the architectures listed in the distributions containing only
+// architecture independent packages.
+func WithArchitectures(archs ...string) Option {
+	return func(o *options) {
+		o.archs = archs
+	}
+}
@@ -6,12 +6,16 @@ package deb
 import (
 	"archive/tar"
 	"bufio"
+	"bytes"
+	"compress/bzip2"
 	"compress/gzip"
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"net/mail"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -20,13 +24,17 @@ import (
 	"github.com/klauspost/compress/zstd"
 	"github.com/opencontainers/go-digest"
 	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
 
 	"go.linka.cloud/artifact-registry/pkg/buffer"
 	"go.linka.cloud/artifact-registry/pkg/storage"
 	"go.linka.cloud/artifact-registry/pkg/validation"
 )
 
-const controlTar = "control.tar"
+const (
+	controlTar = "control.tar"
+	dataTar    = "data.tar"
+)
 
 var (
 	ErrMissingControlFile     = errors.New("control file is missing")
@@ -41,7 +49,7 @@ var (
 	versionPattern = regexp.MustCompile(`\A(?:[0-9]:)?[a-zA-Z0-9.+~]+(?:-[a-zA-Z0-9.+-~]+)?\z`)
 )
 
-var _ storage.Artifact = (*Package)(nil)
+var _ storage.MultiFileArtifact = (*Package)(nil)
 
 type Package struct {
 	PkgName      string    `json:"name"`
@@ -60,6 +68,15 @@ type Package struct {
 	SHA256 string `json:"sha256"`
 	SHA512 string `json:"sha512"`
 
+	// Contents is the list of the files shipped by a binary package, it is only available when the package
+	// has just been parsed as it is stored in the ContentsFile to keep it out of the repository manifest
+	Contents []string `json:"-"`
+	// ContentsFile is the gzipped list of the files shipped by a binary package
+	ContentsFile *SourceFile `json:"contentsFile,omitempty"`
+
+	// SourceFiles are the files referenced by a source package
+	SourceFiles []*SourceFile `json:"sourceFiles,omitempty"`
+
 	reader io.ReadCloser
 }
 
@@ -71,6 +88,12 @@ func (p *Package) Read(b []byte) (n int, err error) {
 }
 
 func (p *Package) Close() error {
+	for _, v := range p.SourceFiles {
+		v.Close()
+	}
+	if p.ContentsFile != nil {
+		p.ContentsFile.Close()
+	}
 	if p.reader == nil {
 		return nil
 	}
@@ -101,8 +124,16 @@ func (p *Package) Digest() digest.Digest {
 	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
 }
 
+func (p *Package) Files() []storage.Artifact {
+	if p.ContentsFile != nil {
+		return append(storage.AsArtifact(p.SourceFiles), p.ContentsFile)
+	}
+	return storage.AsArtifact(p.SourceFiles)
+}
+
 type Metadata struct {
 	Maintainer   string   `json:"maintainer,omitempty"`
+	Section      string   `json:"section,omitempty"`
 	ProjectURL   string   `json:"projectURL,omitempty"`
 	Description  string   `json:"description,omitempty"`
 	Dependencies []string `json:"dependencies,omitempty"`
@@ -123,6 +154,9 @@ func NewPackage(r io.Reader, distribution, component string) (*Package, error) {
 	pkg.Component = component
 	pkg.Distribution = distribution
 	pkg.FilePath = filepath.Join("pool", pkg.Distribution, pkg.Component, fmt.Sprintf("%s_%s_%s.deb", pkg.PkgName, pkg.PkgVersion, pkg.Architecture))
+	if pkg.ContentsFile, err = newContentsFile(strings.TrimSuffix(pkg.FilePath, ".deb")+".contents.gz", pkg.Contents); err != nil {
+		return nil, err
+	}
 	pkg.reader = reader
 	pkg.PkgSize = size
 	md5, sha1, sha256, sha512 := reader.Sums()
@@ -137,6 +171,8 @@ func NewPackage(r io.Reader, distribution, component string) (*Package, error) {
 func parsePackage(r io.Reader) (*Package, error) {
 	arr := ar.NewReader(r)
 
+	var pkg *Package
+	var contents []string
 	for {
 		hd, err := arr.Next()
 		if err == io.EOF {
@@ -146,62 +182,167 @@ func parsePackage(r io.Reader) (*Package, error) {
 			return nil, err
 		}
 
-		if strings.HasPrefix(hd.Name, controlTar) {
-			var inner io.Reader
-			// https://man7.org/linux/man-pages/man5/deb-split.5.html#FORMAT
-			// The file names might contain a trailing slash (since dpkg 1.15.6).
-			switch strings.TrimSuffix(hd.Name[len(controlTar):], "/") {
-			case "":
-				inner = arr
-			case ".gz":
-				gzr, err := gzip.NewReader(arr)
-				if err != nil {
-					return nil, err
+		switch {
+		case strings.HasPrefix(hd.Name, controlTar):
+			err = readTar(arr, hd.Name[len(controlTar):], func(tr *tar.Reader) error {
+				for {
+					hd, err := tr.Next()
+					if err == io.EOF {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+
+					if hd.Typeflag != tar.TypeReg {
+						continue
+					}
+
+					if hd.FileInfo().Name() == "control" {
+						pkg, err = ParseControlFile(tr)
+						return err
+					}
 				}
-				defer gzr.Close()
-
-				inner = gzr
-			case ".xz":
-				xzr, err := xz.NewReader(arr)
-				if err != nil {
-					return nil, err
+			})
+		case strings.HasPrefix(hd.Name, dataTar):
+			err = readTar(arr, hd.Name[len(dataTar):], func(tr *tar.Reader) error {
+				for {
+					hd, err := tr.Next()
+					if err == io.EOF {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+
+					// the Contents indices list the files and the links, not the directories
+					switch hd.Typeflag {
+					case tar.TypeReg, tar.TypeSymlink, tar.TypeLink:
+						contents = append(contents, strings.TrimPrefix(path.Clean("/"+hd.Name), "/"))
+					}
 				}
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
 
-				inner = xzr
-			case ".zst":
-				zr, err := zstd.NewReader(arr)
-				if err != nil {
-					return nil, err
-				}
-				defer zr.Close()
+	if pkg == nil {
+		return nil, ErrMissingControlFile
+	}
+	pkg.Contents = contents
 
-				inner = zr
-			default:
-				return nil, ErrUnsupportedCompression
-			}
+	return pkg, nil
+}
 
-			tr := tar.NewReader(inner)
-			for {
-				hd, err := tr.Next()
-				if err == io.EOF {
-					break
-				}
-				if err != nil {
-					return nil, err
-				}
+// newContentsFile returns the gzipped list of the files shipped by the package
+func newContentsFile(path string, contents []string) (*SourceFile, error) {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	for _, v := range contents {
+		fmt.Fprintln(gzw, v)
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, err
+	}
+	buf, err := buffer.CreateHashedBufferFromReader(&b)
+	if err != nil {
+		return nil, err
+	}
+	md5, sha1, sha256, sha512 := buf.Sums()
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		buf.Close()
+		return nil, err
+	}
+	return &SourceFile{
+		FileName: filepath.Base(path),
+		FilePath: path,
+		FileSize: buf.Size(),
+		MD5:      hex.EncodeToString(md5),
+		SHA1:     hex.EncodeToString(sha1),
+		SHA256:   hex.EncodeToString(sha256),
+		SHA512:   hex.EncodeToString(sha512),
+		reader:   buf,
+	}, nil
+}
 
-				if hd.Typeflag != tar.TypeReg {
-					continue
-				}
+// readContents returns the list of the files shipped by the package, reading it from the storage
+// when the package was loaded from the repository
+func readContents(ctx context.Context, pkg *Package) ([]string, error) {
+	// the contents file is only readable from the storage once the package has been written
+	if pkg.ContentsFile == nil || pkg.ContentsFile.reader != nil {
+		return pkg.Contents, nil
+	}
+	s, ok := storage.Lookup(ctx)
+	if !ok {
+		return nil, nil
+	}
+	rc, err := s.Open(ctx, pkg.ContentsFile.Path())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pkg.ContentsFile.Path(), err)
+	}
+	defer rc.Close()
+	gzr, err := gzip.NewReader(rc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pkg.ContentsFile.Path(), err)
+	}
+	defer gzr.Close()
+	var contents []string
+	sc := bufio.NewScanner(gzr)
+	for sc.Scan() {
+		if l := sc.Text(); l != "" {
+			contents = append(contents, l)
+		}
+	}
+	return contents, sc.Err()
+}
 
-				if hd.FileInfo().Name() == "control" {
-					return ParseControlFile(tr)
-				}
-			}
+// readTar calls fn with a reader for the tar archive compressed with the given extension
+func readTar(r io.Reader, ext string, fn func(tr *tar.Reader) error) error {
+	var inner io.Reader
+	// https://man7.org/linux/man-pages/man5/deb-split.5.html#FORMAT
+	// The file names might contain a trailing slash (since dpkg 1.15.6).
+	switch strings.TrimSuffix(ext, "/") {
+	case "":
+		inner = r
+	case ".gz":
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
 		}
+		defer gzr.Close()
+
+		inner = gzr
+	case ".xz":
+		xzr, err := xz.NewReader(r)
+		if err != nil {
+			return err
+		}
+
+		inner = xzr
+	case ".bz2":
+		inner = bzip2.NewReader(r)
+	case ".lzma":
+		lr, err := lzma.NewReader(r)
+		if err != nil {
+			return err
+		}
+
+		inner = lr
+	case ".zst":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+
+		inner = zr
+	default:
+		return ErrUnsupportedCompression
 	}
 
-	return nil, ErrMissingControlFile
+	return fn(tar.NewReader(inner))
 }
 
 // ParseControlFile parses a Debian control file to retrieve the metadata
@@ -245,6 +386,8 @@ func ParseControlFile(r io.Reader) (*Package, error) {
 				p.PkgVersion = value
 			case "Architecture":
 				p.Architecture = value
+			case "Section":
+				p.Metadata.Section = value
 			case "Maintainer":
 				a, err := mail.ParseAddress(value)
 				if err != nil || a.Name == "" {
@@ -0,0 +1,137 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blakesmith/ar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDeb builds a debian package from the control file and the data files paths,
+// the "path -> target" entries are added as symlinks
+func testDeb(t *testing.T, control string, files ...string) []byte {
+	targz := func(fn func(tw *tar.Writer)) []byte {
+		var b bytes.Buffer
+		gzw := gzip.NewWriter(&b)
+		tw := tar.NewWriter(gzw)
+		fn(tw)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gzw.Close())
+		return b.Bytes()
+	}
+	add := func(tw *tar.Writer, hd *tar.Header, content string) {
+		hd.Size = int64(len(content))
+		hd.Mode = 0644
+		require.NoError(t, tw.WriteHeader(hd))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	ctrl := targz(func(tw *tar.Writer) {
+		add(tw, &tar.Header{Name: "./control", Typeflag: tar.TypeReg}, control)
+	})
+	data := targz(func(tw *tar.Writer) {
+		add(tw, &tar.Header{Name: "./usr/", Typeflag: tar.TypeDir}, "")
+		for _, v := range files {
+			if name, target, ok := strings.Cut(v, " -> "); ok {
+				add(tw, &tar.Header{Name: "./" + name, Typeflag: tar.TypeSymlink, Linkname: target}, "")
+				continue
+			}
+			add(tw, &tar.Header{Name: "./" + v, Typeflag: tar.TypeReg}, v)
+		}
+	})
+	var b bytes.Buffer
+	w := ar.NewWriter(&b)
+	require.NoError(t, w.WriteGlobalHeader())
+	for _, v := range []struct {
+		name string
+		data []byte
+	}{
+		{"debian-binary", []byte("2.0\n")},
+		{controlTar + ".gz", ctrl},
+		{dataTar + ".gz", data},
+	} {
+		require.NoError(t, w.WriteHeader(&ar.Header{Name: v.name, Size: int64(len(v.data)), Mode: 0644, ModTime: time.Unix(0, 0)}))
+		_, err := w.Write(v.data)
+		require.NoError(t, err)
+	}
+	return b.Bytes()
+}
+
+func testControl(name, arch string) string {
+	return "Package: " + name + "\nVersion: 1.0.0\nArchitecture: " + arch + "\nMaintainer: Test <test@example.org>\nSection: utils\nDescription: test package\n"
+}
+
+func TestNewPackage(t *testing.T) {
+	tests := []struct {
+		name     string
+		control  string
+		files    []string
+		contents []string
+		wantErr  error
+	}{
+		{
+			name:     "files and links",
+			control:  testControl("hello", "amd64"),
+			files:    []string{"usr/bin/hello", "usr/share/doc/hello/copyright", "usr/bin/hi -> hello"},
+			contents: []string{"usr/bin/hello", "usr/share/doc/hello/copyright", "usr/bin/hi"},
+		},
+		{
+			name:    "no files",
+			control: testControl("empty", "all"),
+		},
+		{
+			name:    "missing control file",
+			wantErr: ErrMissingControlFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testDeb(t, tt.control, tt.files...)
+			if tt.control == "" {
+				// rename the control archive so that it is ignored
+				b = bytes.Replace(b, []byte(controlTar+".gz"), []byte("xontrol.tar.gz"), 1)
+			}
+			pkg, err := NewPackage(bytes.NewReader(b), "stable", "main")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, tt.contents, pkg.Contents)
+			require.NotNil(t, pkg.ContentsFile)
+			assert.Equal(t, "pool/stable/main/"+pkg.PkgName+"_1.0.0_"+pkg.Architecture+".contents.gz", pkg.ContentsFile.Path())
+			assert.Contains(t, pkg.Files(), pkg.ContentsFile)
+			gzr, err := gzip.NewReader(pkg.ContentsFile)
+			require.NoError(t, err)
+			b, err = io.ReadAll(gzr)
+			require.NoError(t, err)
+			var want string
+			for _, v := range tt.contents {
+				want += v + "\n"
+			}
+			assert.Equal(t, want, string(b))
+		})
+	}
+}
@@ -16,8 +16,10 @@ package deb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -25,6 +27,7 @@ import (
 	"github.com/gorilla/mux"
 	"go.linka.cloud/grpc-toolkit/logger"
 
+	"go.linka.cloud/artifact-registry/pkg/auth"
 	"go.linka.cloud/artifact-registry/pkg/packages"
 	"go.linka.cloud/artifact-registry/pkg/storage"
 )
@@ -79,6 +82,175 @@ func (p *provider) setup(_ string) http.HandlerFunc {
 	}
 }
 
+// pushSource handles the upload of a source package as a multipart form containing the source control file (.dsc)
+// and the files it references.
+// The referenced files already published in the pool, e.g. the upstream tarball shared by the package revisions,
+// can be omitted.
+func (p *provider) pushSource(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+		var dsc io.Reader
+		files := make(map[string]*multipart.FileHeader)
+		for _, v := range r.MultipartForm.File {
+			for _, h := range v {
+				name := filepath.Base(h.Filename)
+				if filepath.Ext(name) != ".dsc" {
+					files[name] = h
+					continue
+				}
+				if dsc != nil {
+					http.Error(w, "multiple source control files", http.StatusBadRequest)
+					return
+				}
+				f, err := h.Open()
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				defer f.Close()
+				dsc = f
+			}
+		}
+		if dsc == nil {
+			http.Error(w, "missing source control file", http.StatusBadRequest)
+			return
+		}
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		dist, component := mux.Vars(r)["distribution"], mux.Vars(r)["component"]
+		pkg, err := NewSourcePackage(dsc, func(name string) (io.ReadCloser, error) {
+			if h, ok := files[name]; ok {
+				return h.Open()
+			}
+			rc, err := s.Open(ctx, filepath.Join("pool", dist, component, name))
+			if storage.IsNotFound(err) {
+				return nil, ErrMissingSourceFile
+			}
+			return rc, err
+		}, dist, component)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		logger.C(ctx).WithFields("name", pkg.Name(), "filepath", pkg.Path(), "arch", pkg.Arch()).Infof("uploading artifact")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// upload implements the dput http upload method: the files are uploaded one by one and staged until the .changes file
+// referencing them is uploaded, then they are verified and all the packages are published at once.
+// The files are staged in the registry so that the uploads work with multiple server instances.
+func (p *provider) upload(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user, _, ok := auth.FromContext(ctx).BasicAuth()
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="artifact-registry"`)
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		component, name := mux.Vars(r)["component"], mux.Vars(r)["filename"]
+		if name == "." || name == ".." {
+			http.Error(w, "invalid file name", http.StatusBadRequest)
+			return
+		}
+		s := storage.FromContext(ctx)
+		// reject the users who cannot publish before reading the upload
+		if err := s.CheckWrite(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		in, err := storage.NewStorage(ctx, mux.Vars(r)["repo"], &incomingRepo{})
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		defer in.Close()
+		if filepath.Ext(name) != ".changes" {
+			f, err := newIncomingFile(user, name, r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer f.Close()
+			if err := in.Write(ctx, f); err != nil {
+				storage.Error(w, err)
+				return
+			}
+			if err := cleanIncoming(ctx, in); err != nil {
+				logger.C(ctx).WithError(err).Warn("failed to remove the expired uploads")
+			}
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		changes, err := ParseChangesFile(b, Options(ctx).Uploaders())
+		if errors.Is(err, ErrInvalidSignature) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		pkgs, err := changes.Packages(func(name string) (io.ReadCloser, error) {
+			rc, err := in.Open(ctx, incomingPath(user, name))
+			if err == nil || !storage.IsNotFound(err) {
+				return rc, err
+			}
+			// the upstream tarball may already be published by a previous revision
+			rc, err = s.Open(ctx, filepath.Join("pool", changes.Distribution, component, name))
+			if storage.IsNotFound(err) {
+				return nil, ErrMissingSourceFile
+			}
+			return rc, err
+		}, component)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer func() {
+			for _, v := range pkgs {
+				v.Close()
+			}
+		}()
+		logger.C(ctx).WithFields("source", changes.Source, "version", changes.Version, "distribution", changes.Distribution).Infof("publishing changes")
+		if err := s.Write(ctx, storage.AsArtifact(pkgs)...); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		var paths []string
+		for _, v := range changes.Files {
+			paths = append(paths, incomingPath(user, v.Name))
+		}
+		if err := cleanIncoming(ctx, in, paths...); err != nil {
+			logger.C(ctx).WithError(err).Warn("failed to remove the published uploads")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
 func (p *provider) Routes() []*packages.Route {
 	return []*packages.Route{
 		{
@@ -101,6 +273,14 @@ func (p *provider) Routes() []*packages.Route {
 				return filepath.Join("dists", dist, filename)
 			}),
 		},
+		{
+			Method: http.MethodGet,
+			Path:   "/dists/{distribution}/{component}/{filename}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				dist, component, filename := mux.Vars(r)["distribution"], mux.Vars(r)["component"], mux.Vars(r)["filename"]
+				return filepath.Join("dists", dist, component, filename)
+			}),
+		},
 		{
 			Method: http.MethodGet,
 			Path:   "/dists/{distribution}/{component}/{architecture}/{filename}",
@@ -109,6 +289,32 @@ func (p *provider) Routes() []*packages.Route {
 				return filepath.Join("dists", dist, component, architecture, filename)
 			}),
 		},
+		{
+			Method: http.MethodGet,
+			Path:   "/dists/{distribution}/{component}/by-hash/{algorithm}/{hash}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				dist, component, algorithm, hash := mux.Vars(r)["distribution"], mux.Vars(r)["component"], mux.Vars(r)["algorithm"], mux.Vars(r)["hash"]
+				return filepath.Join("dists", dist, component, "by-hash", algorithm, hash)
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/dists/{distribution}/{component}/{architecture}/by-hash/{algorithm}/{hash}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				dist, component, architecture, algorithm, hash := mux.Vars(r)["distribution"], mux.Vars(r)["component"], mux.Vars(r)["architecture"], mux.Vars(r)["algorithm"], mux.Vars(r)["hash"]
+				return filepath.Join("dists", dist, component, architecture, "by-hash", algorithm, hash)
+			}),
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/upload/{component}/{filename}",
+			Handler: p.upload,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/pool/{distribution}/{component}/source/push",
+			Handler: p.pushSource,
+		},
 		{
 			Method: http.MethodPut,
 			Path:   "/pool/{distribution}/{component}/push",
@@ -133,5 +339,21 @@ func (p *provider) Routes() []*packages.Route {
 				return filepath.Join("pool", dist, component, name+"_"+version+"_"+architecture+".deb")
 			}),
 		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/pool/{distribution}/{component}/{filename:[^/]+\\.dsc}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				dist, component, filename := mux.Vars(r)["distribution"], mux.Vars(r)["component"], mux.Vars(r)["filename"]
+				return filepath.Join("pool", dist, component, filename)
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/pool/{distribution}/{component}/{filename}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				dist, component, filename := mux.Vars(r)["distribution"], mux.Vars(r)["component"], mux.Vars(r)["filename"]
+				return filepath.Join("pool", dist, component, filename)
+			}),
+		},
 	}
 }
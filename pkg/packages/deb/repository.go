@@ -7,6 +7,9 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -29,8 +32,15 @@ import (
 const (
 	RepositoryPublicKey  = "repository.key"
 	RepositoryPrivateKey = "private.key"
+
+	// AllArchitecture is the architecture of the architecture independent packages
+	AllArchitecture = "all"
 )
 
+// DefaultArchitectures are the architectures listed by default in the distributions containing only
+// architecture independent packages.
+var DefaultArchitectures = []string{"amd64", "arm64", "armel", "armhf", "i386", "ppc64el", "riscv64", "s390x"}
+
 var _ storage.Repository = (*repo)(nil)
 
 type repo struct{}
@@ -63,6 +73,9 @@ func (r *repo) Codec() storage.Codec {
 
 func (r *repo) Index(ctx context.Context, priv string, as ...storage.Artifact) (out []storage.Artifact, err error) {
 	pkgs := storage.MustAs[*Package](as)
+	// the packages contents are read from the storage only once, as the architecture independent
+	// packages are listed in all the architectures contents indices
+	contents := make(map[string][]string)
 	distributions := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
 		return p.Distribution
 	}))
@@ -73,9 +86,18 @@ func (r *repo) Index(ctx context.Context, priv string, as ...storage.Artifact) (
 		components := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
 			return p.Component
 		}))
-		architectures := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		architectures := slices.Filter(slices.Distinct(slices.Map(pkgs, func(p *Package) string {
 			return p.Architecture
-		}))
+		})), func(v string) bool {
+			return v != SourceArchitecture && v != AllArchitecture
+		})
+		// the architecture independent packages are listed in the architectures indices,
+		// so we need at least some architectures when the distribution only contains such packages
+		if len(architectures) == 0 && len(slices.Filter(pkgs, func(p *Package) bool {
+			return p.Architecture == AllArchitecture
+		})) != 0 {
+			architectures = append([]string(nil), Options(ctx).Architectures()...)
+		}
 		var rs []*storage.File
 		for _, component := range components {
 			pkgs := slices.Filter(pkgs, func(p *Package) bool {
@@ -83,7 +105,7 @@ func (r *repo) Index(ctx context.Context, priv string, as ...storage.Artifact) (
 			})
 			for _, architecture := range architectures {
 				pkgs := slices.Filter(pkgs, func(p *Package) bool {
-					return p.Architecture == architecture
+					return p.Architecture == architecture || p.Architecture == AllArchitecture
 				})
 				r, err := buildPackagesIndices(ctx, distribution, component, architecture, pkgs...)
 				if err != nil {
@@ -91,8 +113,29 @@ func (r *repo) Index(ctx context.Context, priv string, as ...storage.Artifact) (
 				}
 				rs = append(rs, r...)
 				out = append(out, storage.AsArtifact(r)...)
+				c, err := buildContentsIndex(ctx, distribution, component, architecture, contents, pkgs...)
+				if err != nil {
+					return nil, err
+				}
+				if c != nil {
+					rs = append(rs, c)
+					out = append(out, c)
+				}
+			}
+			r, err := buildSourcesIndices(ctx, distribution, component, slices.Filter(pkgs, func(p *Package) bool {
+				return p.Architecture == SourceArchitecture
+			})...)
+			if err != nil {
+				return nil, err
 			}
+			rs = append(rs, r...)
+			out = append(out, storage.AsArtifact(r)...)
+		}
+		hs, err := buildByHashFiles(ctx, rs...)
+		if err != nil {
+			return nil, err
 		}
+		out = append(out, hs...)
 		as2, err := buildReleaseFiles(ctx, distribution, components, architectures, priv, rs...)
 		if err != nil {
 			return nil, err
@@ -161,8 +204,193 @@ func buildPackagesIndices(_ context.Context, distribution, component, architectu
 	return out, nil
 }
 
+// buildContentsIndex builds the architecture Contents index, the packages contents being cached by package path
+// https://wiki.debian.org/DebianRepository/Format#A.22Contents.22_indices
+func buildContentsIndex(ctx context.Context, distribution, component, architecture string, cache map[string][]string, pkgs ...*Package) (*storage.File, error) {
+	files := make(map[string][]string)
+	for _, v := range pkgs {
+		location := v.PkgName
+		if v.Metadata != nil && v.Metadata.Section != "" {
+			location = v.Metadata.Section + "/" + v.PkgName
+		}
+		contents, ok := cache[v.FilePath]
+		if !ok {
+			var err error
+			if contents, err = readContents(ctx, v); err != nil {
+				return nil, err
+			}
+			cache[v.FilePath] = contents
+		}
+		for _, f := range contents {
+			files[f] = append(files[f], location)
+		}
+	}
+
+	// Delete the contents index if there are no files
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(files))
+	for k := range files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	contentsGzipContent := &bytes.Buffer{}
+	gzw := gzip.NewWriter(contentsGzipContent)
+	for _, v := range names {
+		locations := slices.Distinct(files[v])
+		sort.Strings(locations)
+		fmt.Fprintf(gzw, "%s %s\n", v, strings.Join(locations, ","))
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, err
+	}
+
+	return storage.NewFile(fmt.Sprintf("dists/%s/%s/Contents-%s.gz", distribution, component, architecture), contentsGzipContent.Bytes()), nil
+}
+
+// https://wiki.debian.org/DebianRepository/Format#A.22Sources.22_Indices
+func buildSourcesIndices(_ context.Context, distribution, component string, pkgs ...*Package) (out []*storage.File, err error) {
+
+	// Delete the sources indices if there are no source packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+
+	sourcesContent := &bytes.Buffer{}
+
+	sourcesGzipContent := &bytes.Buffer{}
+	gzw := gzip.NewWriter(sourcesGzipContent)
+
+	sourcesXzContent := &bytes.Buffer{}
+	xzw, err := xz.NewWriter(sourcesXzContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create xz writer: %w", err)
+	}
+
+	w := io.MultiWriter(sourcesContent, gzw, xzw)
+
+	addSeparator := false
+	for _, v := range pkgs {
+		if addSeparator {
+			fmt.Fprintln(w)
+		}
+		addSeparator = true
+
+		fmt.Fprintf(w, "%s\n", strings.TrimSpace(v.Control))
+
+		fmt.Fprintf(w, "Directory: %s\n", filepath.Dir(v.Path()))
+		// the source control file is listed with the files it references
+		files := append([]*SourceFile{{
+			FileName: filepath.Base(v.Path()),
+			FileSize: v.PkgSize,
+			MD5:      v.MD5,
+			SHA1:     v.SHA1,
+			SHA256:   v.SHA256,
+			SHA512:   v.SHA512,
+		}}, v.SourceFiles...)
+		for _, f := range []struct {
+			name string
+			sum  func(f *SourceFile) string
+		}{
+			{"Files", func(f *SourceFile) string { return f.MD5 }},
+			{"Checksums-Sha1", func(f *SourceFile) string { return f.SHA1 }},
+			{"Checksums-Sha256", func(f *SourceFile) string { return f.SHA256 }},
+			{"Checksums-Sha512", func(f *SourceFile) string { return f.SHA512 }},
+		} {
+			fmt.Fprintf(w, "%s:\n", f.name)
+			for _, vv := range files {
+				fmt.Fprintf(w, " %s %d %s\n", f.sum(vv), vv.FileSize, vv.FileName)
+			}
+		}
+	}
+
+	if err := gzw.Close(); err != nil {
+		return nil, err
+	}
+	if err := xzw.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, v := range []struct {
+		name string
+		buff *bytes.Buffer
+	}{
+		{"Sources", sourcesContent},
+		{"Sources.gz", sourcesGzipContent},
+		{"Sources.xz", sourcesXzContent},
+	} {
+		out = append(out, storage.NewFile(fmt.Sprintf("dists/%s/%s/source/%s", distribution, component, v.name), v.buff.Bytes()))
+	}
+
+	return out, nil
+}
+
+// https://wiki.debian.org/DebianRepository/Format#indices_acquisition_via_hashsums_.28by-hash.29
+// The copies of the previous indices are kept, so that the clients which retrieved the previous Release file
+// can still download the indices it references.
+func buildByHashFiles(ctx context.Context, files ...*storage.File) (out []storage.Artifact, err error) {
+	seen := make(map[string]struct{})
+	add := func(path string, data []byte) {
+		// apt uses the strongest hash listed in the Release file
+		for _, v := range []struct {
+			name string
+			sum  []byte
+		}{
+			{"SHA256", sha256Sum(data)},
+			{"SHA512", sha512Sum(data)},
+		} {
+			p := filepath.Join(filepath.Dir(path), "by-hash", v.name, hex.EncodeToString(v.sum))
+			if _, ok := seen[p]; ok {
+				continue
+			}
+			seen[p] = struct{}{}
+			out = append(out, storage.NewFile(p, data))
+		}
+	}
+	s, _ := storage.Lookup(ctx)
+	for _, v := range files {
+		data, err := io.ReadAll(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Path(), err)
+		}
+		// reset the file as we had to read it
+		*v = *storage.NewFile(v.Path(), data)
+		add(v.Path(), data)
+		if s == nil {
+			continue
+		}
+		rc, err := s.Open(ctx, v.Path())
+		if storage.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Path(), err)
+		}
+		data, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Path(), err)
+		}
+		add(v.Path(), data)
+	}
+	return out, nil
+}
+
+func sha256Sum(b []byte) []byte {
+	h := sha256.Sum256(b)
+	return h[:]
+}
+
+func sha512Sum(b []byte) []byte {
+	h := sha512.Sum512(b)
+	return h[:]
+}
+
 // https://wiki.debian.org/DebianRepository/Format#A.22Release.22_files
-func buildReleaseFiles(_ context.Context, distribution string, components, architectures []string, priv string, files ...*storage.File) (out []storage.Artifact, err error) {
+func buildReleaseFiles(ctx context.Context, distribution string, components, architectures []string, priv string, files ...*storage.File) (out []storage.Artifact, err error) {
 	// Delete the release files if there are no packages
 	if len(files) == 0 {
 		return nil, nil
@@ -193,14 +421,21 @@ func buildReleaseFiles(_ context.Context, distribution string, components, archi
 
 	w := io.MultiWriter(sw, releaseContent)
 
-	fmt.Fprintf(w, "Origin: %s\n", "Artifact Registry")
-	fmt.Fprintf(w, "Label: %s\n", "Artifact Registry")
-	fmt.Fprintf(w, "Suite: %s\n", distribution)
+	opts := Options(ctx)
+	now := time.Now().UTC()
+	fmt.Fprintf(w, "Origin: %s\n", opts.Origin())
+	fmt.Fprintf(w, "Label: %s\n", opts.Label())
+	fmt.Fprintf(w, "Suite: %s\n", opts.Suite(distribution))
 	fmt.Fprintf(w, "Codename: %s\n", distribution)
 	fmt.Fprintf(w, "Components: %s\n", strings.Join(components, " "))
 	fmt.Fprintf(w, "Architectures: %s\n", strings.Join(architectures, " "))
-	fmt.Fprintf(w, "Date: %s\n", time.Now().UTC().Format(time.RFC1123))
-	// fmt.Fprintln(w, "Acquire-By-Hash: yes")
+	// the architecture independent packages are merged into the architectures Packages indices
+	fmt.Fprintln(w, "No-Support-for-Architecture-all: Packages")
+	fmt.Fprintf(w, "Date: %s\n", now.Format(time.RFC1123))
+	if d := opts.ValidUntil(); d > 0 {
+		fmt.Fprintf(w, "Valid-Until: %s\n", now.Add(d).Format(time.RFC1123))
+	}
+	fmt.Fprintln(w, "Acquire-By-Hash: yes")
 
 	var md5, sha1, sha256, sha512 strings.Builder
 	fn := func(v *storage.File) error {
@@ -0,0 +1,293 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+// memStorage is a storage serving the files written by the previous index updates
+type memStorage struct {
+	storage.Storage
+	files map[string][]byte
+	opens map[string]int
+}
+
+func (s *memStorage) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	if s.opens != nil {
+		s.opens[name]++
+	}
+	b, ok := s.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// store writes the package files to the storage and returns the package as loaded from the repository index
+func (s *memStorage) store(t *testing.T, pkg *Package) *Package {
+	for _, v := range pkg.Files() {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		s.files[v.Path()] = b
+	}
+	b, err := json.Marshal(pkg)
+	require.NoError(t, err)
+	var out Package
+	require.NoError(t, json.Unmarshal(b, &out))
+	return &out
+}
+
+func newTestPackage(t *testing.T, control string, files ...string) *Package {
+	pkg, err := NewPackage(bytes.NewReader(testDeb(t, control, files...)), "stable", "main")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		pkg.Close()
+	})
+	return pkg
+}
+
+func readGzip(t *testing.T, f *storage.File) string {
+	gzr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	b, err := io.ReadAll(gzr)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestBuildContentsIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		pkgs   func(t *testing.T, s *memStorage) []*Package
+		want   string
+		wantOK bool
+	}{
+		{
+			name: "new packages",
+			pkgs: func(t *testing.T, s *memStorage) []*Package {
+				return []*Package{
+					newTestPackage(t, testControl("hello", "amd64"), "usr/bin/hello", "usr/bin/hi -> hello"),
+					newTestPackage(t, testControl("hello-doc", "all"), "usr/share/doc/hello/README"),
+				}
+			},
+			want:   "usr/bin/hello utils/hello\nusr/bin/hi utils/hello\nusr/share/doc/hello/README utils/hello-doc\n",
+			wantOK: true,
+		},
+		{
+			name: "stored packages",
+			pkgs: func(t *testing.T, s *memStorage) []*Package {
+				return []*Package{
+					s.store(t, newTestPackage(t, testControl("hello", "amd64"), "usr/bin/hello")),
+					newTestPackage(t, testControl("hello-doc", "all"), "usr/share/doc/hello/README"),
+				}
+			},
+			want:   "usr/bin/hello utils/hello\nusr/share/doc/hello/README utils/hello-doc\n",
+			wantOK: true,
+		},
+		{
+			name: "file shipped by multiple packages",
+			pkgs: func(t *testing.T, s *memStorage) []*Package {
+				return []*Package{
+					newTestPackage(t, testControl("bar", "amd64"), "usr/share/common"),
+					newTestPackage(t, testControl("foo", "amd64"), "usr/share/common"),
+				}
+			},
+			want:   "usr/share/common utils/bar,utils/foo\n",
+			wantOK: true,
+		},
+		{
+			name: "no files",
+			pkgs: func(t *testing.T, s *memStorage) []*Package {
+				return []*Package{newTestPackage(t, testControl("empty", "amd64"))}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &memStorage{files: make(map[string][]byte)}
+			ctx := storage.Context(context.Background(), s)
+			f, err := buildContentsIndex(ctx, "stable", "main", "amd64", make(map[string][]string), tt.pkgs(t, s)...)
+			require.NoError(t, err)
+			if !tt.wantOK {
+				assert.Nil(t, f)
+				return
+			}
+			require.NotNil(t, f)
+			assert.Equal(t, "dists/stable/main/Contents-amd64.gz", f.Path())
+			assert.Equal(t, tt.want, readGzip(t, f))
+		})
+	}
+}
+
+func TestBuildByHashFiles(t *testing.T) {
+	byHash := func(content string) []string {
+		return []string{
+			"dists/stable/main/binary-amd64/by-hash/SHA256/" + hex.EncodeToString(sha256Sum([]byte(content))),
+			"dists/stable/main/binary-amd64/by-hash/SHA512/" + hex.EncodeToString(sha512Sum([]byte(content))),
+		}
+	}
+	tests := []struct {
+		name     string
+		previous map[string][]byte
+		want     []string
+	}{
+		{
+			name: "first generation",
+			want: byHash("new"),
+		},
+		{
+			name:     "keeps the previous generation",
+			previous: map[string][]byte{"dists/stable/main/binary-amd64/Packages": []byte("old")},
+			want:     append(byHash("new"), byHash("old")...),
+		},
+		{
+			name:     "unchanged file",
+			previous: map[string][]byte{"dists/stable/main/binary-amd64/Packages": []byte("new")},
+			want:     byHash("new"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := storage.Context(context.Background(), &memStorage{files: tt.previous})
+			f := storage.NewFile("dists/stable/main/binary-amd64/Packages", []byte("new"))
+			out, err := buildByHashFiles(ctx, f)
+			require.NoError(t, err)
+			var got []string
+			for _, v := range out {
+				got = append(got, v.Path())
+			}
+			assert.Equal(t, tt.want, got)
+			// the index file must still be readable once hashed
+			b, err := io.ReadAll(f)
+			require.NoError(t, err)
+			assert.Equal(t, "new", string(b))
+		})
+	}
+}
+
+func TestIndexReadsContentsOnce(t *testing.T) {
+	priv, _, err := (&repo{}).GenerateKeypair()
+	require.NoError(t, err)
+	s := &memStorage{files: make(map[string][]byte), opens: make(map[string]int)}
+	as := []storage.Artifact{
+		s.store(t, newTestPackage(t, testControl("hello", "amd64"), "usr/bin/hello")),
+		s.store(t, newTestPackage(t, testControl("hello", "arm64"), "usr/bin/hello")),
+		s.store(t, newTestPackage(t, testControl("hello-doc", "all"), "usr/share/doc/hello/README")),
+	}
+	out, err := (&repo{}).Index(storage.Context(context.Background(), s), priv, as...)
+	require.NoError(t, err)
+	var n int
+	for _, v := range out {
+		if strings.Contains(v.Path(), "/Contents-") {
+			n++
+		}
+	}
+	assert.Equal(t, 2, n)
+	for _, v := range as {
+		p := v.(*Package).ContentsFile.Path()
+		assert.Equal(t, 1, s.opens[p], p)
+	}
+}
+
+func TestIndexArchitectureAll(t *testing.T) {
+	priv, _, err := (&repo{}).GenerateKeypair()
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		opts  []Option
+		pkgs  []string
+		want  map[string][]string
+		archs string
+	}{
+		{
+			name: "merged into each architecture",
+			pkgs: []string{"hello:amd64", "hello:arm64", "hello-doc:all"},
+			want: map[string][]string{
+				"amd64": {"hello", "hello-doc"},
+				"arm64": {"hello", "hello-doc"},
+			},
+			archs: "amd64 arm64",
+		},
+		{
+			name: "only architecture independent packages",
+			pkgs: []string{"hello-doc:all"},
+			want: map[string][]string{
+				"amd64": {"hello-doc"},
+				"s390x": {"hello-doc"},
+			},
+			archs: strings.Join(DefaultArchitectures, " "),
+		},
+		{
+			name: "only architecture independent packages with configured architectures",
+			opts: []Option{WithArchitectures("amd64", "riscv64")},
+			pkgs: []string{"hello-doc:all"},
+			want: map[string][]string{
+				"amd64":   {"hello-doc"},
+				"riscv64": {"hello-doc"},
+			},
+			archs: "amd64 riscv64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithOptions(context.Background(), tt.opts...)
+			var as []storage.Artifact
+			for _, v := range tt.pkgs {
+				name, arch, _ := strings.Cut(v, ":")
+				as = append(as, newTestPackage(t, testControl(name, arch)))
+			}
+			out, err := (&repo{}).Index(ctx, priv, as...)
+			require.NoError(t, err)
+			files := make(map[string]storage.Artifact)
+			for _, v := range out {
+				files[v.Path()] = v
+			}
+			for arch, names := range tt.want {
+				f, ok := files["dists/stable/main/binary-"+arch+"/Packages"]
+				require.True(t, ok, arch)
+				b, err := io.ReadAll(f)
+				require.NoError(t, err)
+				var got []string
+				for _, l := range strings.Split(string(b), "\n") {
+					if n, ok := strings.CutPrefix(l, "Package: "); ok {
+						got = append(got, n)
+					}
+				}
+				assert.ElementsMatch(t, names, got, arch)
+			}
+			_, ok := files["dists/stable/main/binary-all/Packages"]
+			assert.False(t, ok)
+			f, ok := files["dists/stable/Release"]
+			require.True(t, ok)
+			b, err := io.ReadAll(f)
+			require.NoError(t, err)
+			assert.Contains(t, string(b), "Architectures: "+tt.archs+"\n")
+		})
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+	"go.linka.cloud/artifact-registry/pkg/validation"
+)
+
+// SourceArchitecture is the architecture of the source packages
+const SourceArchitecture = "source"
+
+var (
+	ErrMissingSourceFiles = errors.New("source control file does not reference any file")
+	ErrMissingSourceFile  = errors.New("source file is missing")
+	ErrInvalidSourceFile  = errors.New("source file does not match the source control file")
+)
+
+var _ storage.Artifact = (*SourceFile)(nil)
+
+// SourceFile is a file referenced by a source package, e.g. the .orig.tar.gz or the .debian.tar.xz
+type SourceFile struct {
+	FileName string `json:"name"`
+	FilePath string `json:"filePath"`
+	FileSize int64  `json:"size"`
+
+	MD5    string `json:"md5"`
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+	SHA512 string `json:"sha512"`
+
+	reader io.ReadCloser
+}
+
+func (f *SourceFile) Read(b []byte) (n int, err error) {
+	if f.reader == nil {
+		return 0, io.EOF
+	}
+	return f.reader.Read(b)
+}
+
+func (f *SourceFile) Close() error {
+	if f.reader == nil {
+		return nil
+	}
+	return f.reader.Close()
+}
+
+func (f *SourceFile) Name() string {
+	return f.FileName
+}
+
+func (f *SourceFile) Path() string {
+	return f.FilePath
+}
+
+func (f *SourceFile) Arch() string {
+	return ""
+}
+
+func (f *SourceFile) Version() string {
+	return ""
+}
+
+func (f *SourceFile) Size() int64 {
+	return f.FileSize
+}
+
+func (f *SourceFile) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, f.SHA256)
+}
+
+// SourceFileOpener opens a file referenced by a source control file.
+type SourceFileOpener func(name string) (io.ReadCloser, error)
+
+// NewSourcePackage parses the Debian source control file and verifies the files it references
+// https://manpages.debian.org/bookworm/dpkg-dev/dsc.5.en.html
+func NewSourcePackage(r io.Reader, open SourceFileOpener, distribution, component string) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	// the source control file is usually signed by the maintainer
+	if block, _ := clearsign.Decode(b); block != nil {
+		b = block.Plaintext
+	}
+	pkg, err := ParseSourceControlFile(bytes.NewReader(b))
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	pkg.reader = reader
+	pkg.Component = component
+	pkg.Distribution = distribution
+	dir := filepath.Join("pool", pkg.Distribution, pkg.Component)
+	pkg.FilePath = filepath.Join(dir, fmt.Sprintf("%s_%s.dsc", pkg.PkgName, trimEpoch(pkg.PkgVersion)))
+	pkg.PkgSize = reader.Size()
+	md5, sha1, sha256, sha512 := reader.Sums()
+	pkg.MD5 = hex.EncodeToString(md5)
+	pkg.SHA1 = hex.EncodeToString(sha1)
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	pkg.SHA512 = hex.EncodeToString(sha512)
+	for _, v := range pkg.SourceFiles {
+		if err := v.load(open); err != nil {
+			pkg.Close()
+			return nil, fmt.Errorf("%s: %w", v.FileName, err)
+		}
+		v.FilePath = filepath.Join(dir, v.FileName)
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		pkg.Close()
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// validFileName reports whether the name is a plain file name, without any directory element
+func validFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name
+}
+
+// load reads the file content and verifies it against the checksums declared in the source control file
+func (f *SourceFile) load(open SourceFileOpener) error {
+	r, err := open(f.FileName)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	buf, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return err
+	}
+	md5, sha1, sha256, sha512 := buf.Sums()
+	if buf.Size() != f.FileSize || hex.EncodeToString(md5) != f.MD5 || (f.SHA256 != "" && hex.EncodeToString(sha256) != f.SHA256) {
+		buf.Close()
+		return ErrInvalidSourceFile
+	}
+	f.SHA1 = hex.EncodeToString(sha1)
+	f.SHA256 = hex.EncodeToString(sha256)
+	f.SHA512 = hex.EncodeToString(sha512)
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		buf.Close()
+		return err
+	}
+	f.reader = buf
+	return nil
+}
+
+// ParseSourceControlFile parses a Debian source control file to retrieve the metadata
+// and the files it references.
+// The Control of the returned package is the source paragraph as written in the Sources index,
+// without the files checksums.
+func ParseSourceControlFile(r io.Reader) (*Package, error) {
+	p := &Package{
+		Architecture: SourceArchitecture,
+		Metadata:     &Metadata{},
+	}
+
+	key := ""
+	var depends strings.Builder
+	var control strings.Builder
+	files := make(map[string]*SourceFile)
+	var sums []string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			switch key {
+			case "Build-Depends":
+				depends.WriteString(trimmed)
+			case "Files", "Checksums-Sha256":
+				parts := strings.Fields(trimmed)
+				if len(parts) != 3 {
+					return nil, fmt.Errorf("invalid %s entry: %q", key, trimmed)
+				}
+				size, err := strconv.ParseInt(parts[1], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s entry: %q", key, trimmed)
+				}
+				if !validFileName(parts[2]) {
+					return nil, fmt.Errorf("%w: %q", ErrInvalidFileName, parts[2])
+				}
+				f, ok := files[parts[2]]
+				if !ok {
+					f = &SourceFile{FileName: parts[2], FileSize: size}
+					files[parts[2]] = f
+					sums = append(sums, parts[2])
+				}
+				if f.FileSize != size {
+					return nil, fmt.Errorf("%s: %w", parts[2], ErrInvalidSourceFile)
+				}
+				if key == "Files" {
+					f.MD5 = parts[0]
+				} else {
+					f.SHA256 = parts[0]
+				}
+				continue
+			case "Checksums-Sha1", "Checksums-Sha512":
+				continue
+			}
+			control.WriteString(line + "\n")
+			continue
+		}
+
+		parts := strings.SplitN(trimmed, ":", 2)
+		if len(parts) < 2 {
+			continue
+		}
+
+		key = parts[0]
+		value := strings.TrimSpace(parts[1])
+		switch key {
+		case "Source":
+			p.PkgName = value
+			continue
+		case "Version":
+			p.PkgVersion = value
+		case "Maintainer":
+			a, err := mail.ParseAddress(value)
+			if err != nil || a.Name == "" {
+				p.Metadata.Maintainer = value
+			} else {
+				p.Metadata.Maintainer = a.Name
+			}
+		case "Build-Depends":
+			depends.WriteString(value)
+		case "Homepage":
+			if validation.IsValidURL(value) {
+				p.Metadata.ProjectURL = value
+			}
+		case "Files", "Checksums-Sha1", "Checksums-Sha256", "Checksums-Sha512":
+			continue
+		}
+		control.WriteString(line + "\n")
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if !namePattern.MatchString(p.PkgName) {
+		return nil, ErrInvalidName
+	}
+	if !versionPattern.MatchString(p.PkgVersion) {
+		return nil, ErrInvalidVersion
+	}
+	if len(sums) == 0 {
+		return nil, ErrMissingSourceFiles
+	}
+	for _, v := range sums {
+		if files[v].MD5 == "" {
+			return nil, fmt.Errorf("%s: %w", v, ErrInvalidSourceFile)
+		}
+		p.SourceFiles = append(p.SourceFiles, files[v])
+	}
+
+	dependencies := strings.Split(depends.String(), ",")
+	for i := range dependencies {
+		dependencies[i] = strings.TrimSpace(dependencies[i])
+	}
+	p.Metadata.Dependencies = dependencies
+
+	// the Sources index uses the Package field for the source name
+	p.Control = "Package: " + p.PkgName + "\n" + strings.TrimSpace(control.String())
+
+	return p, nil
+}
+
+// trimEpoch removes the epoch from the version as it is not part of the file names
+func trimEpoch(version string) string {
+	if i := strings.Index(version, ":"); i >= 0 {
+		return version[i+1:]
+	}
+	return version
+}
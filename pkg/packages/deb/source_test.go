@@ -0,0 +1,207 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deb
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSums(content string) (string, string) {
+	m, s := md5.Sum([]byte(content)), sha256.Sum256([]byte(content))
+	return hex.EncodeToString(m[:]), hex.EncodeToString(s[:])
+}
+
+// testDsc returns a source control file referencing the files
+func testDsc(source, version string, files map[string]string) string {
+	var md5s, sha256s strings.Builder
+	for _, name := range []string{"hello_1.0.orig.tar.gz", "hello_1.0-1.debian.tar.xz"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		m, s := testSums(content)
+		fmt.Fprintf(&md5s, " %s %d %s\n", m, len(content), name)
+		fmt.Fprintf(&sha256s, " %s %d %s\n", s, len(content), name)
+	}
+	return "Format: 3.0 (quilt)\n" +
+		"Source: " + source + "\n" +
+		"Binary: hello, hello-doc\n" +
+		"Architecture: any all\n" +
+		"Version: " + version + "\n" +
+		"Maintainer: John Doe <john@example.org>\n" +
+		"Homepage: https://example.org/hello\n" +
+		"Build-Depends: debhelper-compat (= 13),\n" +
+		" libc6-dev\n" +
+		"Checksums-Sha256:\n" + sha256s.String() +
+		"Files:\n" + md5s.String()
+}
+
+var testSourceFiles = map[string]string{
+	"hello_1.0.orig.tar.gz":     "orig",
+	"hello_1.0-1.debian.tar.xz": "debian",
+}
+
+func TestParseSourceControlFile(t *testing.T) {
+	dsc := testDsc("hello", "1.0-1", testSourceFiles)
+	tests := []struct {
+		name    string
+		dsc     string
+		wantErr error
+		fn      func(t *testing.T, p *Package)
+	}{
+		{
+			name: "valid",
+			dsc:  dsc,
+			fn: func(t *testing.T, p *Package) {
+				assert.Equal(t, "hello", p.PkgName)
+				assert.Equal(t, "1.0-1", p.PkgVersion)
+				assert.Equal(t, SourceArchitecture, p.Architecture)
+				assert.Equal(t, "John Doe", p.Metadata.Maintainer)
+				assert.Equal(t, "https://example.org/hello", p.Metadata.ProjectURL)
+				assert.Equal(t, []string{"debhelper-compat (= 13)", "libc6-dev"}, p.Metadata.Dependencies)
+				assert.True(t, strings.HasPrefix(p.Control, "Package: hello\nFormat: 3.0 (quilt)\n"))
+				assert.NotContains(t, p.Control, "Source:")
+				assert.NotContains(t, p.Control, "Files:")
+				assert.NotContains(t, p.Control, "Checksums-Sha256:")
+				require.Len(t, p.SourceFiles, 2)
+				for _, v := range p.SourceFiles {
+					m, s := testSums(testSourceFiles[v.FileName])
+					assert.Equal(t, int64(len(testSourceFiles[v.FileName])), v.FileSize)
+					assert.Equal(t, m, v.MD5)
+					assert.Equal(t, s, v.SHA256)
+				}
+			},
+		},
+		{
+			name:    "invalid name",
+			dsc:     testDsc("Hello", "1.0-1", testSourceFiles),
+			wantErr: ErrInvalidName,
+		},
+		{
+			name:    "invalid version",
+			dsc:     testDsc("hello", "1.0_1", testSourceFiles),
+			wantErr: ErrInvalidVersion,
+		},
+		{
+			name:    "no files",
+			dsc:     testDsc("hello", "1.0-1", nil),
+			wantErr: ErrMissingSourceFiles,
+		},
+		{
+			name:    "size mismatch",
+			dsc:     strings.Replace(dsc, " 4 hello_1.0.orig.tar.gz", " 5 hello_1.0.orig.tar.gz", 1),
+			wantErr: ErrInvalidSourceFile,
+		},
+		{
+			name:    "traversing file name",
+			dsc:     strings.ReplaceAll(dsc, "hello_1.0.orig.tar.gz", "../../../other/x.deb"),
+			wantErr: ErrInvalidFileName,
+		},
+		{
+			name:    "parent file name",
+			dsc:     strings.ReplaceAll(dsc, "hello_1.0.orig.tar.gz", ".."),
+			wantErr: ErrInvalidFileName,
+		},
+		{
+			name:    "missing md5",
+			dsc:     dsc[:strings.Index(dsc, "Files:")],
+			wantErr: ErrInvalidSourceFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseSourceControlFile(strings.NewReader(tt.dsc))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.fn(t, p)
+		})
+	}
+}
+
+func TestNewSourcePackage(t *testing.T) {
+	open := func(files map[string]string) SourceFileOpener {
+		return func(name string) (io.ReadCloser, error) {
+			content, ok := files[name]
+			if !ok {
+				return nil, ErrMissingSourceFile
+			}
+			return io.NopCloser(strings.NewReader(content)), nil
+		}
+	}
+	tests := []struct {
+		name    string
+		version string
+		files   map[string]string
+		wantErr error
+	}{
+		{
+			name:    "valid",
+			version: "1.0-1",
+			files:   testSourceFiles,
+		},
+		{
+			name:    "epoch",
+			version: "1:1.0-1",
+			files:   testSourceFiles,
+		},
+		{
+			name:    "missing file",
+			version: "1.0-1",
+			files:   map[string]string{"hello_1.0.orig.tar.gz": "orig"},
+			wantErr: ErrMissingSourceFile,
+		},
+		{
+			name:    "checksum mismatch",
+			version: "1.0-1",
+			files:   map[string]string{"hello_1.0.orig.tar.gz": "orif", "hello_1.0-1.debian.tar.xz": "debian"},
+			wantErr: ErrInvalidSourceFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewSourcePackage(strings.NewReader(testDsc("hello", tt.version, testSourceFiles)), open(tt.files), "stable", "main")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer p.Close()
+			assert.Equal(t, "pool/stable/main/hello_1.0-1.dsc", p.Path())
+			require.Len(t, p.Files(), 2)
+			for _, v := range p.SourceFiles {
+				assert.Equal(t, "pool/stable/main/"+v.FileName, v.Path())
+				b, err := io.ReadAll(v)
+				require.NoError(t, err)
+				assert.Equal(t, testSourceFiles[v.FileName], string(b))
+			}
+			b, err := io.ReadAll(p)
+			require.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(b, []byte("Format: 3.0 (quilt)\n")))
+		})
+	}
+}
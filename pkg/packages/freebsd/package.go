@@ -0,0 +1,199 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package freebsd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	"github.com/ulikunitz/xz"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	PackagesDir = "All"
+	PackageExt  = ".pkg"
+
+	compactManifest = "+COMPACT_MANIFEST"
+)
+
+var (
+	ErrInvalidPackage      = errors.New("pkg package is invalid")
+	ErrMissingManifestFile = errors.New("+COMPACT_MANIFEST file is missing")
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName    string `json:"name"`
+	PkgVersion string `json:"version"`
+	Origin     string `json:"origin"`
+	ABI        string `json:"abi"`
+	Comment    string `json:"comment"`
+	Maintainer string `json:"maintainer"`
+	WWW        string `json:"www"`
+
+	// Manifest is the raw +COMPACT_MANIFEST content, used as is in the packagesite
+	Manifest json.RawMessage `json:"manifest"`
+
+	Published time.Time `json:"published"`
+	PkgSize   int64     `json:"size"`
+	FilePath  string    `json:"filePath"`
+	SHA256    string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return p.ABI
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+func PackagePath(name, version string) string {
+	return path.Join(PackagesDir, fmt.Sprintf("%s-%s%s", name, version, PackageExt))
+}
+
+func NewPackage(r io.Reader) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := parsePackage(reader, reader.Size())
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	_, _, sha256, _ := reader.Sums()
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	pkg.PkgSize = reader.Size()
+	pkg.FilePath = PackagePath(pkg.PkgName, pkg.PkgVersion)
+	pkg.Published = time.Now().UTC()
+	pkg.reader = reader
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+func parsePackage(r io.ReaderAt, size int64) (*Package, error) {
+	magic := make([]byte, 6)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	var tr *tar.Reader
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		defer zr.Close()
+		tr = tar.NewReader(zr)
+	case bytes.Equal(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		xr, err := xz.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		tr = tar.NewReader(xr)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gzr, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		defer gzr.Close()
+		tr = tar.NewReader(gzr)
+	default:
+		return nil, fmt.Errorf("%w: unsupported compression", ErrInvalidPackage)
+	}
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrMissingManifestFile
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		if strings.TrimPrefix(hd.Name, "/") == compactManifest {
+			return parseManifest(tr)
+		}
+	}
+}
+
+func parseManifest(r io.Reader) (*Package, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	var pkg Package
+	if err := json.Unmarshal(b, &pkg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	if pkg.PkgName == "" || pkg.PkgVersion == "" || pkg.Origin == "" || pkg.ABI == "" {
+		return nil, fmt.Errorf("%w: name, version, origin and abi are required", ErrInvalidPackage)
+	}
+	if strings.ContainsAny(pkg.PkgName+pkg.PkgVersion, "/\\") {
+		return nil, fmt.Errorf("%w: invalid name or version", ErrInvalidPackage)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	pkg.Manifest = compact.Bytes()
+	return &pkg, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package freebsd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+const testManifest = `{
+  "name": "example",
+  "origin": "misc/example",
+  "version": "1.0.0_1",
+  "comment": "An example package",
+  "maintainer": "john@example.org",
+  "www": "https://example.org",
+  "abi": "FreeBSD:14:amd64",
+  "arch": "freebsd:14:x86:64",
+  "prefix": "/usr/local",
+  "flatsize": 1024,
+  "deps": {"libfoo": {"origin": "devel/libfoo", "version": "2.0"}}
+}`
+
+func testPackage(t *testing.T, compress func(io.Writer) io.WriteCloser, files map[string]string) []byte {
+	var b bytes.Buffer
+	w := compress(&b)
+	tw := tar.NewWriter(w)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func TestNewPackage(t *testing.T) {
+	files := map[string]string{
+		"+COMPACT_MANIFEST":            testManifest,
+		"+MANIFEST":                    testManifest,
+		"/usr/local/bin/example":       "#!/bin/sh\n",
+		"/usr/local/share/example/doc": "",
+	}
+	tests := []struct {
+		name     string
+		compress func(io.Writer) io.WriteCloser
+	}{
+		{
+			name: "zstd",
+			compress: func(w io.Writer) io.WriteCloser {
+				zw, err := zstd.NewWriter(w)
+				require.NoError(t, err)
+				return zw
+			},
+		},
+		{
+			name: "xz",
+			compress: func(w io.Writer) io.WriteCloser {
+				xw, err := xz.NewWriter(w)
+				require.NoError(t, err)
+				return xw
+			},
+		},
+		{
+			name: "gzip",
+			compress: func(w io.Writer) io.WriteCloser {
+				return gzip.NewWriter(w)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testPackage(t, tt.compress, files)
+			pkg, err := NewPackage(bytes.NewReader(b))
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, "example", pkg.Name())
+			assert.Equal(t, "1.0.0_1", pkg.Version())
+			assert.Equal(t, "misc/example", pkg.Origin)
+			assert.Equal(t, "FreeBSD:14:amd64", pkg.ABI)
+			assert.Equal(t, "An example package", pkg.Comment)
+			assert.Equal(t, "All/example-1.0.0_1.pkg", pkg.Path())
+			assert.Equal(t, int64(len(b)), pkg.Size())
+			assert.Len(t, pkg.SHA256, 64)
+			// the manifest is kept as is, compacted
+			assert.JSONEq(t, testManifest, string(pkg.Manifest))
+			assert.NotContains(t, string(pkg.Manifest), "\n")
+		})
+	}
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	gz := func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	}
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "missing manifest",
+			data:    testPackage(t, gz, map[string]string{"+MANIFEST": testManifest}),
+			wantErr: ErrMissingManifestFile,
+		},
+		{
+			name:    "missing abi",
+			data:    testPackage(t, gz, map[string]string{"+COMPACT_MANIFEST": `{"name":"example","origin":"misc/example","version":"1.0.0"}`}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "invalid version",
+			data:    testPackage(t, gz, map[string]string{"+COMPACT_MANIFEST": `{"name":"example","origin":"misc/example","version":"../1.0.0","abi":"FreeBSD:14:amd64"}`}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "invalid manifest",
+			data:    testPackage(t, gz, map[string]string{"+COMPACT_MANIFEST": "name: example"}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "unsupported compression",
+			data:    []byte("not a package"),
+			wantErr: ErrInvalidPackage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(tt.data))
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package freebsd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/crypt/rsa"
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	Name = "freebsd"
+
+	FingerprintFile = "fingerprint"
+)
+
+var _ packages.Provider = (*provider)(nil)
+
+var repoTemplate = template.Must(template.New("repo").Parse(`{{.Name}}: {
+  url: "{{.URL}}",
+  signature_type: "fingerprints",
+  fingerprints: "/usr/local/etc/pkg/fingerprints/{{.Name}}",
+  enabled: yes
+}
+`))
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+func repoName(repo string, r *http.Request) string {
+	if repo != "" {
+		return strings.NewReplacer("/", "-").Replace(repo)
+	}
+	return strings.NewReplacer("/", "-", ".", "-").Replace(strings.TrimPrefix(strings.Split(r.Host, ":")[0], Name+"."))
+}
+
+// config serves the pkg repository configuration, to be written in /usr/local/etc/pkg/repos/
+func (p *provider) config(repo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if _, err := storage.FromContext(ctx).Stat(ctx, RepositoryPublicKey); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		host := strings.TrimSuffix(r.Host, "/")
+		if user, pass, ok := r.BasicAuth(); ok {
+			host = fmt.Sprintf("%s:%s@%s", user, pass, host)
+		}
+		url := strings.TrimSuffix(fmt.Sprintf("%s://%s/%s", packages.Scheme(r), host, strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, ".conf"), "/")), "/")
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := repoTemplate.Execute(w, map[string]string{"Name": repoName(repo, r), "URL": url}); err != nil {
+			logger.C(r.Context()).WithError(err).Error("failed to execute template")
+		}
+	}
+}
+
+// fingerprint serves the trusted fingerprint file, to be written in /usr/local/etc/pkg/fingerprints/{name}/trusted/
+func (p *provider) fingerprint(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pub, _, err := rsa.PublicKeyAndFingerprintFromPrivateKey(storage.FromContext(r.Context()).Key())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, Fingerprint(pub))
+	}
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    ".conf",
+			Handler: p.config,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + FingerprintFile,
+			Handler: p.fingerprint,
+		},
+		{
+			Method: http.MethodPut,
+			Path:   "/push",
+			Handler: packages.Push(func(r *http.Request, reader io.Reader, key string) (storage.Artifact, error) {
+				return NewPackage(reader)
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + PackagesDir + "/{filename}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				return path.Join(PackagesDir, mux.Vars(r)["filename"])
+			}),
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/" + PackagesDir + "/{filename}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				return path.Join(PackagesDir, mux.Vars(r)["filename"])
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/{filename}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				return mux.Vars(r)["filename"]
+			}),
+		},
+	}
+}
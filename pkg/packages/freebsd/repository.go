@@ -0,0 +1,220 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package freebsd
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	rsa2 "go.linka.cloud/artifact-registry/pkg/crypt/rsa"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	MetaFile        = "meta.conf"
+	PackageSiteFile = "packagesite.yaml"
+	PackageSiteName = "packagesite"
+
+	SignatureEntry = "signature"
+	PublicKeyEntry = "public.key"
+)
+
+// metaConf describes the repository layout, the packagesite archive is served
+// as packagesite.pkg (zstd) for recent pkg releases and packagesite.txz for older ones
+var metaConf = fmt.Sprintf(`version = 2;
+packing_format = "tzst";
+manifests = %q;
+manifests_archive = %q;
+`, PackageSiteFile, PackageSiteName)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "freebsd"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return rsa2.GenerateKeyPair()
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+func (r *repo) Index(_ context.Context, priv string, as ...storage.Artifact) ([]storage.Artifact, error) {
+	pkgs := storage.MustAs[*Package](as)
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	// only the latest published version of each package is listed
+	latest := make(map[string]*Package)
+	for _, v := range pkgs {
+		if p, ok := latest[v.PkgName]; !ok || v.Published.After(p.Published) {
+			latest[v.PkgName] = v
+		}
+	}
+	pkgs = make([]*Package, 0, len(latest))
+	for _, v := range latest {
+		pkgs = append(pkgs, v)
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].PkgName < pkgs[j].PkgName
+	})
+
+	var site bytes.Buffer
+	for _, v := range pkgs {
+		b, err := siteEntry(v)
+		if err != nil {
+			return nil, err
+		}
+		site.Write(b)
+		site.WriteByte('\n')
+	}
+
+	sig, pub, err := sign(priv, site.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	entries := []entry{
+		{name: PackageSiteFile, content: site.Bytes()},
+		{name: SignatureEntry, content: sig},
+		{name: PublicKeyEntry, content: pub},
+	}
+	var zst bytes.Buffer
+	zw, err := zstd.NewWriter(&zst)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeArchive(zw, entries...); err != nil {
+		return nil, err
+	}
+	var txz bytes.Buffer
+	xw, err := xz.NewWriter(&txz)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeArchive(xw, entries...); err != nil {
+		return nil, err
+	}
+	return []storage.Artifact{
+		storage.NewFile(MetaFile, []byte(metaConf)),
+		storage.NewFile(PackageSiteName+PackageExt, zst.Bytes()),
+		storage.NewFile(PackageSiteName+".txz", txz.Bytes()),
+	}, nil
+}
+
+// siteEntry returns the package compact manifest completed with the repository fields
+func siteEntry(p *Package) ([]byte, error) {
+	m := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(p.Manifest, &m); err != nil {
+		return nil, err
+	}
+	for k, v := range map[string]any{
+		"path":     p.FilePath,
+		"repopath": p.FilePath,
+		"sum":      p.SHA256,
+		"pkgsize":  p.PkgSize,
+	} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = b
+	}
+	return json.Marshal(m)
+}
+
+// sign signs the packagesite the same way pkg's signing_command does:
+// the hex encoded sha256 sum of the file is signed using RSA PKCS#1 v1.5 with SHA256.
+// It returns the signature and the PEM encoded public key.
+func sign(priv string, content []byte) ([]byte, []byte, error) {
+	key, err := rsa2.ParsePrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, _, err := rsa2.PublicKeyAndFingerprintFromPrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(content)
+	h := sha256.Sum256([]byte(hex.EncodeToString(sum[:])))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig, pub, nil
+}
+
+// Fingerprint returns the pkg fingerprints file content for the given PEM encoded public key
+func Fingerprint(pub []byte) string {
+	sum := sha256.Sum256(pub)
+	return fmt.Sprintf("function: \"sha256\"\nfingerprint: \"%s\"\n", hex.EncodeToString(sum[:]))
+}
+
+type entry struct {
+	name    string
+	content []byte
+}
+
+func writeArchive(w io.WriteCloser, entries ...entry) error {
+	tw := tar.NewWriter(w)
+	now := time.Now()
+	for _, v := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: v.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(v.content)), ModTime: now}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(v.content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package freebsd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func readArchive(t *testing.T, r io.Reader) map[string][]byte {
+	tr := tar.NewReader(r)
+	out := make(map[string][]byte)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return out
+		}
+		require.NoError(t, err)
+		b, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		out[hd.Name] = b
+	}
+}
+
+func TestIndex(t *testing.T) {
+	priv, _, err := (&repo{}).GenerateKeypair()
+	require.NoError(t, err)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkg := func(name, version string, published time.Time) storage.Artifact {
+		return &Package{
+			PkgName:    name,
+			PkgVersion: version,
+			Manifest:   json.RawMessage(`{"name":"` + name + `","version":"` + version + `","abi":"FreeBSD:14:amd64"}`),
+			Published:  published,
+			PkgSize:    42,
+			FilePath:   PackagePath(name, version),
+			SHA256:     "sum-" + version,
+		}
+	}
+	out, err := (&repo{}).Index(context.Background(), priv,
+		pkg("example", "2.0.0", now.Add(time.Hour)),
+		pkg("example", "1.0.0", now),
+		pkg("abc", "0.1.0", now),
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	require.Len(t, files, 3)
+	assert.Contains(t, string(files[MetaFile]), `manifests_archive = "packagesite";`)
+
+	zr, err := zstd.NewReader(bytes.NewReader(files["packagesite.pkg"]))
+	require.NoError(t, err)
+	defer zr.Close()
+	site := readArchive(t, zr)
+	xr, err := xz.NewReader(bytes.NewReader(files["packagesite.txz"]))
+	require.NoError(t, err)
+	assert.Equal(t, site, readArchive(t, xr))
+
+	// one compact manifest per line, only the latest published version of each package
+	var entries []map[string]any
+	s := bufio.NewScanner(bytes.NewReader(site[PackageSiteFile]))
+	for s.Scan() {
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(s.Bytes(), &m))
+		entries = append(entries, m)
+	}
+	require.NoError(t, s.Err())
+	assert.Equal(t, []map[string]any{
+		{"name": "abc", "version": "0.1.0", "abi": "FreeBSD:14:amd64", "path": "All/abc-0.1.0.pkg", "repopath": "All/abc-0.1.0.pkg", "sum": "sum-0.1.0", "pkgsize": float64(42)},
+		{"name": "example", "version": "2.0.0", "abi": "FreeBSD:14:amd64", "path": "All/example-2.0.0.pkg", "repopath": "All/example-2.0.0.pkg", "sum": "sum-2.0.0", "pkgsize": float64(42)},
+	}, entries)
+
+	// the signature is the RSA signature of the hex encoded sha256 sum of the packagesite
+	block, _ := pem.Decode(site[PublicKeyEntry])
+	require.NotNil(t, block)
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+	sum := sha256.Sum256(site[PackageSiteFile])
+	h := sha256.Sum256([]byte(hex.EncodeToString(sum[:])))
+	assert.NoError(t, rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, h[:], site[SignatureEntry]))
+}
+
+func TestFingerprint(t *testing.T) {
+	sum := sha256.Sum256([]byte("key"))
+	assert.Equal(t, "function: \"sha256\"\nfingerprint: \""+hex.EncodeToString(sum[:])+"\"\n", Fingerprint([]byte("key")))
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+var ErrInvalidPath = errors.New("file path is invalid")
+
+// reserved are the repository files which cannot be overwritten by uploads
+var reserved = map[string]bool{
+	SumsFile:             true,
+	SumsFile + SigExt:    true,
+	RepositoryPublicKey:  true,
+	RepositoryPrivateKey: true,
+}
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName    string    `json:"name"`
+	PkgVersion string    `json:"version"`
+	Published  time.Time `json:"published"`
+	PkgSize    int64     `json:"size"`
+	FilePath   string    `json:"filePath"`
+	SHA256     string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// CleanPath returns the cleaned file path, it fails if the path escapes the repository
+// or targets one of the repository files
+func CleanPath(name string) (string, error) {
+	p := path.Clean("/" + name)[1:]
+	if p == "" || p != strings.Trim(name, "/") || reserved[p] {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, name)
+	}
+	return p, nil
+}
+
+// NewPackage creates a file stored at the given path, the name defaults to the file base name
+func NewPackage(r io.Reader, filePath, name, version string) (*Package, error) {
+	p, err := CleanPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = path.Base(p)
+	}
+	_, _, sha256, _ := reader.Sums()
+	pkg := &Package{
+		PkgName:    name,
+		PkgVersion: version,
+		Published:  time.Now().UTC(),
+		PkgSize:    reader.Size(),
+		FilePath:   p,
+		SHA256:     hex.EncodeToString(sha256),
+		reader:     reader,
+	}
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
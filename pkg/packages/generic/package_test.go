@@ -0,0 +1,83 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "file.txt", want: "file.txt"},
+		{name: "/dir/file.txt", want: "dir/file.txt"},
+		{name: "dir/sub/", want: "dir/sub"},
+		{name: "dir/../file.txt", wantErr: true},
+		{name: "../file.txt", wantErr: true},
+		{name: "dir//file.txt", wantErr: true},
+		{name: "./file.txt", wantErr: true},
+		{name: "/", wantErr: true},
+		{name: "", wantErr: true},
+		{name: SumsFile, wantErr: true},
+		{name: "/" + SumsFile + SigExt, wantErr: true},
+		{name: RepositoryPrivateKey, wantErr: true},
+		{name: "dir/" + SumsFile, want: "dir/" + SumsFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanPath(tt.name)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPath)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewPackage(t *testing.T) {
+	const content = "hello"
+	sum := sha256.Sum256([]byte(content))
+
+	pkg, err := NewPackage(strings.NewReader(content), "/releases/1.0.0/tool.tar.gz", "", "1.0.0")
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, "tool.tar.gz", pkg.Name())
+	assert.Equal(t, "1.0.0", pkg.Version())
+	assert.Equal(t, "releases/1.0.0/tool.tar.gz", pkg.Path())
+	assert.Equal(t, int64(len(content)), pkg.Size())
+	assert.Equal(t, hex.EncodeToString(sum[:]), pkg.SHA256)
+	b, err := io.ReadAll(pkg)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(b))
+
+	pkg, err = NewPackage(strings.NewReader(content), "tool.tar.gz", "tool", "")
+	require.NoError(t, err)
+	assert.Equal(t, "tool", pkg.Name())
+
+	_, err = NewPackage(strings.NewReader(content), "../tool.tar.gz", "", "")
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
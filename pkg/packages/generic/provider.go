@@ -0,0 +1,95 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "generic"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/" + RepositoryPublicKey,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return RepositoryPublicKey
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + SumsFile,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return SumsFile
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + SumsFile + SigExt,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return SumsFile + SigExt
+			}),
+		},
+		{
+			// the optional name and version labels are passed as query parameters, e.g. ?name=firmware&version=1.0.0
+			Method: http.MethodPut,
+			Path:   "/{path:.+}",
+			Deep:   true,
+			Handler: packages.Push(func(r *http.Request, reader io.Reader, key string) (storage.Artifact, error) {
+				return NewPackage(reader, mux.Vars(r)["path"], r.URL.Query().Get("name"), r.URL.Query().Get("version"))
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/{path:.+}",
+			Deep:   true,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return mux.Vars(r)["path"]
+			}),
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/{path:.+}",
+			Deep:   true,
+			Handler: packages.Delete(func(r *http.Request) string {
+				return mux.Vars(r)["path"]
+			}),
+		},
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	SumsFile = "SHA256SUMS"
+	SigExt   = ".asc"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "generic"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Generic Registry", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Index generates the SHA256SUMS manifest of all the files and its armored detached signature
+func (r *repo) Index(_ context.Context, priv string, as ...storage.Artifact) ([]storage.Artifact, error) {
+	pkgs := storage.MustAs[*Package](as)
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].FilePath < pkgs[j].FilePath
+	})
+	var sums bytes.Buffer
+	for _, v := range pkgs {
+		fmt.Fprintf(&sums, "%s  %s\n", v.SHA256, v.FilePath)
+	}
+	var sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sig, priv, bytes.NewReader(sums.Bytes())); err != nil {
+		return nil, err
+	}
+	return []storage.Artifact{
+		storage.NewFile(SumsFile, sums.Bytes()),
+		storage.NewFile(SumsFile+SigExt, sig.Bytes()),
+	}, nil
+}
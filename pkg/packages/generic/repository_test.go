@@ -0,0 +1,55 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	priv, pub, err := (&repo{}).GenerateKeypair()
+	require.NoError(t, err)
+	out, err := (&repo{}).Index(context.Background(), priv,
+		&Package{FilePath: "releases/1.0.0/tool.tar.gz", SHA256: "sum-tool"},
+		&Package{FilePath: "README.md", SHA256: "sum-readme"},
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	require.Len(t, files, 2)
+	sums := files[SumsFile]
+	assert.Equal(t, "sum-readme  README.md\nsum-tool  releases/1.0.0/tool.tar.gz\n", string(sums))
+
+	keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(pub))
+	require.NoError(t, err)
+	_, err = openpgp.CheckArmoredDetachedSignature(keys, bytes.NewReader(sums), bytes.NewReader(files[SumsFile+SigExt]), nil)
+	assert.NoError(t, err)
+
+	out, err = (&repo{}).Index(context.Background(), priv)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
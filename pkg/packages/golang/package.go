@@ -0,0 +1,200 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"archive/zip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	modzip "golang.org/x/mod/zip"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+var (
+	ErrInvalidLayout = errors.New("module zip layout is invalid")
+	ErrInvalidGoMod  = errors.New("module go.mod is invalid")
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	Module     string    `json:"module"`
+	PkgVersion string    `json:"version"`
+	GoMod      string    `json:"goMod"`
+	Time       time.Time `json:"time"`
+
+	PkgSize  int64  `json:"size"`
+	FilePath string `json:"filePath"`
+
+	SHA256 string `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.Module
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// Dir returns the escaped module version directory, e.g. "github.com/!example/mod/@v"
+func (p *Package) Dir() string {
+	return Dir(p.Module)
+}
+
+// File returns the path of the module version file with the given extension, e.g. ".mod"
+func (p *Package) File(ext string) string {
+	v, _ := module.EscapeVersion(p.PkgVersion)
+	return path.Join(p.Dir(), v+ext)
+}
+
+// Dir returns the escaped version directory of the module
+// https://go.dev/ref/mod#goproxy-protocol
+func Dir(mod string) string {
+	p, _ := module.EscapePath(mod)
+	return path.Join(p, "@v")
+}
+
+// NewPackage parses and validates a module zip file as created by the go command,
+// the module path and version are read from the files prefix: {module}@{version}/
+// https://go.dev/ref/mod#zip-files
+func NewPackage(r io.Reader) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(reader, reader.Size())
+	if err != nil {
+		return nil, err
+	}
+	if len(zr.File) == 0 {
+		return nil, fmt.Errorf("%w: empty archive", ErrInvalidLayout)
+	}
+	// module paths contain slashes but no "@"
+	mod, rest, ok := strings.Cut(zr.File[0].Name, "@")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: missing module version", ErrInvalidLayout, zr.File[0].Name)
+	}
+	version, _, ok := strings.Cut(rest, "/")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: missing module version directory", ErrInvalidLayout, zr.File[0].Name)
+	}
+	prefix := mod + "@" + version
+	m := module.Version{Path: mod, Version: version}
+	if err := module.Check(m.Path, m.Version); err != nil {
+		return nil, err
+	}
+	if err := checkZip(m, reader, reader.Size()); err != nil {
+		return nil, err
+	}
+	gomod := fmt.Sprintf("module %s\n", modfile.AutoQuote(mod))
+	for _, v := range zr.File {
+		if v.Name != prefix+"/go.mod" {
+			continue
+		}
+		rc, err := v.Open()
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		if p := modfile.ModulePath(b); p != mod {
+			return nil, fmt.Errorf("%w: module path %q does not match %q", ErrInvalidGoMod, p, mod)
+		}
+		gomod = string(b)
+		break
+	}
+	pkg := &Package{
+		Module:     mod,
+		PkgVersion: version,
+		GoMod:      gomod,
+		Time:       time.Now().UTC(),
+		PkgSize:    reader.Size(),
+		reader:     reader,
+	}
+	pkg.FilePath = pkg.File(".zip")
+	_, _, sha256, _ := reader.Sums()
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+// checkZip runs the go command zip validation, which only works on files
+func checkZip(m module.Version, r io.ReaderAt, size int64) error {
+	f, err := os.CreateTemp("", "module-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := io.Copy(f, io.NewSectionReader(r, 0, size)); err != nil {
+		return err
+	}
+	cf, err := modzip.CheckZip(m, f.Name())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidLayout, err)
+	}
+	if err := cf.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidLayout, err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testFile struct {
+	name    string
+	content string
+}
+
+func testModule(t *testing.T, files ...testFile) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func TestDir(t *testing.T) {
+	tests := []struct {
+		module string
+		want   string
+	}{
+		{module: "example.org/mod", want: "example.org/mod/@v"},
+		{module: "github.com/Example/Mod/v2", want: "github.com/!example/!mod/v2/@v"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.module, func(t *testing.T) {
+			assert.Equal(t, tt.want, Dir(tt.module))
+		})
+	}
+	p := &Package{Module: "github.com/Example/mod", PkgVersion: "v1.0.0-RC.1"}
+	assert.Equal(t, "github.com/!example/mod/@v/v1.0.0-!r!c.1.zip", p.File(".zip"))
+}
+
+func TestNewPackage(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       []testFile
+		wantModule  string
+		wantVersion string
+		wantPath    string
+		wantGoMod   string
+		wantErr     error
+	}{
+		{
+			name: "with go.mod",
+			files: []testFile{
+				{name: "github.com/Example/mod@v1.2.3/go.mod", content: "module github.com/Example/mod\n\ngo 1.21\n"},
+				{name: "github.com/Example/mod@v1.2.3/mod.go", content: "package mod\n"},
+			},
+			wantModule:  "github.com/Example/mod",
+			wantVersion: "v1.2.3",
+			wantPath:    "github.com/!example/mod/@v/v1.2.3.zip",
+			wantGoMod:   "module github.com/Example/mod\n\ngo 1.21\n",
+		},
+		{
+			name: "without go.mod",
+			files: []testFile{
+				{name: "example.org/mod@v0.1.0/mod.go", content: "package mod\n"},
+			},
+			wantModule:  "example.org/mod",
+			wantVersion: "v0.1.0",
+			wantPath:    "example.org/mod/@v/v0.1.0.zip",
+			wantGoMod:   "module example.org/mod\n",
+		},
+		{
+			name: "missing version",
+			files: []testFile{
+				{name: "example.org/mod/mod.go", content: "package mod\n"},
+			},
+			wantErr: ErrInvalidLayout,
+		},
+		{
+			name: "missing version directory",
+			files: []testFile{
+				{name: "example.org/mod@v0.1.0", content: "package mod\n"},
+			},
+			wantErr: ErrInvalidLayout,
+		},
+		{
+			name: "mixed prefixes",
+			files: []testFile{
+				{name: "example.org/mod@v0.1.0/mod.go", content: "package mod\n"},
+				{name: "example.org/other@v0.1.0/other.go", content: "package other\n"},
+			},
+			wantErr: ErrInvalidLayout,
+		},
+		{
+			name: "go.mod module mismatch",
+			files: []testFile{
+				{name: "example.org/mod@v0.1.0/go.mod", content: "module example.org/other\n"},
+			},
+			wantErr: ErrInvalidGoMod,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage(bytes.NewReader(testModule(t, tt.files...)))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, tt.wantModule, pkg.Name())
+			assert.Equal(t, tt.wantVersion, pkg.Version())
+			assert.Equal(t, tt.wantPath, pkg.Path())
+			assert.Equal(t, tt.wantGoMod, pkg.GoMod)
+			assert.Len(t, pkg.SHA256, 64)
+		})
+	}
+}
+
+func TestNewPackageInvalidVersion(t *testing.T) {
+	_, err := NewPackage(bytes.NewReader(testModule(t, testFile{name: "example.org/mod@1.0.0/mod.go", content: "package mod\n"})))
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "go"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// push stores the module, published versions cannot be overwritten as their hashes are recorded in the consumers go.sum
+func (p *provider) push(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var reader io.ReadCloser = r.Body
+		if file, _, err := r.FormFile("file"); err == nil {
+			reader = file
+		}
+		defer reader.Close()
+		pkg, err := NewPackage(reader)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		if _, err := s.Stat(ctx, pkg.Path()); err == nil {
+			http.Error(w, fmt.Sprintf("module %s version %s already exists", pkg.Module, pkg.PkgVersion), http.StatusConflict)
+			return
+		} else if !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.Version()).Infof("uploading module")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// https://go.dev/ref/mod#goproxy-protocol
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method: http.MethodPut,
+			Path:   "/push",
+			Handler: p.push,
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/{module:.+}/@v/{file}",
+			Deep:   true,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return path.Join(mux.Vars(r)["module"], "@v", mux.Vars(r)["file"])
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/{module:.+}/@latest",
+			Deep:   true,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return path.Join(mux.Vars(r)["module"], LatestFile)
+			}),
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/{module:.+}/@v/{version}.zip",
+			Deep:   true,
+			Handler: packages.Delete(func(r *http.Request) string {
+				return path.Join(mux.Vars(r)["module"], "@v", mux.Vars(r)["version"]+".zip")
+			}),
+		},
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+// memStorage is a storage keeping the written artifacts in memory
+type memStorage struct {
+	storage.Storage
+	files map[string][]byte
+}
+
+func (s *memStorage) Init(_ context.Context) error {
+	return nil
+}
+
+func (s *memStorage) Stat(_ context.Context, file string) (storage.ArtifactInfo, error) {
+	if _, ok := s.files[file]; !ok {
+		return nil, fmt.Errorf("%s: %w", file, os.ErrNotExist)
+	}
+	return nil, nil
+}
+
+func (s *memStorage) Write(_ context.Context, artifacts ...storage.Artifact) error {
+	for _, v := range artifacts {
+		b, err := io.ReadAll(v)
+		if err != nil {
+			return err
+		}
+		s.files[v.Path()] = b
+	}
+	return nil
+}
+
+func TestPush(t *testing.T) {
+	s := &memStorage{files: make(map[string][]byte)}
+	push := func(b []byte) int {
+		r := httptest.NewRequest(http.MethodPut, "/push", bytes.NewReader(b))
+		r = r.WithContext(storage.Context(r.Context(), s))
+		w := httptest.NewRecorder()
+		(&provider{}).push("")(w, r)
+		return w.Code
+	}
+	v1 := testModule(t, testFile{name: "example.org/mod@v1.0.0/go.mod", content: "module example.org/mod\n"})
+	require.Equal(t, http.StatusCreated, push(v1))
+	assert.Equal(t, v1, s.files["example.org/mod/@v/v1.0.0.zip"])
+
+	modified := testModule(t,
+		testFile{name: "example.org/mod@v1.0.0/go.mod", content: "module example.org/mod\n"},
+		testFile{name: "example.org/mod@v1.0.0/mod.go", content: "package mod\n"},
+	)
+	assert.Equal(t, http.StatusConflict, push(modified), "published versions cannot be overwritten")
+	assert.Equal(t, v1, s.files["example.org/mod/@v/v1.0.0.zip"])
+
+	v2 := testModule(t, testFile{name: "example.org/mod@v1.1.0/go.mod", content: "module example.org/mod\n"})
+	assert.Equal(t, http.StatusCreated, push(v2))
+	assert.Equal(t, http.StatusBadRequest, push([]byte("not a zip")))
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	ListFile   = "list"
+	LatestFile = "@latest"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "go"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Go Module Proxy", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Info is the module version metadata served as $module/@v/$version.info
+type Info struct {
+	Version string    `json:"Version"`
+	Time    time.Time `json:"Time"`
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	mods := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.Module
+	}))
+	sort.Strings(mods)
+	for _, mod := range mods {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.Module == mod
+		})
+		sort.Slice(pkgs, func(i, j int) bool {
+			return semver.Compare(pkgs[i].PkgVersion, pkgs[j].PkgVersion) < 0
+		})
+		var list []string
+		for _, p := range pkgs {
+			b, err := json.Marshal(Info{Version: p.PkgVersion, Time: p.Time})
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, storage.NewFile(p.File(".info"), b), storage.NewFile(p.File(".mod"), []byte(p.GoMod)))
+			// pseudo-versions are not listed
+			if !module.IsPseudoVersion(p.PkgVersion) {
+				list = append(list, p.PkgVersion)
+			}
+		}
+		var data []byte
+		if len(list) != 0 {
+			data = []byte(strings.Join(list, "\n") + "\n")
+		}
+		out = append(out, storage.NewFile(path.Join(Dir(mod), ListFile), data))
+		l := latest(pkgs)
+		b, err := json.Marshal(Info{Version: l.PkgVersion, Time: l.Time})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, storage.NewFile(path.Join(path.Dir(Dir(mod)), LatestFile), b))
+	}
+	return out, nil
+}
+
+// latest returns the module version the go command would choose as the latest one:
+// the highest release version, then the highest pre-release, then the most recent pseudo-version.
+// The packages must be sorted by version.
+func latest(pkgs []*Package) *Package {
+	var pre, pseudo *Package
+	for i := len(pkgs) - 1; i >= 0; i-- {
+		p := pkgs[i]
+		switch {
+		case module.IsPseudoVersion(p.PkgVersion):
+			if pseudo == nil || p.Time.After(pseudo.Time) {
+				pseudo = p
+			}
+		case semver.Prerelease(p.PkgVersion) != "":
+			if pre == nil {
+				pre = p
+			}
+		default:
+			return p
+		}
+	}
+	if pre != nil {
+		return pre
+	}
+	return pseudo
+}
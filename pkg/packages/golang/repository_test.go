@@ -0,0 +1,120 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkg := func(mod, version string, tm time.Time) storage.Artifact {
+		p := &Package{Module: mod, PkgVersion: version, GoMod: "module " + mod + "\n", Time: tm}
+		p.FilePath = p.File(".zip")
+		return p
+	}
+	tests := []struct {
+		name       string
+		pkgs       []storage.Artifact
+		wantList   string
+		wantLatest string
+	}{
+		{
+			name: "release",
+			pkgs: []storage.Artifact{
+				pkg("github.com/Example/mod", "v1.10.0", now),
+				pkg("github.com/Example/mod", "v1.2.0", now.Add(time.Hour)),
+				pkg("github.com/Example/mod", "v2.0.0-rc.1", now.Add(2*time.Hour)),
+				pkg("github.com/Example/mod", "v0.0.0-20230101000000-abcdefabcdef", now.Add(3*time.Hour)),
+			},
+			wantList:   "v1.2.0\nv1.10.0\nv2.0.0-rc.1\n",
+			wantLatest: "v1.10.0",
+		},
+		{
+			name: "pre-release",
+			pkgs: []storage.Artifact{
+				pkg("github.com/Example/mod", "v1.0.0-beta.1", now),
+				pkg("github.com/Example/mod", "v1.0.0-alpha.1", now.Add(time.Hour)),
+			},
+			wantList:   "v1.0.0-alpha.1\nv1.0.0-beta.1\n",
+			wantLatest: "v1.0.0-beta.1",
+		},
+		{
+			name: "pseudo-versions only",
+			pkgs: []storage.Artifact{
+				pkg("github.com/Example/mod", "v0.0.0-20230102000000-abcdefabcdef", now),
+				pkg("github.com/Example/mod", "v0.0.0-20230101000000-abcdefabcdef", now.Add(time.Hour)),
+			},
+			wantLatest: "v0.0.0-20230101000000-abcdefabcdef",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := (&repo{}).Index(context.Background(), "", tt.pkgs...)
+			require.NoError(t, err)
+			files := make(map[string]string)
+			for _, v := range out {
+				b, err := io.ReadAll(v)
+				require.NoError(t, err)
+				files[v.Path()] = string(b)
+			}
+			// .info and .mod per version, plus the list and @latest
+			assert.Len(t, files, 2*len(tt.pkgs)+2)
+			assert.Equal(t, tt.wantList, files["github.com/!example/mod/@v/list"])
+			var info Info
+			require.NoError(t, json.Unmarshal([]byte(files["github.com/!example/mod/@latest"]), &info))
+			assert.Equal(t, tt.wantLatest, info.Version)
+			for _, v := range tt.pkgs {
+				p := v.(*Package)
+				assert.Equal(t, p.GoMod, files[p.File(".mod")])
+				require.NoError(t, json.Unmarshal([]byte(files[p.File(".info")]), &info))
+				assert.Equal(t, p.PkgVersion, info.Version)
+				assert.True(t, p.Time.Equal(info.Time))
+			}
+		})
+	}
+}
+
+func TestIndexModules(t *testing.T) {
+	out, err := (&repo{}).Index(context.Background(), "",
+		&Package{Module: "example.org/mod", PkgVersion: "v1.0.0"},
+		&Package{Module: "example.org/mod/sub", PkgVersion: "v0.1.0"},
+	)
+	require.NoError(t, err)
+	var paths []string
+	for _, v := range out {
+		paths = append(paths, v.Path())
+	}
+	// a nested module has its own index next to the parent module files
+	assert.ElementsMatch(t, []string{
+		"example.org/mod/@v/v1.0.0.info",
+		"example.org/mod/@v/v1.0.0.mod",
+		"example.org/mod/@v/list",
+		"example.org/mod/@latest",
+		"example.org/mod/sub/@v/v0.1.0.info",
+		"example.org/mod/sub/@v/v0.1.0.mod",
+		"example.org/mod/sub/@v/list",
+		"example.org/mod/sub/@latest",
+	}, paths)
+}
@@ -0,0 +1,324 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	MetadataFile = "maven-metadata.xml"
+
+	snapshot = "SNAPSHOT"
+)
+
+var (
+	ErrInvalidPath        = errors.New("artifact path is invalid")
+	ErrInvalidFilename    = errors.New("artifact filename is invalid")
+	ErrMissingCoordinates = errors.New("artifact coordinates not found")
+
+	// https://maven.apache.org/guides/mini/guide-naming-conventions.html
+	idPattern      = regexp.MustCompile(`\A[A-Za-z0-9_\-.]+\z`)
+	versionPattern = regexp.MustCompile(`\A[A-Za-z0-9_\-.+]+\z`)
+	// unique snapshot version suffix: yyyyMMdd.HHmmss-buildNumber
+	timestampPattern = regexp.MustCompile(`\A(\d{8}\.\d{6})-(\d+)`)
+
+	checksums = []string{".md5", ".sha1", ".sha256", ".sha512"}
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	GroupID    string `json:"groupId"`
+	ArtifactID string `json:"artifactId"`
+	// PkgVersion is the base version, e.g. 1.0-SNAPSHOT
+	PkgVersion string `json:"version"`
+	Filename   string `json:"filename"`
+	Classifier string `json:"classifier,omitempty"`
+	Extension  string `json:"extension"`
+	// Timestamp and BuildNumber are set for unique snapshot files
+	Timestamp   string    `json:"timestamp,omitempty"`
+	BuildNumber int       `json:"buildNumber,omitempty"`
+	Uploaded    time.Time `json:"uploaded"`
+
+	PkgSize  int64  `json:"size"`
+	FilePath string `json:"filePath"`
+
+	MD5    string `json:"md5"`
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+	SHA512 string `json:"sha512"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.GroupID + ":" + p.ArtifactID
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// IsSnapshot returns whether the package version is a snapshot
+func (p *Package) IsSnapshot() bool {
+	return IsSnapshot(p.PkgVersion)
+}
+
+// Checksum returns the hex encoded checksum matching the checksum file extension, e.g. ".sha1"
+func (p *Package) Checksum(ext string) string {
+	switch ext {
+	case ".md5":
+		return p.MD5
+	case ".sha1":
+		return p.SHA1
+	case ".sha256":
+		return p.SHA256
+	case ".sha512":
+		return p.SHA512
+	}
+	return ""
+}
+
+func IsSnapshot(version string) bool {
+	return strings.HasSuffix(version, "-"+snapshot)
+}
+
+// IsChecksum returns the checksum extension of the file if it is a checksum file
+func IsChecksum(name string) (string, bool) {
+	for _, v := range checksums {
+		if strings.HasSuffix(name, v) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// IsMetadata returns whether the file is a maven-metadata.xml file or one of its checksums
+func IsMetadata(name string) bool {
+	if ext, ok := IsChecksum(name); ok {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return path.Base(name) == MetadataFile
+}
+
+// ParsePath parses a repository layout path: {groupId as directories}/{artifactId}/{version}/{filename}
+// https://maven.apache.org/repository/layout.html
+func ParsePath(p string) (*Package, error) {
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	if len(parts) < 4 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPath, p)
+	}
+	for _, v := range parts[:len(parts)-2] {
+		if !idPattern.MatchString(v) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPath, p)
+		}
+	}
+	pkg := &Package{
+		GroupID:    strings.Join(parts[:len(parts)-3], "."),
+		ArtifactID: parts[len(parts)-3],
+		PkgVersion: parts[len(parts)-2],
+		Filename:   parts[len(parts)-1],
+	}
+	if !versionPattern.MatchString(pkg.PkgVersion) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPath, p)
+	}
+	// {artifactId}-{version}(-{classifier})?.{extension}
+	rest, ok := strings.CutPrefix(pkg.Filename, pkg.ArtifactID+"-")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilename, pkg.Filename)
+	}
+	if r, ok := strings.CutPrefix(rest, pkg.PkgVersion); ok {
+		rest = r
+	} else if r, ok := strings.CutPrefix(rest, strings.TrimSuffix(pkg.PkgVersion, snapshot)); ok && pkg.IsSnapshot() {
+		m := timestampPattern.FindStringSubmatch(r)
+		if m == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFilename, pkg.Filename)
+		}
+		pkg.Timestamp = m[1]
+		pkg.BuildNumber, _ = strconv.Atoi(m[2])
+		rest = strings.TrimPrefix(r, m[0])
+	} else {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilename, pkg.Filename)
+	}
+	switch {
+	case strings.HasPrefix(rest, "-"):
+		c, ext, ok := strings.Cut(rest[1:], ".")
+		if !ok || c == "" || ext == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFilename, pkg.Filename)
+		}
+		pkg.Classifier, pkg.Extension = c, ext
+	case strings.HasPrefix(rest, ".") && len(rest) > 1:
+		pkg.Extension = rest[1:]
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilename, pkg.Filename)
+	}
+	pkg.FilePath = path.Join(strings.Join(parts[:len(parts)-3], "/"), pkg.ArtifactID, pkg.PkgVersion, pkg.Filename)
+	return pkg, nil
+}
+
+// NewPackage reads the artifact file at the given repository path.
+// If the path is empty, it is computed from the artifact content.
+func NewPackage(r io.Reader, p string) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if p == "" {
+		if p, err = DetectPath(reader, reader.Size()); err != nil {
+			return nil, err
+		}
+	}
+	pkg, err := ParsePath(p)
+	if err != nil {
+		return nil, err
+	}
+	pkg.Uploaded = time.Now().UTC()
+	pkg.reader = reader
+	pkg.PkgSize = reader.Size()
+	md5, sha1, sha256, sha512 := reader.Sums()
+	pkg.MD5 = hex.EncodeToString(md5)
+	pkg.SHA1 = hex.EncodeToString(sha1)
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	pkg.SHA512 = hex.EncodeToString(sha512)
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+// DetectPath returns the repository path of a jar containing the maven descriptor
+// (META-INF/maven/{groupId}/{artifactId}/pom.properties) or of a pom file.
+// Snapshots cannot be detected as their filename contains the deployment timestamp.
+func DetectPath(r io.ReaderAt, size int64) (string, error) {
+	var g, a, v, ext string
+	if zr, err := zip.NewReader(r, size); err == nil {
+		ext = "jar"
+		for _, f := range zr.File {
+			if !strings.HasPrefix(f.Name, "META-INF/maven/") || path.Base(f.Name) != "pom.properties" {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return "", err
+			}
+			props := parseProperties(rc)
+			rc.Close()
+			g, a, v = props["groupId"], props["artifactId"], props["version"]
+			break
+		}
+	} else {
+		ext = "pom"
+		var pom struct {
+			GroupID    string `xml:"groupId"`
+			ArtifactID string `xml:"artifactId"`
+			Version    string `xml:"version"`
+			Parent     struct {
+				GroupID string `xml:"groupId"`
+				Version string `xml:"version"`
+			} `xml:"parent"`
+		}
+		if err := xml.NewDecoder(io.NewSectionReader(r, 0, size)).Decode(&pom); err != nil {
+			return "", fmt.Errorf("%w: not a jar or a pom file", ErrMissingCoordinates)
+		}
+		g, a, v = pom.GroupID, pom.ArtifactID, pom.Version
+		// groupId and version may be inherited from the parent
+		if g == "" {
+			g = pom.Parent.GroupID
+		}
+		if v == "" {
+			v = pom.Parent.Version
+		}
+	}
+	if g == "" || a == "" || v == "" {
+		return "", ErrMissingCoordinates
+	}
+	if IsSnapshot(v) {
+		return "", fmt.Errorf("%s:%s:%s: snapshots must be deployed with maven", g, a, v)
+	}
+	return path.Join(strings.ReplaceAll(g, ".", "/"), a, v, fmt.Sprintf("%s-%s.%s", a, v, ext)), nil
+}
+
+func parseProperties(r io.Reader) map[string]string {
+	out := make(map[string]string)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		l := strings.TrimSpace(s.Text())
+		if l == "" || l[0] == '#' || l[0] == '!' {
+			continue
+		}
+		k, v, ok := strings.Cut(l, "=")
+		if !ok {
+			continue
+		}
+		out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return out
+}
+
+// checksum returns the checksum found at the beginning of a checksum file content,
+// which may be followed by the filename
+func checksum(b []byte) string {
+	f := bytes.Fields(b)
+	if len(f) == 0 {
+		return ""
+	}
+	return strings.ToLower(string(f[0]))
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    *Package
+		wantErr error
+	}{
+		{
+			path: "org/example/lib/1.0/lib-1.0.jar",
+			want: &Package{GroupID: "org.example", ArtifactID: "lib", PkgVersion: "1.0", Filename: "lib-1.0.jar", Extension: "jar"},
+		},
+		{
+			path: "/org/example/lib/1.0/lib-1.0-sources.jar",
+			want: &Package{GroupID: "org.example", ArtifactID: "lib", PkgVersion: "1.0", Filename: "lib-1.0-sources.jar", Classifier: "sources", Extension: "jar"},
+		},
+		{
+			path: "org/example/lib/1.0/lib-1.0.tar.gz",
+			want: &Package{GroupID: "org.example", ArtifactID: "lib", PkgVersion: "1.0", Filename: "lib-1.0.tar.gz", Extension: "tar.gz"},
+		},
+		{
+			path: "org/example/lib/1.0-SNAPSHOT/lib-1.0-20230101.120000-3.pom",
+			want: &Package{GroupID: "org.example", ArtifactID: "lib", PkgVersion: "1.0-SNAPSHOT", Filename: "lib-1.0-20230101.120000-3.pom", Extension: "pom", Timestamp: "20230101.120000", BuildNumber: 3},
+		},
+		{
+			path: "org/example/lib/1.0-SNAPSHOT/lib-1.0-20230101.120000-3-javadoc.jar",
+			want: &Package{GroupID: "org.example", ArtifactID: "lib", PkgVersion: "1.0-SNAPSHOT", Filename: "lib-1.0-20230101.120000-3-javadoc.jar", Classifier: "javadoc", Extension: "jar", Timestamp: "20230101.120000", BuildNumber: 3},
+		},
+		{
+			path: "org/example/lib/1.0-SNAPSHOT/lib-1.0-SNAPSHOT.jar",
+			want: &Package{GroupID: "org.example", ArtifactID: "lib", PkgVersion: "1.0-SNAPSHOT", Filename: "lib-1.0-SNAPSHOT.jar", Extension: "jar"},
+		},
+		{path: "lib/1.0/lib-1.0.jar", wantErr: ErrInvalidPath},
+		{path: "org/exa mple/lib/1.0/lib-1.0.jar", wantErr: ErrInvalidPath},
+		{path: "org/example/lib/1.0/other-1.0.jar", wantErr: ErrInvalidFilename},
+		{path: "org/example/lib/1.0/lib-1.1.jar", wantErr: ErrInvalidFilename},
+		{path: "org/example/lib/1.0/lib-1.0", wantErr: ErrInvalidFilename},
+		{path: "org/example/lib/1.0/lib-1.0-.jar", wantErr: ErrInvalidFilename},
+		{path: "org/example/lib/1.0-SNAPSHOT/lib-1.0-latest.jar", wantErr: ErrInvalidFilename},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParsePath(tt.path)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.want.FilePath = strings.TrimPrefix(tt.path, "/")
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsMetadata(t *testing.T) {
+	assert.True(t, IsMetadata("org/example/lib/maven-metadata.xml"))
+	assert.True(t, IsMetadata("org/example/lib/1.0-SNAPSHOT/maven-metadata.xml.sha1"))
+	assert.False(t, IsMetadata("org/example/lib/1.0/lib-1.0.jar.sha1"))
+	ext, ok := IsChecksum("lib-1.0.jar.sha256")
+	assert.True(t, ok)
+	assert.Equal(t, ".sha256", ext)
+	assert.Equal(t, "abcdef", checksum([]byte("ABCDEF  lib-1.0.jar\n")))
+}
+
+func testJar(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func TestDetectPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "jar",
+			data: testJar(t, map[string]string{
+				"META-INF/MANIFEST.MF":                          "Manifest-Version: 1.0\n",
+				"META-INF/maven/org.example/lib/pom.properties": "# generated\ngroupId=org.example\nartifactId=lib\nversion=1.0\n",
+			}),
+			want: "org/example/lib/1.0/lib-1.0.jar",
+		},
+		{
+			name: "pom",
+			data: []byte(`<project><groupId>org.example</groupId><artifactId>lib</artifactId><version>1.0</version></project>`),
+			want: "org/example/lib/1.0/lib-1.0.pom",
+		},
+		{
+			name: "pom with parent",
+			data: []byte(`<project><parent><groupId>org.example</groupId><version>2.0</version></parent><artifactId>lib</artifactId></project>`),
+			want: "org/example/lib/2.0/lib-2.0.pom",
+		},
+		{
+			name:    "jar without descriptor",
+			data:    testJar(t, map[string]string{"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\n"}),
+			wantErr: true,
+		},
+		{
+			name:    "snapshot",
+			data:    []byte(`<project><groupId>org.example</groupId><artifactId>lib</artifactId><version>1.0-SNAPSHOT</version></project>`),
+			wantErr: true,
+		},
+		{
+			name:    "garbage",
+			data:    []byte("not an artifact"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectPath(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewPackage(t *testing.T) {
+	data := []byte(`<project><groupId>org.example</groupId><artifactId>lib</artifactId><version>1.0</version></project>`)
+	pkg, err := NewPackage(bytes.NewReader(data), "")
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, "org.example:lib", pkg.Name())
+	assert.Equal(t, "org/example/lib/1.0/lib-1.0.pom", pkg.Path())
+	assert.Equal(t, int64(len(data)), pkg.Size())
+	assert.Len(t, pkg.Checksum(".md5"), 32)
+	assert.Len(t, pkg.Checksum(".sha1"), 40)
+	assert.Len(t, pkg.Checksum(".sha256"), 64)
+	assert.Len(t, pkg.Checksum(".sha512"), 128)
+}
@@ -0,0 +1,225 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "maven"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// upload handles the files deployed by maven.
+// The maven-metadata.xml files are generated by the repository, so the uploaded ones are discarded,
+// as are the checksums files once verified against the stored artifact.
+// Releases cannot be overwritten.
+func (p *provider) upload(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		name := mux.Vars(r)["path"]
+		s := storage.FromContext(ctx)
+		if IsMetadata(name) {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		if ext, ok := IsChecksum(name); ok {
+			b, err := io.ReadAll(io.LimitReader(r.Body, 1024))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			pkg, err := stat(ctx, s, strings.TrimSuffix(name, ext))
+			if err != nil && !storage.IsNotFound(err) {
+				storage.Error(w, err)
+				return
+			}
+			if pkg != nil && checksum(b) != pkg.Checksum(ext) {
+				http.Error(w, fmt.Sprintf("%s: checksum mismatch: expected %s, got %s", pkg.Filename, checksum(b), pkg.Checksum(ext)), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		pkg, err := NewPackage(r.Body, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		if !pkg.IsSnapshot() {
+			if _, err := s.Stat(ctx, pkg.Path()); err == nil {
+				http.Error(w, fmt.Sprintf("%s: release already exists", pkg.Path()), http.StatusConflict)
+				return
+			} else if !storage.IsNotFound(err) {
+				storage.Error(w, err)
+				return
+			}
+		}
+		logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.Version(), "filepath", pkg.Path()).Infof("uploading artifact")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// download serves the repository files, the checksums files are computed on the fly
+func (p *provider) download(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		name := mux.Vars(r)["path"]
+		s := storage.FromContext(ctx)
+		ext, ok := IsChecksum(name)
+		if !ok {
+			if err := s.ServeFile(w, r, name); err != nil {
+				storage.Error(w, err)
+			}
+			return
+		}
+		name = strings.TrimSuffix(name, ext)
+		var sum string
+		pkg, err := stat(ctx, s, name)
+		switch {
+		case err != nil:
+			storage.Error(w, err)
+			return
+		case pkg != nil:
+			sum = pkg.Checksum(ext)
+		default:
+			// generated files do not have metadata
+			if sum, err = hashFile(ctx, s, name, ext); err != nil {
+				storage.Error(w, err)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, sum)
+	}
+}
+
+// stat returns the artifact stored at the given path,
+// or nil if the path is a generated file
+func stat(ctx context.Context, s storage.Storage, name string) (*Package, error) {
+	i, err := s.Stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(i.Meta()) == 0 {
+		return nil, nil
+	}
+	var pkg Package
+	if err := json.Unmarshal(i.Meta(), &pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+func hashFile(ctx context.Context, s storage.Storage, name, ext string) (string, error) {
+	var h hash.Hash
+	switch ext {
+	case ".md5":
+		h = md5.New()
+	case ".sha1":
+		h = sha1.New()
+	case ".sha256":
+		h = sha256.New()
+	default:
+		h = sha512.New()
+	}
+	rc, err := s.Open(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// https://maven.apache.org/repository/layout.html
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodPut,
+			Path:    "/push",
+			Handler: p.upload,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/{path:.+}",
+			Deep:    true,
+			Handler: p.download,
+		},
+		{
+			Method:  http.MethodHead,
+			Path:    "/{path:.+}",
+			Deep:    true,
+			Handler: p.download,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/{path:.+}",
+			Deep:    true,
+			Handler: p.upload,
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/{path:.+}",
+			Deep:   true,
+			Handler: packages.Delete(func(r *http.Request) string {
+				return mux.Vars(r)["path"]
+			}),
+		},
+	}
+}
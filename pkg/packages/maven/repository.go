@@ -0,0 +1,347 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	timeFormat = "20060102150405"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "maven"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Maven Repository", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// https://maven.apache.org/ref/3.9.6/maven-repository-metadata/repository-metadata.html
+
+type Metadata struct {
+	XMLName      xml.Name   `xml:"metadata"`
+	ModelVersion string     `xml:"modelVersion,attr"`
+	GroupID      string     `xml:"groupId"`
+	ArtifactID   string     `xml:"artifactId"`
+	Version      string     `xml:"version,omitempty"`
+	Versioning   Versioning `xml:"versioning"`
+}
+
+type Versioning struct {
+	Latest           string            `xml:"latest,omitempty"`
+	Release          string            `xml:"release,omitempty"`
+	Snapshot         *Snapshot         `xml:"snapshot,omitempty"`
+	Versions         *Versions         `xml:"versions,omitempty"`
+	LastUpdated      string            `xml:"lastUpdated"`
+	SnapshotVersions *SnapshotVersions `xml:"snapshotVersions,omitempty"`
+}
+
+type Versions struct {
+	Version []string `xml:"version"`
+}
+
+type SnapshotVersions struct {
+	SnapshotVersion []SnapshotVersion `xml:"snapshotVersion"`
+}
+
+type Snapshot struct {
+	Timestamp   string `xml:"timestamp"`
+	BuildNumber int    `xml:"buildNumber"`
+}
+
+type SnapshotVersion struct {
+	Classifier string `xml:"classifier,omitempty"`
+	Extension  string `xml:"extension"`
+	Value      string `xml:"value"`
+	Updated    string `xml:"updated"`
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	names := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.Name()
+	}))
+	sort.Strings(names)
+	for _, name := range names {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.Name() == name
+		})
+		dir := path.Dir(path.Dir(pkgs[0].Path()))
+		versions := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+			return p.PkgVersion
+		}))
+		sort.Slice(versions, func(i, j int) bool {
+			return CompareVersions(versions[i], versions[j]) < 0
+		})
+		m := Metadata{
+			ModelVersion: "1.1.0",
+			GroupID:      pkgs[0].GroupID,
+			ArtifactID:   pkgs[0].ArtifactID,
+			Versioning: Versioning{
+				Latest:      versions[len(versions)-1],
+				Versions:    &Versions{Version: versions},
+				LastUpdated: lastUpdated(pkgs),
+			},
+		}
+		for _, v := range versions {
+			if !IsSnapshot(v) {
+				m.Versioning.Release = v
+			}
+		}
+		f, err := buildMetadataFile(path.Join(dir, MetadataFile), m)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+		for _, v := range versions {
+			if !IsSnapshot(v) {
+				continue
+			}
+			f, err := buildMetadataFile(path.Join(dir, v, MetadataFile), snapshotMetadata(v, slices.Filter(pkgs, func(p *Package) bool {
+				return p.PkgVersion == v
+			})))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+// snapshotMetadata builds the version level metadata listing the latest deployed file of each classifier and extension
+func snapshotMetadata(version string, pkgs []*Package) Metadata {
+	sort.Slice(pkgs, func(i, j int) bool {
+		if pkgs[i].Timestamp != pkgs[j].Timestamp {
+			return pkgs[i].Timestamp < pkgs[j].Timestamp
+		}
+		return pkgs[i].BuildNumber < pkgs[j].BuildNumber
+	})
+	m := Metadata{
+		ModelVersion: "1.1.0",
+		GroupID:      pkgs[0].GroupID,
+		ArtifactID:   pkgs[0].ArtifactID,
+		Version:      version,
+		Versioning: Versioning{
+			LastUpdated: lastUpdated(pkgs),
+		},
+	}
+	if l := pkgs[len(pkgs)-1]; l.Timestamp != "" {
+		m.Versioning.Snapshot = &Snapshot{Timestamp: l.Timestamp, BuildNumber: l.BuildNumber}
+	}
+	type key struct{ classifier, extension string }
+	latest := make(map[key]*Package)
+	var keys []key
+	for _, v := range pkgs {
+		k := key{v.Classifier, v.Extension}
+		if _, ok := latest[k]; !ok {
+			keys = append(keys, k)
+		}
+		latest[k] = v
+	}
+	m.Versioning.SnapshotVersions = &SnapshotVersions{}
+	for _, k := range keys {
+		p := latest[k]
+		value := p.PkgVersion
+		if p.Timestamp != "" {
+			value = strings.TrimSuffix(p.PkgVersion, snapshot) + p.Timestamp + "-" + strconv.Itoa(p.BuildNumber)
+		}
+		m.Versioning.SnapshotVersions.SnapshotVersion = append(m.Versioning.SnapshotVersions.SnapshotVersion, SnapshotVersion{
+			Classifier: k.classifier,
+			Extension:  k.extension,
+			Value:      value,
+			Updated:    p.Uploaded.Format(timeFormat),
+		})
+	}
+	return m
+}
+
+func lastUpdated(pkgs []*Package) string {
+	var t time.Time
+	for _, v := range pkgs {
+		if v.Uploaded.After(t) {
+			t = v.Uploaded
+		}
+	}
+	return t.UTC().Format(timeFormat)
+}
+
+func buildMetadataFile(name string, m Metadata) (storage.Artifact, error) {
+	b, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewFile(name, append([]byte(xml.Header), append(b, '\n')...)), nil
+}
+
+// qualifiers are the well known version qualifiers in ascending order, the release being the empty one
+// https://maven.apache.org/pom.html#version-order-specification
+var qualifiers = map[string]int{
+	"alpha":     0,
+	"beta":      1,
+	"milestone": 2,
+	"rc":        3,
+	"snapshot":  4,
+	"":          5,
+	"sp":        6,
+}
+
+var qualifierAliases = map[string]string{
+	"a":       "alpha",
+	"b":       "beta",
+	"m":       "milestone",
+	"cr":      "rc",
+	"ga":      "",
+	"final":   "",
+	"release": "",
+}
+
+type versionItem struct {
+	num   string
+	qual  string
+	isNum bool
+}
+
+func (i versionItem) compare(o versionItem) int {
+	switch {
+	case i.isNum && o.isNum:
+		if len(i.num) != len(o.num) {
+			return cmp.Compare(len(i.num), len(o.num))
+		}
+		return strings.Compare(i.num, o.num)
+	case i.isNum:
+		return 1
+	case o.isNum:
+		return -1
+	}
+	ri, iok := qualifiers[i.qual]
+	ro, ook := qualifiers[o.qual]
+	switch {
+	case iok && ook:
+		return cmp.Compare(ri, ro)
+	case iok:
+		return -1
+	case ook:
+		return 1
+	}
+	return strings.Compare(i.qual, o.qual)
+}
+
+func parseVersion(v string) []versionItem {
+	var (
+		items []versionItem
+		cur   []rune
+	)
+	flush := func() {
+		s := string(cur)
+		cur = nil
+		if s == "" {
+			return
+		}
+		if unicode.IsDigit(rune(s[0])) {
+			s = strings.TrimLeft(s, "0")
+			items = append(items, versionItem{num: s, isNum: true})
+			return
+		}
+		s = strings.ToLower(s)
+		if a, ok := qualifierAliases[s]; ok {
+			s = a
+		}
+		items = append(items, versionItem{qual: s})
+	}
+	for _, r := range v {
+		switch {
+		case r == '.' || r == '-':
+			flush()
+		case len(cur) != 0 && unicode.IsDigit(r) != unicode.IsDigit(cur[len(cur)-1]):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	return items
+}
+
+// CompareVersions compares two maven versions following a simplified version order specification:
+// numeric items are compared numerically, qualifiers by their well known order, then lexically,
+// and missing items are equal to 0 or to the release qualifier.
+func CompareVersions(a, b string) int {
+	va, vb := parseVersion(a), parseVersion(b)
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		x, y := padding(va, vb, i), padding(vb, va, i)
+		if c := x.compare(y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// padding returns the item at index i or the item equivalent to a missing one
+func padding(v, o []versionItem, i int) versionItem {
+	if i < len(v) {
+		return v[i]
+	}
+	if o[i].isNum {
+		return versionItem{isNum: true}
+	}
+	return versionItem{}
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maven
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	pkg := func(p string, uploaded time.Time) storage.Artifact {
+		pkg, err := ParsePath(p)
+		require.NoError(t, err)
+		pkg.Uploaded = uploaded
+		return pkg
+	}
+	out, err := (&repo{}).Index(context.Background(), "",
+		pkg("org/example/lib/1.10/lib-1.10.jar", now),
+		pkg("org/example/lib/1.9/lib-1.9.jar", now.Add(time.Hour)),
+		pkg("org/example/lib/2.0-SNAPSHOT/lib-2.0-20230101.120000-1.jar", now.Add(2*time.Hour)),
+		pkg("org/example/lib/2.0-SNAPSHOT/lib-2.0-20230101.120000-1.pom", now.Add(2*time.Hour)),
+		pkg("org/example/lib/2.0-SNAPSHOT/lib-2.0-20230101.130000-2.jar", now.Add(3*time.Hour)),
+		pkg("org/example/lib/2.0-SNAPSHOT/lib-2.0-20230101.130000-2-sources.jar", now.Add(3*time.Hour)),
+		pkg("org/example/other/1.0/other-1.0.pom", now),
+	)
+	require.NoError(t, err)
+	files := make(map[string]Metadata)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		var m Metadata
+		require.NoError(t, xml.Unmarshal(b, &m))
+		files[v.Path()] = m
+	}
+	require.Len(t, files, 3)
+
+	m := files["org/example/lib/maven-metadata.xml"]
+	assert.Equal(t, "org.example", m.GroupID)
+	assert.Equal(t, "lib", m.ArtifactID)
+	assert.Empty(t, m.Version)
+	assert.Equal(t, Versioning{
+		Latest:      "2.0-SNAPSHOT",
+		Release:     "1.10",
+		Versions:    &Versions{Version: []string{"1.9", "1.10", "2.0-SNAPSHOT"}},
+		LastUpdated: "20230101150000",
+	}, m.Versioning)
+
+	m = files["org/example/lib/2.0-SNAPSHOT/maven-metadata.xml"]
+	assert.Equal(t, "2.0-SNAPSHOT", m.Version)
+	assert.Equal(t, Versioning{
+		Snapshot:    &Snapshot{Timestamp: "20230101.130000", BuildNumber: 2},
+		LastUpdated: "20230101150000",
+		SnapshotVersions: &SnapshotVersions{SnapshotVersion: []SnapshotVersion{
+			{Extension: "jar", Value: "2.0-20230101.130000-2", Updated: "20230101150000"},
+			// the pom was not redeployed with the last build
+			{Extension: "pom", Value: "2.0-20230101.120000-1", Updated: "20230101140000"},
+			{Classifier: "sources", Extension: "jar", Value: "2.0-20230101.130000-2", Updated: "20230101150000"},
+		}},
+	}, m.Versioning)
+
+	m = files["org/example/other/maven-metadata.xml"]
+	assert.Equal(t, "1.0", m.Versioning.Release)
+	assert.Equal(t, "1.0", m.Versioning.Latest)
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0", b: "1.0", want: 0},
+		{a: "1", b: "1.0.0", want: 0},
+		{a: "1.9", b: "1.10", want: -1},
+		{a: "1.0-alpha-1", b: "1.0-beta-1", want: -1},
+		{a: "1.0-rc1", b: "1.0", want: -1},
+		{a: "1.0-SNAPSHOT", b: "1.0", want: -1},
+		{a: "1.0-rc1", b: "1.0-SNAPSHOT", want: -1},
+		{a: "1.0", b: "1.0-sp1", want: -1},
+		{a: "2.0", b: "1.10", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, CompareVersions(tt.a, tt.b))
+			assert.Equal(t, -tt.want, CompareVersions(tt.b, tt.a))
+		})
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	NarDir     = "nar"
+	NarInfoDir = "narinfo"
+
+	NarInfoExt = ".narinfo"
+
+	KindNar     = "nar"
+	KindNarInfo = "narinfo"
+
+	// nix32Chars is the nix base32 alphabet, which omits the e, o, u and t letters
+	nix32Chars = "0123456789abcdfghijklmnpqrsvwxyz"
+)
+
+var (
+	ErrInvalidNar     = errors.New("nar file is invalid")
+	ErrInvalidNarInfo = errors.New("narinfo is invalid")
+
+	hashPattern    = regexp.MustCompile(`\A[` + nix32Chars + `]{32}\z`)
+	narFilePattern = regexp.MustCompile(`\A([` + nix32Chars + `]{52})\.nar(\.[a-z0-9]+)?\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+// Package is either a nar file or the narinfo describing a store path
+type Package struct {
+	Kind string   `json:"kind"`
+	Hash string   `json:"hash"`
+	Info *NarInfo `json:"info,omitempty"`
+
+	Published time.Time `json:"published"`
+	PkgSize   int64     `json:"size"`
+	FilePath  string    `json:"filePath"`
+	SHA256    string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+// Name returns the store path name for the narinfo, e.g. hello-2.12.1, and the file name for the nar
+func (p *Package) Name() string {
+	if p.Info != nil {
+		_, name, _ := strings.Cut(path.Base(p.Info.StorePath), "-")
+		return name
+	}
+	return path.Base(p.FilePath)
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	if p.Info != nil {
+		return p.Info.System
+	}
+	return ""
+}
+
+func (p *Package) Version() string {
+	return ""
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// NarInfoPath returns the uploaded narinfo path, the signed one being served at the repository root
+func NarInfoPath(hash string) string {
+	return path.Join(NarInfoDir, hash+NarInfoExt)
+}
+
+// NewNar creates a nar package, the file name being the nix32 encoded sha256 of the (compressed) nar file,
+// e.g. 1w1fff338fvdw53sqgamddn1b2xgds473pv6y13gizdbqjv4i5p3.nar.xz
+func NewNar(r io.Reader, file string) (*Package, error) {
+	m := narFilePattern.FindStringSubmatch(file)
+	if m == nil {
+		return nil, fmt.Errorf("%w: invalid file name %q", ErrInvalidNar, file)
+	}
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	_, _, sha256, _ := reader.Sums()
+	if got := Nix32(sha256); got != m[1] {
+		reader.Close()
+		return nil, fmt.Errorf("%w: file hash mismatch: expected %s, got %s", ErrInvalidNar, m[1], got)
+	}
+	return &Package{
+		Kind:      KindNar,
+		Hash:      m[1],
+		Published: time.Now().UTC(),
+		PkgSize:   reader.Size(),
+		FilePath:  path.Join(NarDir, file),
+		SHA256:    hex.EncodeToString(sha256),
+		reader:    reader,
+	}, nil
+}
+
+// NewNarInfo creates a narinfo package for the store path hash
+func NewNarInfo(r io.Reader, hash string) (*Package, error) {
+	if !hashPattern.MatchString(hash) {
+		return nil, fmt.Errorf("%w: invalid hash %q", ErrInvalidNarInfo, hash)
+	}
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	info, err := ParseNarInfo(b)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	if info.Hash() != hash {
+		reader.Close()
+		return nil, fmt.Errorf("%w: store path %s does not match %s", ErrInvalidNarInfo, info.StorePath, hash)
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	_, _, sha256, _ := reader.Sums()
+	return &Package{
+		Kind:      KindNarInfo,
+		Hash:      hash,
+		Info:      info,
+		Published: time.Now().UTC(),
+		PkgSize:   reader.Size(),
+		FilePath:  NarInfoPath(hash),
+		SHA256:    hex.EncodeToString(sha256),
+		reader:    reader,
+	}, nil
+}
+
+// NarInfo describes a store path and its nar file
+// https://nixos.org/manual/nix/stable/protocols/binary-cache.html
+type NarInfo struct {
+	StorePath   string   `json:"storePath"`
+	URL         string   `json:"url"`
+	Compression string   `json:"compression,omitempty"`
+	FileHash    string   `json:"fileHash,omitempty"`
+	FileSize    int64    `json:"fileSize,omitempty"`
+	NarHash     string   `json:"narHash"`
+	NarSize     int64    `json:"narSize"`
+	References  []string `json:"references"`
+	Deriver     string   `json:"deriver,omitempty"`
+	System      string   `json:"system,omitempty"`
+	Sigs        []string `json:"sigs,omitempty"`
+	CA          string   `json:"ca,omitempty"`
+}
+
+// ParseNarInfo parses the narinfo "Key: value" lines
+func ParseNarInfo(b []byte) (*NarInfo, error) {
+	i := &NarInfo{References: []string{}}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(s.Text(), ": ")
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid line %q", ErrInvalidNarInfo, s.Text())
+		}
+		var err error
+		switch k {
+		case "StorePath":
+			i.StorePath = v
+		case "URL":
+			i.URL = v
+		case "Compression":
+			i.Compression = v
+		case "FileHash":
+			i.FileHash = v
+		case "FileSize":
+			i.FileSize, err = strconv.ParseInt(v, 10, 64)
+		case "NarHash":
+			i.NarHash = v
+		case "NarSize":
+			i.NarSize, err = strconv.ParseInt(v, 10, 64)
+		case "References":
+			i.References = strings.Fields(v)
+		case "Deriver":
+			if v != "unknown-deriver" {
+				i.Deriver = v
+			}
+		case "System":
+			i.System = v
+		case "Sig":
+			i.Sigs = append(i.Sigs, v)
+		case "CA":
+			i.CA = v
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s: %v", ErrInvalidNarInfo, k, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if i.StorePath == "" || i.URL == "" || i.NarHash == "" || i.NarSize == 0 {
+		return nil, fmt.Errorf("%w: missing StorePath, URL, NarHash or NarSize", ErrInvalidNarInfo)
+	}
+	if !hashPattern.MatchString(i.Hash()) {
+		return nil, fmt.Errorf("%w: invalid store path %q", ErrInvalidNarInfo, i.StorePath)
+	}
+	if _, err := NormalizeHash(i.NarHash); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Hash returns the store path hash, e.g. 7h7qgvs4kgzsn8a6rb273saxyqh4jxlz for
+// /nix/store/7h7qgvs4kgzsn8a6rb273saxyqh4jxlz-hello-2.12.1
+func (i *NarInfo) Hash() string {
+	h, _, _ := strings.Cut(path.Base(i.StorePath), "-")
+	return h
+}
+
+// Fingerprint returns the store path fingerprint signed by the binary caches
+// https://github.com/NixOS/nix/blob/master/src/libstore/path-info.cc
+func (i *NarInfo) Fingerprint() (string, error) {
+	h, err := NormalizeHash(i.NarHash)
+	if err != nil {
+		return "", err
+	}
+	dir := path.Dir(i.StorePath)
+	refs := make([]string, 0, len(i.References))
+	for _, v := range i.References {
+		refs = append(refs, path.Join(dir, v))
+	}
+	return fmt.Sprintf("1;%s;%s;%d;%s", i.StorePath, h, i.NarSize, strings.Join(refs, ",")), nil
+}
+
+func (i *NarInfo) String() string {
+	var b strings.Builder
+	w := func(k, v string) {
+		b.WriteString(k + ": " + v + "\n")
+	}
+	w("StorePath", i.StorePath)
+	w("URL", i.URL)
+	if i.Compression != "" {
+		w("Compression", i.Compression)
+	}
+	if i.FileHash != "" {
+		w("FileHash", i.FileHash)
+	}
+	if i.FileSize != 0 {
+		w("FileSize", strconv.FormatInt(i.FileSize, 10))
+	}
+	w("NarHash", i.NarHash)
+	w("NarSize", strconv.FormatInt(i.NarSize, 10))
+	w("References", strings.Join(i.References, " "))
+	if i.Deriver != "" {
+		w("Deriver", i.Deriver)
+	}
+	if i.System != "" {
+		w("System", i.System)
+	}
+	for _, v := range i.Sigs {
+		w("Sig", v)
+	}
+	if i.CA != "" {
+		w("CA", i.CA)
+	}
+	return b.String()
+}
+
+// NormalizeHash returns the sha256 hash in the nix32 format used in the fingerprints, e.g. sha256:1b8m03r63zqhnjf7l5wnldhh7c134ap5vpj0850ymkq1iyzicy5s,
+// the hash being either nix32, hex or base64 (SRI) encoded
+func NormalizeHash(h string) (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	switch v, ok := strings.CutPrefix(h, "sha256:"); {
+	case ok && len(v) == 52:
+		return h, nil
+	case ok && len(v) == 64:
+		b, err = hex.DecodeString(v)
+	case strings.HasPrefix(h, "sha256-"):
+		b, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(h, "sha256-"))
+	default:
+		return "", fmt.Errorf("%w: unsupported hash %q", ErrInvalidNarInfo, h)
+	}
+	if err != nil || len(b) != 32 {
+		return "", fmt.Errorf("%w: invalid hash %q", ErrInvalidNarInfo, h)
+	}
+	return "sha256:" + Nix32(b), nil
+}
+
+// Nix32 returns the nix base32 encoding of the bytes
+// https://github.com/NixOS/nix/blob/master/src/libutil/hash.cc
+func Nix32(b []byte) string {
+	n := (len(b)*8-1)/5 + 1
+	out := make([]byte, 0, n)
+	for i := n - 1; i >= 0; i-- {
+		bit := i * 5
+		j, k := bit/8, uint(bit%8)
+		c := b[j] >> k
+		if j+1 < len(b) {
+			c |= b[j+1] << (8 - k)
+		}
+		out = append(out, nix32Chars[c&0x1f])
+	}
+	return string(out)
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nix
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testHash    = "7h7qgvs4kgzsn8a6rb273saxyqh4jxlz"
+	testNarInfo = `StorePath: /nix/store/7h7qgvs4kgzsn8a6rb273saxyqh4jxlz-hello-2.12.1
+URL: nar/1w1fff338fvdw53sqgamddn1b2xgds473pv6y13gizdbqjv4i5p3.nar.xz
+Compression: xz
+FileHash: sha256:1w1fff338fvdw53sqgamddn1b2xgds473pv6y13gizdbqjv4i5p3
+FileSize: 50088
+NarHash: sha256:0mdqa9w1p6cmli6976v4wi0sw9r4p5prkj7lzfd1877wk11c9c73
+NarSize: 226560
+References: 7h7qgvs4kgzsn8a6rb273saxyqh4jxlz-hello-2.12.1 ld2r7kzw9v9h3d9h5z1x8hkfkz7bppwd-glibc-2.38
+Deriver: v9p8ilrr9mbjy0b8pj0w2kjz7kz3h4l1-hello-2.12.1.drv
+System: x86_64-linux
+Sig: cache.nixos.org-1:abc
+`
+)
+
+func TestNix32(t *testing.T) {
+	sum := sha256.Sum256(nil)
+	assert.Equal(t, "0mdqa9w1p6cmli6976v4wi0sw9r4p5prkj7lzfd1877wk11c9c73", Nix32(sum[:]))
+}
+
+func TestNormalizeHash(t *testing.T) {
+	want := "sha256:0mdqa9w1p6cmli6976v4wi0sw9r4p5prkj7lzfd1877wk11c9c73"
+	tests := []struct {
+		hash    string
+		wantErr bool
+	}{
+		{hash: want},
+		{hash: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{hash: "sha256-47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="},
+		{hash: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b85z", wantErr: true},
+		{hash: "sha256-47DEQpj8HBSa", wantErr: true},
+		{hash: "sha512:abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hash, func(t *testing.T) {
+			got, err := NormalizeHash(tt.hash)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidNarInfo)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestParseNarInfo(t *testing.T) {
+	i, err := ParseNarInfo([]byte(testNarInfo))
+	require.NoError(t, err)
+	assert.Equal(t, &NarInfo{
+		StorePath:   "/nix/store/7h7qgvs4kgzsn8a6rb273saxyqh4jxlz-hello-2.12.1",
+		URL:         "nar/1w1fff338fvdw53sqgamddn1b2xgds473pv6y13gizdbqjv4i5p3.nar.xz",
+		Compression: "xz",
+		FileHash:    "sha256:1w1fff338fvdw53sqgamddn1b2xgds473pv6y13gizdbqjv4i5p3",
+		FileSize:    50088,
+		NarHash:     "sha256:0mdqa9w1p6cmli6976v4wi0sw9r4p5prkj7lzfd1877wk11c9c73",
+		NarSize:     226560,
+		References:  []string{"7h7qgvs4kgzsn8a6rb273saxyqh4jxlz-hello-2.12.1", "ld2r7kzw9v9h3d9h5z1x8hkfkz7bppwd-glibc-2.38"},
+		Deriver:     "v9p8ilrr9mbjy0b8pj0w2kjz7kz3h4l1-hello-2.12.1.drv",
+		System:      "x86_64-linux",
+		Sigs:        []string{"cache.nixos.org-1:abc"},
+	}, i)
+	assert.Equal(t, testHash, i.Hash())
+	assert.Equal(t, testNarInfo, i.String())
+
+	fp, err := i.Fingerprint()
+	require.NoError(t, err)
+	assert.Equal(t, "1;/nix/store/7h7qgvs4kgzsn8a6rb273saxyqh4jxlz-hello-2.12.1;sha256:0mdqa9w1p6cmli6976v4wi0sw9r4p5prkj7lzfd1877wk11c9c73;226560;"+
+		"/nix/store/7h7qgvs4kgzsn8a6rb273saxyqh4jxlz-hello-2.12.1,/nix/store/ld2r7kzw9v9h3d9h5z1x8hkfkz7bppwd-glibc-2.38", fp)
+}
+
+func TestParseNarInfoErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		info string
+	}{
+		{
+			name: "invalid line",
+			info: testNarInfo + "invalid\n",
+		},
+		{
+			name: "invalid size",
+			info: strings.Replace(testNarInfo, "NarSize: 226560", "NarSize: big", 1),
+		},
+		{
+			name: "missing nar hash",
+			info: strings.Replace(testNarInfo, "NarHash: ", "Hash: ", 1),
+		},
+		{
+			name: "invalid store path",
+			info: strings.Replace(testNarInfo, "StorePath: /nix/store/7h7qgvs4kgzsn8a6rb273saxyqh4jxlz-", "StorePath: /nix/store/", 1),
+		},
+		{
+			name: "unsupported nar hash",
+			info: strings.Replace(testNarInfo, "NarHash: sha256:", "NarHash: md5:", 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseNarInfo([]byte(tt.info))
+			assert.ErrorIs(t, err, ErrInvalidNarInfo)
+		})
+	}
+}
+
+func TestNewNar(t *testing.T) {
+	sum := sha256.Sum256([]byte("nar"))
+	file := Nix32(sum[:]) + ".nar.xz"
+	pkg, err := NewNar(strings.NewReader("nar"), file)
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, KindNar, pkg.Kind)
+	assert.Equal(t, Nix32(sum[:]), pkg.Hash)
+	assert.Equal(t, "nar/"+file, pkg.Path())
+	assert.Equal(t, file, pkg.Name())
+	assert.Equal(t, int64(3), pkg.Size())
+
+	_, err = NewNar(strings.NewReader("other"), file)
+	assert.ErrorIs(t, err, ErrInvalidNar)
+	_, err = NewNar(strings.NewReader("nar"), "hello.nar")
+	assert.ErrorIs(t, err, ErrInvalidNar)
+}
+
+func TestNewNarInfo(t *testing.T) {
+	pkg, err := NewNarInfo(strings.NewReader(testNarInfo), testHash)
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, KindNarInfo, pkg.Kind)
+	assert.Equal(t, "narinfo/"+testHash+".narinfo", pkg.Path())
+	assert.Equal(t, "hello-2.12.1", pkg.Name())
+	assert.Equal(t, "x86_64-linux", pkg.Arch())
+	b, err := io.ReadAll(pkg)
+	require.NoError(t, err)
+	assert.Equal(t, testNarInfo, string(b))
+
+	_, err = NewNarInfo(strings.NewReader(testNarInfo), "ld2r7kzw9v9h3d9h5z1x8hkfkz7bppwd")
+	assert.True(t, errors.Is(err, ErrInvalidNarInfo), err)
+	_, err = NewNarInfo(strings.NewReader(testNarInfo), "hello")
+	assert.True(t, errors.Is(err, ErrInvalidNarInfo), err)
+}
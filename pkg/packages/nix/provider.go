@@ -0,0 +1,201 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nix
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	Name = "nix"
+
+	CacheInfoFile = "nix-cache-info"
+)
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// cacheInfo serves the binary cache configuration, it always exists so that nix does not try to upload it
+// https://nixos.org/manual/nix/stable/protocols/binary-cache.html#nix-cache-info
+func (p *provider) cacheInfo(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/x-nix-cache-info")
+		fmt.Fprint(w, "StoreDir: /nix/store\nWantMassQuery: 1\nPriority: 40\n")
+	}
+}
+
+// uploadNar stores a nar file. As the nar files are content addressed, an existing file is not uploaded again.
+func (p *provider) uploadNar(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		defer r.Body.Close()
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		file := mux.Vars(r)["file"]
+		if _, err := s.Stat(ctx, path.Join(NarDir, file)); err == nil {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusOK)
+			return
+		} else if !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		pkg, err := NewNar(r.Body, file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		logger.C(ctx).WithFields("filepath", pkg.Path()).Infof("uploading nar")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// uploadNarInfo stores a narinfo, which is uploaded by nix after the nar file it refers to
+func (p *provider) uploadNarInfo(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		defer r.Body.Close()
+		pkg, err := NewNarInfo(r.Body, mux.Vars(r)["hash"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		nar := strings.TrimPrefix(pkg.Info.URL, "/")
+		if !strings.HasPrefix(nar, NarDir+"/") {
+			http.Error(w, fmt.Sprintf("%s: unsupported nar url", pkg.Info.URL), http.StatusBadRequest)
+			return
+		}
+		if _, err := s.Stat(ctx, nar); err != nil {
+			if storage.IsNotFound(err) {
+				http.Error(w, fmt.Sprintf("%s: nar file not found", pkg.Info.URL), http.StatusBadRequest)
+				return
+			}
+			storage.Error(w, err)
+			return
+		}
+		logger.C(ctx).WithFields("name", pkg.Name(), "filepath", pkg.Path()).Infof("uploading narinfo")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func narPath(r *http.Request) string {
+	return path.Join(NarDir, mux.Vars(r)["file"])
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + CacheInfoFile,
+			Handler: p.cacheInfo,
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + RepositoryPublicKey,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return RepositoryPublicKey
+			}),
+		},
+		// nix checks the store paths existence with HEAD requests
+		{
+			Method: http.MethodHead,
+			Path:   "/{hash}" + NarInfoExt,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return mux.Vars(r)["hash"] + NarInfoExt
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/{hash}" + NarInfoExt,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return mux.Vars(r)["hash"] + NarInfoExt
+			}),
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/{hash}" + NarInfoExt,
+			Handler: p.uploadNarInfo,
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/{hash}" + NarInfoExt,
+			Handler: packages.Delete(func(r *http.Request) string {
+				return NarInfoPath(mux.Vars(r)["hash"])
+			}),
+		},
+		{
+			Method:  http.MethodHead,
+			Path:    "/" + NarDir + "/{file}",
+			Handler: packages.Pull(narPath),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + NarDir + "/{file}",
+			Handler: packages.Pull(narPath),
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/" + NarDir + "/{file}",
+			Handler: p.uploadNar,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/" + NarDir + "/{file}",
+			Handler: packages.Delete(narPath),
+		},
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nix
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/nixkey"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "nix"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return nixkey.GenerateKeypair("")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Index writes the {hash}.narinfo files signed with the repository key for the store paths whose nar file exists
+func (r *repo) Index(_ context.Context, priv string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	nars := make(map[string]struct{})
+	for _, v := range pkgs {
+		if v.Kind == KindNar {
+			nars[v.FilePath] = struct{}{}
+		}
+	}
+	name, _, _ := strings.Cut(priv, ":")
+	for _, v := range pkgs {
+		if v.Kind != KindNarInfo {
+			continue
+		}
+		if _, ok := nars[strings.TrimPrefix(v.Info.URL, "/")]; !ok {
+			continue
+		}
+		fp, err := v.Info.Fingerprint()
+		if err != nil {
+			return nil, err
+		}
+		sig, err := nixkey.Sign(priv, fp)
+		if err != nil {
+			return nil, err
+		}
+		info := *v.Info
+		// the repository signature replaces the one made by any key with the same name
+		info.Sigs = append(slices.Filter(info.Sigs, func(s string) bool {
+			return !strings.HasPrefix(s, name+":")
+		}), sig)
+		out = append(out, storage.NewFile(v.Hash+NarInfoExt, []byte(info.String())))
+	}
+	return out, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nix
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/crypt/nixkey"
+)
+
+func TestIndex(t *testing.T) {
+	priv, pub, err := (&repo{}).GenerateKeypair()
+	require.NoError(t, err)
+	name, _, _ := strings.Cut(pub, ":")
+
+	info, err := ParseNarInfo([]byte(testNarInfo))
+	require.NoError(t, err)
+	// a previous signature made by the repository key is replaced
+	info.Sigs = append(info.Sigs, name+":old")
+	orphan, err := ParseNarInfo([]byte(strings.NewReplacer(testHash, "ld2r7kzw9v9h3d9h5z1x8hkfkz7bppwd", "/1w1f", "/0w1f").Replace(testNarInfo)))
+	require.NoError(t, err)
+	out, err := (&repo{}).Index(context.Background(), priv,
+		&Package{Kind: KindNar, FilePath: info.URL},
+		&Package{Kind: KindNarInfo, Hash: testHash, Info: info, FilePath: NarInfoPath(testHash)},
+		// the nar file has not been uploaded yet
+		&Package{Kind: KindNarInfo, Hash: orphan.Hash(), Info: orphan, FilePath: NarInfoPath(orphan.Hash())},
+	)
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	assert.Equal(t, testHash+".narinfo", out[0].Path())
+	b, err := io.ReadAll(out[0])
+	require.NoError(t, err)
+
+	signed, err := ParseNarInfo(b)
+	require.NoError(t, err)
+	require.Len(t, signed.Sigs, 2)
+	assert.Equal(t, "cache.nixos.org-1:abc", signed.Sigs[0])
+	fp, err := signed.Fingerprint()
+	require.NoError(t, err)
+	assert.NoError(t, nixkey.Verify(pub, signed.Sigs[1], fp))
+	// the uploaded narinfo is left untouched
+	assert.Equal(t, []string{"cache.nixos.org-1:abc", name + ":old"}, info.Sigs)
+
+	out, err = (&repo{}).Index(context.Background(), priv)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
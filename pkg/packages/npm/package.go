@@ -0,0 +1,215 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+var (
+	ErrMissingManifest = errors.New("package.json is missing")
+	ErrInvalidName     = errors.New("package name is invalid")
+	ErrInvalidVersion  = errors.New("package version is invalid")
+	ErrInvalidTag      = errors.New("package tag is invalid")
+
+	// https://github.com/npm/validate-npm-package-name
+	namePattern = regexp.MustCompile(`\A(?:@[a-z0-9-*~][a-z0-9-*._~]*/)?[a-z0-9-~][a-z0-9-._~]*\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName    string          `json:"name"`
+	PkgVersion string          `json:"version"`
+	Tag        string          `json:"tag,omitempty"`
+	Manifest   json.RawMessage `json:"manifest"`
+	Published  time.Time       `json:"published"`
+
+	PkgSize  int64  `json:"size"`
+	FilePath string `json:"filePath"`
+
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+	SHA512 string `json:"sha512"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// Integrity returns the Subresource Integrity string of the tarball
+func (p *Package) Integrity() string {
+	b, _ := hex.DecodeString(p.SHA512)
+	return "sha512-" + base64.StdEncoding.EncodeToString(b)
+}
+
+// Filename returns the tarball name as generated by npm pack, e.g. "name-1.0.0.tgz"
+func (p *Package) Filename() string {
+	return Filename(p.PkgName, p.PkgVersion)
+}
+
+// Filename returns the tarball name of the given package version
+func Filename(name, version string) string {
+	return fmt.Sprintf("%s-%s.tgz", path.Base(name), version)
+}
+
+// ValidateName validates the package name, scoped or not
+func ValidateName(name string) error {
+	if len(name) > 214 || !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: %s", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// NewPackage parses a tarball as created by npm pack.
+// https://docs.npmjs.com/cli/v10/configuring-npm/package-json
+func NewPackage(r io.Reader, tag string) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	gzr, err := gzip.NewReader(io.NewSectionReader(reader, 0, reader.Size()))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gzr)
+	var manifest map[string]json.RawMessage
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrMissingManifest
+		}
+		if err != nil {
+			return nil, err
+		}
+		// the top level directory is usually "package" but may differ
+		name := strings.TrimPrefix(hd.Name, "./")
+		if hd.Typeflag != tar.TypeReg || strings.Count(name, "/") != 1 || path.Base(name) != "package.json" {
+			continue
+		}
+		if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+			return nil, fmt.Errorf("invalid package.json: %w", err)
+		}
+		break
+	}
+	var name, version string
+	if err := json.Unmarshal(manifest["name"], &name); err != nil {
+		return nil, ErrInvalidName
+	}
+	if err := json.Unmarshal(manifest["version"], &version); err != nil {
+		return nil, ErrInvalidVersion
+	}
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	v, err := semver.StrictNewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVersion, err)
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	// tags must not be valid version ranges
+	if _, err := semver.NewConstraint(tag); err == nil || strings.ContainsAny(tag, "/ ") {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTag, tag)
+	}
+	// the readme is not part of the index and would unnecessarily bloat the artifact's metadata
+	delete(manifest, "readme")
+	delete(manifest, "readmeFilename")
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &Package{
+		PkgName:    name,
+		PkgVersion: v.String(),
+		Tag:        tag,
+		Manifest:   b,
+		Published:  time.Now().UTC(),
+		PkgSize:    reader.Size(),
+		reader:     reader,
+	}
+	pkg.FilePath = path.Join(name, "-", pkg.Filename())
+	_, sha1, sha256, sha512 := reader.Sums()
+	pkg.SHA1 = hex.EncodeToString(sha1)
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	pkg.SHA512 = hex.EncodeToString(sha512)
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+// verify checks the tarball against the dist information sent by the npm client
+func (p *Package) verify(d Dist) error {
+	if d.Shasum != "" && !strings.EqualFold(d.Shasum, p.SHA1) {
+		return fmt.Errorf("shasum mismatch: expected %s, got %s", d.Shasum, p.SHA1)
+	}
+	if d.Integrity != "" && strings.HasPrefix(d.Integrity, "sha512-") && d.Integrity != p.Integrity() {
+		return fmt.Errorf("integrity mismatch: expected %s, got %s", d.Integrity, p.Integrity())
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTarball(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return b.Bytes()
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "example", valid: true},
+		{name: "example-pkg.js", valid: true},
+		{name: "@scope/example", valid: true},
+		{name: "Example"},
+		{name: ".example"},
+		{name: "_example"},
+		{name: "@scope/"},
+		{name: "scope/example"},
+		{name: "exa mple"},
+		{name: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateName(tt.name)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidName)
+			}
+		})
+	}
+}
+
+func TestNewPackage(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		tag      string
+		wantTag  string
+		wantPath string
+		wantErr  error
+	}{
+		{
+			name: "default tag",
+			files: map[string]string{
+				"package/index.js":     "module.exports = {}",
+				"package/package.json": `{"name":"example","version":"1.0.0","readme":"# example","readmeFilename":"README.md"}`,
+			},
+			wantTag:  LatestTag,
+			wantPath: "example/-/example-1.0.0.tgz",
+		},
+		{
+			name: "scoped with tag",
+			files: map[string]string{
+				"other/package.json": `{"name":"@scope/example","version":"2.0.0-beta.1"}`,
+			},
+			tag:      "beta",
+			wantTag:  "beta",
+			wantPath: "@scope/example/-/example-2.0.0-beta.1.tgz",
+		},
+		{
+			name: "nested manifest only",
+			files: map[string]string{
+				"package/lib/package.json": `{"name":"example","version":"1.0.0"}`,
+			},
+			wantErr: ErrMissingManifest,
+		},
+		{
+			name: "invalid name",
+			files: map[string]string{
+				"package/package.json": `{"name":"Example","version":"1.0.0"}`,
+			},
+			wantErr: ErrInvalidName,
+		},
+		{
+			name: "invalid version",
+			files: map[string]string{
+				"package/package.json": `{"name":"example","version":"1.0"}`,
+			},
+			wantErr: ErrInvalidVersion,
+		},
+		{
+			name: "range tag",
+			files: map[string]string{
+				"package/package.json": `{"name":"example","version":"1.0.0"}`,
+			},
+			tag:     "^1.0.0",
+			wantErr: ErrInvalidTag,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testTarball(t, tt.files)
+			pkg, err := NewPackage(bytes.NewReader(b), tt.tag)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, tt.wantTag, pkg.Tag)
+			assert.Equal(t, tt.wantPath, pkg.Path())
+			assert.Equal(t, int64(len(b)), pkg.Size())
+			sum := sha1.Sum(b)
+			assert.Equal(t, hex.EncodeToString(sum[:]), pkg.SHA1)
+			assert.NoError(t, pkg.verify(Dist{Shasum: pkg.SHA1, Integrity: pkg.Integrity()}))
+			assert.Error(t, pkg.verify(Dist{Shasum: "0000"}))
+
+			var m map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(pkg.Manifest, &m))
+			assert.NotContains(t, m, "readme")
+			assert.NotContains(t, m, "readmeFilename")
+
+			got, err := io.ReadAll(pkg)
+			require.NoError(t, err)
+			assert.Equal(t, b, got)
+		})
+	}
+}
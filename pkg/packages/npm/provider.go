@@ -0,0 +1,325 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "npm"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// publishRequest is the document sent by npm publish
+// https://github.com/npm/libnpmpublish
+type publishRequest struct {
+	Name     string            `json:"name"`
+	DistTags map[string]string `json:"dist-tags"`
+	Versions map[string]struct {
+		Dist Dist `json:"dist"`
+	} `json:"versions"`
+	Attachments map[string]struct {
+		Data string `json:"data"`
+	} `json:"_attachments"`
+}
+
+// packageName returns the full package name from the route variables,
+// scoped package names are sent url encoded by npm (@scope%2fname) and are decoded before routing
+func packageName(r *http.Request) string {
+	vars := mux.Vars(r)
+	if vars["scope"] == "" {
+		return vars["name"]
+	}
+	return vars["scope"] + "/" + vars["name"]
+}
+
+func tarballPath(r *http.Request) string {
+	return path.Join(packageName(r), "-", mux.Vars(r)["filename"])
+}
+
+// publish handles npm publish requests containing the package version manifest
+// and the base64 encoded tarball as an attachment
+func (p *provider) publish(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := packageName(r)
+		var req publishRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Name != name {
+			http.Error(w, fmt.Sprintf("package name mismatch: expected %s, got %s", name, req.Name), http.StatusBadRequest)
+			return
+		}
+		if len(req.Versions) != 1 || len(req.Attachments) != 1 || len(req.DistTags) > 1 {
+			http.Error(w, "exactly one version must be published", http.StatusBadRequest)
+			return
+		}
+		var tag, data string
+		for k := range req.DistTags {
+			tag = k
+		}
+		for _, v := range req.Attachments {
+			data = v.Data
+		}
+		pkg, err := NewPackage(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)), tag)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		if pkg.PkgName != name {
+			http.Error(w, fmt.Sprintf("package name mismatch: expected %s, got %s", name, pkg.PkgName), http.StatusBadRequest)
+			return
+		}
+		v, ok := req.Versions[pkg.PkgVersion]
+		if !ok {
+			http.Error(w, fmt.Sprintf("package version %s not found in manifest", pkg.PkgVersion), http.StatusBadRequest)
+			return
+		}
+		if err := pkg.verify(v.Dist); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		write(w, r, pkg)
+	}
+}
+
+// push handles the raw tarball uploads made by lkar
+func (p *provider) push(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var reader io.ReadCloser
+		if file, _, err := r.FormFile("file"); err == nil {
+			reader = file
+		} else {
+			reader = r.Body
+		}
+		defer reader.Close()
+		pkg, err := NewPackage(reader, r.URL.Query().Get("tag"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		write(w, r, pkg)
+	}
+}
+
+// write stores the package, published versions cannot be overwritten
+func write(w http.ResponseWriter, r *http.Request, pkg *Package) {
+	ctx := r.Context()
+	s := storage.FromContext(ctx)
+	if err := s.Init(ctx); err != nil {
+		storage.Error(w, err)
+		return
+	}
+	if _, err := s.Stat(ctx, pkg.Path()); err == nil {
+		http.Error(w, fmt.Sprintf("cannot publish over the previously published version %s", pkg.PkgVersion), http.StatusConflict)
+		return
+	} else if !storage.IsNotFound(err) {
+		storage.Error(w, err)
+		return
+	}
+	logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.Version(), "tag", pkg.Tag).Infof("publishing package")
+	if err := s.Write(ctx, pkg); err != nil {
+		storage.Error(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// packument serves the package document with the tarball urls resolved against the request url
+func (p *provider) packument(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		name := packageName(r)
+		doc, err := readPackument(ctx, name)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		base := fmt.Sprintf("%s://%s%s", packages.Scheme(r), r.Host, strings.TrimSuffix(r.URL.Path, "/"+name))
+		for k, v := range doc.Versions {
+			var d Dist
+			if err := json.Unmarshal(v["dist"], &d); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			d.Tarball = base + "/" + d.Tarball
+			if v["dist"], err = json.Marshal(d); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			doc.Versions[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(doc); err != nil {
+			logger.C(ctx).WithError(err).Error("failed to write packument")
+		}
+	}
+}
+
+// https://github.com/npm/registry/blob/master/docs/REGISTRY-API.md#get-package-dist-tags
+func (p *provider) distTags(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		doc, err := readPackument(ctx, packageName(r))
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(doc.DistTags); err != nil {
+			logger.C(ctx).WithError(err).Error("failed to write dist-tags")
+		}
+	}
+}
+
+// unpublish deletes all the versions of a package
+func (p *provider) unpublish(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		name := packageName(r)
+		s := storage.FromContext(ctx)
+		as, err := s.Artifacts(ctx)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		var paths []string
+		for _, v := range storage.MustAs[*Package](as) {
+			if v.PkgName == name {
+				paths = append(paths, v.Path())
+			}
+		}
+		if len(paths) == 0 {
+			http.Error(w, fmt.Sprintf("%s: package not found", name), http.StatusNotFound)
+			return
+		}
+		if err := s.Delete(ctx, paths...); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// update acknowledges the packument update sent by npm unpublish before deleting a single version's tarball:
+// the packument is generated from the remaining tarballs, so there is nothing to do
+func (p *provider) update(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := readPackument(r.Context(), packageName(r)); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func readPackument(ctx context.Context, name string) (*Packument, error) {
+	rc, err := storage.FromContext(ctx).Open(ctx, PackumentPath(name))
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var doc Packument
+	if err := json.NewDecoder(rc).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (p *provider) Routes() []*packages.Route {
+	routes := []*packages.Route{
+		{
+			Method:  http.MethodPut,
+			Path:    "/push",
+			Handler: p.push,
+		},
+	}
+	// scoped packages routes must be registered first as the unscoped ones would match the scope as part of the repository name
+	for _, v := range []string{"/{scope:@[^/]+}/{name}", "/{name}"} {
+		routes = append(routes,
+			&packages.Route{
+				Method:  http.MethodGet,
+				Path:    "/-/package" + v + "/dist-tags",
+				Handler: p.distTags,
+			},
+			&packages.Route{
+				Method:  http.MethodGet,
+				Path:    v + "/-/{filename}",
+				Handler: packages.Pull(tarballPath),
+			},
+			&packages.Route{
+				Method:  http.MethodDelete,
+				Path:    v + "/-/{filename}",
+				Handler: packages.Delete(tarballPath),
+			},
+			&packages.Route{
+				Method:  http.MethodDelete,
+				Path:    v + "/-/{filename}/-rev/{rev}",
+				Handler: packages.Delete(tarballPath),
+			},
+			&packages.Route{
+				Method:  http.MethodPut,
+				Path:    v + "/-rev/{rev}",
+				Handler: p.update,
+			},
+			&packages.Route{
+				Method:  http.MethodDelete,
+				Path:    v + "/-rev/{rev}",
+				Handler: p.unpublish,
+			},
+			&packages.Route{
+				Method:  http.MethodPut,
+				Path:    v,
+				Handler: p.publish,
+			},
+			&packages.Route{
+				Method:  http.MethodGet,
+				Path:    v,
+				Handler: p.packument,
+			},
+		)
+	}
+	return routes
+}
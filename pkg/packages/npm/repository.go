@@ -0,0 +1,194 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	PackumentFile = "package.json"
+
+	LatestTag = "latest"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "npm"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "npm Registry", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// https://github.com/npm/registry/blob/master/docs/REGISTRY-API.md#package
+
+type Dist struct {
+	// Tarball is stored relative to the repository root and made absolute when served
+	Tarball   string `json:"tarball"`
+	Shasum    string `json:"shasum"`
+	Integrity string `json:"integrity,omitempty"`
+}
+
+type Packument struct {
+	ID       string                                `json:"_id"`
+	Rev      string                                `json:"_rev"`
+	Name     string                                `json:"name"`
+	DistTags map[string]string                     `json:"dist-tags"`
+	Versions map[string]map[string]json.RawMessage `json:"versions"`
+	Time     map[string]time.Time                  `json:"time"`
+}
+
+// PackumentPath returns the path of the package document in the repository
+func PackumentPath(name string) string {
+	return path.Join(name, PackumentFile)
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	names := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.PkgName
+	}))
+	sort.Strings(names)
+	for _, name := range names {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.PkgName == name
+		})
+		doc, err := buildPackument(name, pkgs)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, storage.NewFile(PackumentPath(name), b))
+	}
+	return out, nil
+}
+
+func buildPackument(name string, pkgs []*Package) (*Packument, error) {
+	// oldest first so that the most recently published version wins the tags
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Published.Before(pkgs[j].Published)
+	})
+	doc := &Packument{
+		ID:       name,
+		Name:     name,
+		DistTags: make(map[string]string),
+		Versions: make(map[string]map[string]json.RawMessage),
+		Time:     make(map[string]time.Time),
+	}
+	h := sha256.New()
+	for _, p := range pkgs {
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(p.Manifest, &m); err != nil {
+			return nil, fmt.Errorf("%s@%s: invalid manifest: %w", p.PkgName, p.PkgVersion, err)
+		}
+		id, _ := json.Marshal(p.PkgName + "@" + p.PkgVersion)
+		m["_id"] = id
+		dist, err := json.Marshal(Dist{
+			Tarball:   p.Path(),
+			Shasum:    p.SHA1,
+			Integrity: p.Integrity(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		m["dist"] = dist
+		doc.Versions[p.PkgVersion] = m
+		doc.Time[p.PkgVersion] = p.Published
+		if p.Tag != "" {
+			doc.DistTags[p.Tag] = p.PkgVersion
+		}
+		if _, ok := doc.Time["created"]; !ok {
+			doc.Time["created"] = p.Published
+		}
+		doc.Time["modified"] = p.Published
+		h.Write([]byte(p.SHA256))
+	}
+	if _, ok := doc.DistTags[LatestTag]; !ok {
+		doc.DistTags[LatestTag] = latest(pkgs)
+	}
+	doc.Rev = fmt.Sprintf("%d-%s", len(pkgs), hex.EncodeToString(h.Sum(nil))[:32])
+	return doc, nil
+}
+
+// latest returns the highest stable version, or the highest version if there are only pre-releases
+func latest(pkgs []*Package) string {
+	var best, pre *semver.Version
+	for _, p := range pkgs {
+		v, err := semver.NewVersion(p.PkgVersion)
+		if err != nil {
+			continue
+		}
+		if v.Prerelease() == "" {
+			if best == nil || v.GreaterThan(best) {
+				best = v
+			}
+		} else if pre == nil || v.GreaterThan(pre) {
+			pre = v
+		}
+	}
+	if best == nil {
+		best = pre
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Original()
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package npm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkg := func(name, version, tag string, published time.Time) storage.Artifact {
+		return &Package{
+			PkgName:    name,
+			PkgVersion: version,
+			Tag:        tag,
+			Manifest:   json.RawMessage(`{"name":"` + name + `","version":"` + version + `"}`),
+			Published:  published,
+			FilePath:   name + "/-/" + Filename(name, version),
+			SHA1:       "sha1-" + version,
+			SHA256:     "sha256-" + version,
+			SHA512:     "00",
+		}
+	}
+	out, err := (&repo{}).Index(context.Background(), "",
+		pkg("example", "1.1.0-rc.1", "next", now.Add(2*time.Hour)),
+		pkg("example", "1.0.0", "", now),
+		pkg("example", "0.9.0", "", now.Add(time.Hour)),
+		pkg("@scope/other", "2.0.0-beta.1", "beta", now),
+	)
+	require.NoError(t, err)
+	docs := make(map[string]*Packument)
+	for _, v := range out {
+		var doc Packument
+		require.NoError(t, json.NewDecoder(v).Decode(&doc))
+		docs[v.Path()] = &doc
+	}
+	require.Len(t, docs, 2)
+
+	doc := docs[PackumentPath("example")]
+	require.NotNil(t, doc)
+	assert.Equal(t, "example", doc.ID)
+	assert.Equal(t, "example", doc.Name)
+	assert.Regexp(t, `^3-[0-9a-f]{32}$`, doc.Rev)
+	// without an explicit latest tag, the highest stable version wins over the most recent
+	assert.Equal(t, map[string]string{LatestTag: "1.0.0", "next": "1.1.0-rc.1"}, doc.DistTags)
+	assert.Len(t, doc.Versions, 3)
+	v := doc.Versions["1.0.0"]
+	var id string
+	require.NoError(t, json.Unmarshal(v["_id"], &id))
+	assert.Equal(t, "example@1.0.0", id)
+	var dist Dist
+	require.NoError(t, json.Unmarshal(v["dist"], &dist))
+	assert.Equal(t, Dist{Tarball: "example/-/example-1.0.0.tgz", Shasum: "sha1-1.0.0", Integrity: "sha512-AA=="}, dist)
+	assert.True(t, now.Equal(doc.Time["created"]))
+	assert.True(t, now.Add(2*time.Hour).Equal(doc.Time["modified"]))
+	assert.True(t, now.Add(time.Hour).Equal(doc.Time["0.9.0"]))
+
+	// only pre-releases: the highest one is the latest
+	doc = docs[PackumentPath("@scope/other")]
+	require.NotNil(t, doc)
+	assert.Equal(t, map[string]string{LatestTag: "2.0.0-beta.1", "beta": "2.0.0-beta.1"}, doc.DistTags)
+}
+
+func TestIndexLatestTag(t *testing.T) {
+	out, err := (&repo{}).Index(context.Background(), "",
+		&Package{PkgName: "example", PkgVersion: "2.0.0", Manifest: json.RawMessage(`{}`), SHA512: "00"},
+		&Package{PkgName: "example", PkgVersion: "1.0.0", Tag: LatestTag, Manifest: json.RawMessage(`{}`), SHA512: "00"},
+	)
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	b, err := io.ReadAll(out[0])
+	require.NoError(t, err)
+	var doc Packument
+	require.NoError(t, json.Unmarshal(b, &doc))
+	assert.Equal(t, map[string]string{LatestTag: "1.0.0"}, doc.DistTags)
+}
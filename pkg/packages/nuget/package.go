@@ -0,0 +1,341 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nuget
+
+import (
+	"archive/zip"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+	"go.linka.cloud/artifact-registry/pkg/validation"
+)
+
+const FlatContainer = "v3-flatcontainer"
+
+var (
+	ErrMissingNuspec  = errors.New("nuspec file is missing")
+	ErrInvalidID      = errors.New("package id is invalid")
+	ErrInvalidVersion = errors.New("package version is invalid")
+
+	idPattern = regexp.MustCompile(`\A\w+(?:[_.-]\w+)*\z`)
+	// https://learn.microsoft.com/en-us/nuget/concepts/package-versioning
+	versionPattern = regexp.MustCompile(`\A(\d+)\.(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	ID string `json:"id"`
+	// PkgVersion is the normalized version
+	PkgVersion string    `json:"version"`
+	Metadata   *Metadata `json:"metadata"`
+	Published  time.Time `json:"published"`
+
+	PkgSize  int64  `json:"size"`
+	FilePath string `json:"filePath"`
+
+	SHA256 string `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+type Metadata struct {
+	Title                    string            `json:"title,omitempty"`
+	Authors                  string            `json:"authors,omitempty"`
+	Description              string            `json:"description,omitempty"`
+	Summary                  string            `json:"summary,omitempty"`
+	Tags                     string            `json:"tags,omitempty"`
+	ProjectURL               string            `json:"projectUrl,omitempty"`
+	LicenseURL               string            `json:"licenseUrl,omitempty"`
+	LicenseExpression        string            `json:"licenseExpression,omitempty"`
+	IconURL                  string            `json:"iconUrl,omitempty"`
+	RequireLicenseAcceptance bool              `json:"requireLicenseAcceptance,omitempty"`
+	DependencyGroups         []DependencyGroup `json:"dependencyGroups,omitempty"`
+}
+
+type DependencyGroup struct {
+	TargetFramework string       `json:"targetFramework,omitempty"`
+	Dependencies    []Dependency `json:"dependencies,omitempty"`
+}
+
+type Dependency struct {
+	ID    string `json:"id"`
+	Range string `json:"range,omitempty"`
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.ID
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return ""
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// PackagePath returns the flat container path of the package, e.g. v3-flatcontainer/id/1.0.0/id.1.0.0.nupkg
+func PackagePath(id, version string) string {
+	id, version = strings.ToLower(id), strings.ToLower(version)
+	return path.Join(FlatContainer, id, version, fmt.Sprintf("%s.%s.nupkg", id, version))
+}
+
+// NormalizeVersion returns the normalized form of a version: leading zeros and build metadata are removed,
+// as is the fourth number if it is zero.
+// https://learn.microsoft.com/en-us/nuget/concepts/package-versioning#normalized-version-numbers
+func NormalizeVersion(v string) (string, error) {
+	m := versionPattern.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidVersion, v)
+	}
+	var parts []string
+	for i, v := range m[1:5] {
+		if v == "" {
+			v = "0"
+		}
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrInvalidVersion, v)
+		}
+		if i == 3 && n == 0 {
+			continue
+		}
+		parts = append(parts, strconv.FormatUint(n, 10))
+	}
+	out := strings.Join(parts, ".")
+	if m[5] != "" {
+		out += "-" + m[5]
+	}
+	return out, nil
+}
+
+// https://learn.microsoft.com/en-us/nuget/reference/nuspec
+type nuspec struct {
+	Metadata struct {
+		ID                       string `xml:"id"`
+		Version                  string `xml:"version"`
+		Title                    string `xml:"title"`
+		Authors                  string `xml:"authors"`
+		Description              string `xml:"description"`
+		Summary                  string `xml:"summary"`
+		Tags                     string `xml:"tags"`
+		ProjectURL               string `xml:"projectUrl"`
+		LicenseURL               string `xml:"licenseUrl"`
+		IconURL                  string `xml:"iconUrl"`
+		RequireLicenseAcceptance bool   `xml:"requireLicenseAcceptance"`
+		License                  struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"license"`
+		Dependencies struct {
+			Group []struct {
+				TargetFramework string `xml:"targetFramework,attr"`
+				Dependency      []struct {
+					ID      string `xml:"id,attr"`
+					Version string `xml:"version,attr"`
+				} `xml:"dependency"`
+			} `xml:"group"`
+			Dependency []struct {
+				ID      string `xml:"id,attr"`
+				Version string `xml:"version,attr"`
+			} `xml:"dependency"`
+		} `xml:"dependencies"`
+	} `xml:"metadata"`
+}
+
+// NewPackage parses a .nupkg file and its .nuspec manifest
+func NewPackage(r io.Reader) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := OpenNuspec(reader, reader.Size())
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var spec nuspec
+	if err := xml.NewDecoder(rc).Decode(&spec); err != nil {
+		return nil, err
+	}
+	m := spec.Metadata
+	if !idPattern.MatchString(m.ID) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidID, m.ID)
+	}
+	v, err := NormalizeVersion(m.Version)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &Package{
+		ID:         m.ID,
+		PkgVersion: v,
+		Metadata: &Metadata{
+			Title:                    m.Title,
+			Authors:                  m.Authors,
+			Description:              m.Description,
+			Summary:                  m.Summary,
+			Tags:                     m.Tags,
+			RequireLicenseAcceptance: m.RequireLicenseAcceptance,
+		},
+		Published: time.Now().UTC(),
+		FilePath:  PackagePath(m.ID, v),
+		PkgSize:   reader.Size(),
+		reader:    reader,
+	}
+	if validation.IsValidURL(m.ProjectURL) {
+		pkg.Metadata.ProjectURL = m.ProjectURL
+	}
+	if validation.IsValidURL(m.LicenseURL) {
+		pkg.Metadata.LicenseURL = m.LicenseURL
+	}
+	if validation.IsValidURL(m.IconURL) {
+		pkg.Metadata.IconURL = m.IconURL
+	}
+	if m.License.Type == "expression" {
+		pkg.Metadata.LicenseExpression = strings.TrimSpace(m.License.Value)
+	}
+	for _, g := range m.Dependencies.Group {
+		dg := DependencyGroup{TargetFramework: g.TargetFramework}
+		for _, d := range g.Dependency {
+			dg.Dependencies = append(dg.Dependencies, Dependency{ID: d.ID, Range: d.Version})
+		}
+		pkg.Metadata.DependencyGroups = append(pkg.Metadata.DependencyGroups, dg)
+	}
+	if len(m.Dependencies.Dependency) != 0 {
+		var dg DependencyGroup
+		for _, d := range m.Dependencies.Dependency {
+			dg.Dependencies = append(dg.Dependencies, Dependency{ID: d.ID, Range: d.Version})
+		}
+		pkg.Metadata.DependencyGroups = append(pkg.Metadata.DependencyGroups, dg)
+	}
+	_, _, sha256, _ := reader.Sums()
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+// OpenNuspec opens the .nuspec file located at the root of the package
+func OpenNuspec(r io.ReaderAt, size int64) (io.ReadCloser, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range zr.File {
+		if strings.Contains(v.Name, "/") || !strings.HasSuffix(strings.ToLower(v.Name), ".nuspec") {
+			continue
+		}
+		return v.Open()
+	}
+	return nil, ErrMissingNuspec
+}
+
+// CompareVersions compares two normalized versions following the SemVer 2.0 precedence rules,
+// release labels being compared case-insensitively
+func CompareVersions(a, b string) int {
+	a, apre, _ := strings.Cut(a, "-")
+	b, bpre, _ := strings.Cut(b, "-")
+	an, bn := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < 4; i++ {
+		x, y := uint64(0), uint64(0)
+		if i < len(an) {
+			x, _ = strconv.ParseUint(an[i], 10, 64)
+		}
+		if i < len(bn) {
+			y, _ = strconv.ParseUint(bn[i], 10, 64)
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case apre == bpre:
+		return 0
+	case apre == "":
+		return 1
+	case bpre == "":
+		return -1
+	}
+	al, bl := strings.Split(strings.ToLower(apre), "."), strings.Split(strings.ToLower(bpre), ".")
+	for i := 0; i < len(al) && i < len(bl); i++ {
+		x, xerr := strconv.ParseUint(al[i], 10, 64)
+		y, yerr := strconv.ParseUint(bl[i], 10, 64)
+		switch {
+		case xerr == nil && yerr == nil:
+			if x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+		case xerr == nil:
+			return -1
+		case yerr == nil:
+			return 1
+		default:
+			if c := strings.Compare(al[i], bl[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(al) < len(bl):
+		return -1
+	case len(al) > len(bl):
+		return 1
+	}
+	return 0
+}
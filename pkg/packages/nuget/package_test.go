@@ -0,0 +1,165 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nuget
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNuspec = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://schemas.microsoft.com/packaging/2013/05/nuspec.xsd">
+  <metadata>
+    <id>Example.Package</id>
+    <version>1.02.0.0-Beta+build.1</version>
+    <title>Example</title>
+    <authors>John Doe</authors>
+    <description>An example package</description>
+    <tags>example test</tags>
+    <projectUrl>https://example.org</projectUrl>
+    <iconUrl>not a url</iconUrl>
+    <license type="expression">MIT</license>
+    <dependencies>
+      <group targetFramework="net8.0">
+        <dependency id="Newtonsoft.Json" version="[13.0.1, )" />
+      </group>
+      <dependency id="Other" version="1.0.0" />
+    </dependencies>
+  </metadata>
+</package>`
+
+func testNupkg(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		wantErr bool
+	}{
+		{version: "1.0", want: "1.0.0"},
+		{version: "1.00.01", want: "1.0.1"},
+		{version: "1.0.0.0", want: "1.0.0"},
+		{version: "1.0.0.4", want: "1.0.0.4"},
+		{version: "1.0.0-Beta.1+build", want: "1.0.0-Beta.1"},
+		{version: " 2.1.0 ", want: "2.1.0"},
+		{version: "1", wantErr: true},
+		{version: "1.0.0-", wantErr: true},
+		{version: "v1.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := NormalizeVersion(tt.version)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidVersion)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0.0", b: "1.0.0", want: 0},
+		{a: "1.0.0", b: "1.0.0.1", want: -1},
+		{a: "1.2.0", b: "1.10.0", want: -1},
+		{a: "1.0.0-beta", b: "1.0.0", want: -1},
+		{a: "1.0.0-Beta", b: "1.0.0-beta", want: 0},
+		{a: "1.0.0-beta.2", b: "1.0.0-beta.10", want: -1},
+		{a: "1.0.0-beta.1", b: "1.0.0-beta.a", want: -1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, CompareVersions(tt.a, tt.b))
+			assert.Equal(t, -tt.want, CompareVersions(tt.b, tt.a))
+		})
+	}
+}
+
+func TestNewPackage(t *testing.T) {
+	pkg, err := NewPackage(bytes.NewReader(testNupkg(t, map[string]string{
+		"Example.Package.nuspec":   testNuspec,
+		"lib/net8.0/Example.dll":   "",
+		"package/other/foo.nuspec": "<package/>",
+	})))
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, "Example.Package", pkg.Name())
+	assert.Equal(t, "1.2.0-Beta", pkg.Version())
+	assert.Equal(t, "v3-flatcontainer/example.package/1.2.0-beta/example.package.1.2.0-beta.nupkg", pkg.Path())
+	assert.Equal(t, &Metadata{
+		Title:             "Example",
+		Authors:           "John Doe",
+		Description:       "An example package",
+		Tags:              "example test",
+		ProjectURL:        "https://example.org",
+		LicenseExpression: "MIT",
+		DependencyGroups: []DependencyGroup{
+			{TargetFramework: "net8.0", Dependencies: []Dependency{{ID: "Newtonsoft.Json", Range: "[13.0.1, )"}}},
+			{Dependencies: []Dependency{{ID: "Other", Range: "1.0.0"}}},
+		},
+	}, pkg.Metadata)
+	assert.Len(t, pkg.SHA256, 64)
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr error
+	}{
+		{
+			name:    "missing nuspec",
+			files:   map[string]string{"lib/example.nuspec": testNuspec},
+			wantErr: ErrMissingNuspec,
+		},
+		{
+			name:    "invalid id",
+			files:   map[string]string{"example.nuspec": `<package><metadata><id>../example</id><version>1.0.0</version></metadata></package>`},
+			wantErr: ErrInvalidID,
+		},
+		{
+			name:    "invalid version",
+			files:   map[string]string{"example.nuspec": `<package><metadata><id>example</id><version>latest</version></metadata></package>`},
+			wantErr: ErrInvalidVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(testNupkg(t, tt.files)))
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nuget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "nuget"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// https://learn.microsoft.com/en-us/nuget/api/service-index
+type ServiceIndex struct {
+	Version   string     `json:"version"`
+	Resources []Resource `json:"resources"`
+}
+
+type Resource struct {
+	ID   string `json:"@id"`
+	Type string `json:"@type"`
+}
+
+// baseURL returns the repository url from the request url and the route path
+func baseURL(r *http.Request, suffix string) string {
+	return fmt.Sprintf("%s://%s%s", packages.Scheme(r), r.Host, strings.TrimSuffix(r.URL.Path, suffix))
+}
+
+func (p *provider) serviceIndex(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		base := baseURL(r, "/v3/index.json")
+		i := ServiceIndex{Version: "3.0.0"}
+		for typ, u := range map[string][]string{
+			"/api/v2/package":         {"PackagePublish/2.0.0"},
+			"/" + FlatContainer + "/": {"PackageBaseAddress/3.0.0"},
+			"/" + Registration + "/": {
+				"RegistrationsBaseUrl",
+				"RegistrationsBaseUrl/3.0.0-rc",
+				"RegistrationsBaseUrl/3.0.0-beta",
+				"RegistrationsBaseUrl/3.6.0",
+			},
+			"/v3/search": {
+				"SearchQueryService",
+				"SearchQueryService/3.0.0-rc",
+				"SearchQueryService/3.0.0-beta",
+			},
+		} {
+			for _, v := range u {
+				i.Resources = append(i.Resources, Resource{ID: base + typ, Type: v})
+			}
+		}
+		sort.Slice(i.Resources, func(a, b int) bool {
+			return i.Resources[a].Type < i.Resources[b].Type
+		})
+		writeJSON(r.Context(), w, i)
+	}
+}
+
+// publish handles the multipart upload used by dotnet nuget push as well as raw uploads
+// https://learn.microsoft.com/en-us/nuget/api/package-publish-resource#push-a-package
+func (p *provider) publish(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var reader io.Reader = r.Body
+		if mr, err := r.MultipartReader(); err == nil {
+			part, err := mr.NextPart()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer part.Close()
+			reader = part
+		}
+		pkg, err := NewPackage(reader)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		if _, err := s.Stat(ctx, pkg.Path()); err == nil {
+			http.Error(w, fmt.Sprintf("%s %s already exists", pkg.ID, pkg.PkgVersion), http.StatusConflict)
+			return
+		} else if !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.Version()).Infof("uploading package")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// download serves the package content, the .nuspec file is extracted from the package
+// https://learn.microsoft.com/en-us/nuget/api/package-base-address-resource
+func (p *provider) download(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id, version, file := strings.ToLower(mux.Vars(r)["id"]), strings.ToLower(mux.Vars(r)["version"]), strings.ToLower(mux.Vars(r)["file"])
+		s := storage.FromContext(ctx)
+		if file != id+".nuspec" {
+			if err := s.ServeFile(w, r, path.Join(FlatContainer, id, version, file)); err != nil {
+				storage.Error(w, err)
+			}
+			return
+		}
+		rc, err := s.Open(ctx, PackagePath(id, version))
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		defer rc.Close()
+		b, err := buffer.CreateHashedBufferFromReader(rc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer b.Close()
+		spec, err := OpenNuspec(b, b.Size())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer spec.Close()
+		w.Header().Set("Content-Type", "application/xml")
+		if _, err := io.Copy(w, spec); err != nil {
+			logger.C(ctx).WithError(err).Error("failed to write nuspec")
+		}
+	}
+}
+
+func (p *provider) registration(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := mux.Vars(r)["id"]
+		i, err := readRegistration(ctx, id)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		i.Resolve(baseURL(r, path.Join("/", Registration, id, IndexFile)))
+		writeJSON(ctx, w, i)
+	}
+}
+
+// registrationLeaf serves the leaf referenced by the registration index and the search results,
+// it is read from the registration index where all the leaves are inlined
+// https://learn.microsoft.com/en-us/nuget/api/registration-base-url-resource#registration-leaf
+func (p *provider) registrationLeaf(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id, version := mux.Vars(r)["id"], strings.ToLower(mux.Vars(r)["version"])
+		i, err := readRegistration(ctx, id)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		for _, page := range i.Items {
+			for _, v := range page.Items {
+				if strings.ToLower(v.CatalogEntry.Version) != version {
+					continue
+				}
+				v.Resolve(baseURL(r, path.Join("/", Registration, id, mux.Vars(r)["version"]+".json")))
+				writeJSON(ctx, w, v)
+				return
+			}
+		}
+		http.Error(w, fmt.Sprintf("%s %s: not found", id, version), http.StatusNotFound)
+	}
+}
+
+// https://learn.microsoft.com/en-us/nuget/api/search-query-service-resource
+type SearchResponse struct {
+	TotalHits int            `json:"totalHits"`
+	Data      []SearchResult `json:"data"`
+}
+
+type SearchResult struct {
+	ID           string          `json:"@id"`
+	Type         string          `json:"@type"`
+	Registration string          `json:"registration"`
+	PackageID    string          `json:"id"`
+	Version      string          `json:"version"`
+	Description  string          `json:"description,omitempty"`
+	Summary      string          `json:"summary,omitempty"`
+	Title        string          `json:"title,omitempty"`
+	Authors      []string        `json:"authors,omitempty"`
+	Tags         []string        `json:"tags,omitempty"`
+	ProjectURL   string          `json:"projectUrl,omitempty"`
+	LicenseURL   string          `json:"licenseUrl,omitempty"`
+	IconURL      string          `json:"iconUrl,omitempty"`
+	Versions     []SearchVersion `json:"versions"`
+}
+
+type SearchVersion struct {
+	ID        string `json:"@id"`
+	Version   string `json:"version"`
+	Downloads int    `json:"downloads"`
+}
+
+func (p *provider) search(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		base := baseURL(r, "/v3/search")
+		q := strings.ToLower(r.URL.Query().Get("q"))
+		prerelease, _ := strconv.ParseBool(r.URL.Query().Get("prerelease"))
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		take, err := strconv.Atoi(r.URL.Query().Get("take"))
+		if err != nil || take <= 0 {
+			take = 20
+		}
+		as, err := storage.FromContext(ctx).Artifacts(ctx)
+		if err != nil && !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		pkgs := storage.MustAs[*Package](as)
+		sort.Slice(pkgs, func(i, j int) bool {
+			if a, b := strings.ToLower(pkgs[i].ID), strings.ToLower(pkgs[j].ID); a != b {
+				return a < b
+			}
+			return CompareVersions(pkgs[i].PkgVersion, pkgs[j].PkgVersion) < 0
+		})
+		res := SearchResponse{Data: []SearchResult{}}
+		var cur *SearchResult
+		for _, v := range pkgs {
+			if !prerelease && strings.Contains(v.PkgVersion, "-") {
+				continue
+			}
+			m := v.Metadata
+			if m == nil {
+				m = &Metadata{}
+			}
+			if q != "" && !strings.Contains(strings.ToLower(v.ID), q) && !strings.Contains(strings.ToLower(m.Title), q) && !strings.Contains(strings.ToLower(m.Tags), q) {
+				continue
+			}
+			reg := base + "/" + RegistrationPath(v.ID)
+			if cur == nil || !strings.EqualFold(cur.PackageID, v.ID) {
+				res.Data = append(res.Data, SearchResult{ID: reg, Type: "Package", Registration: reg})
+				cur = &res.Data[len(res.Data)-1]
+			}
+			// the last version is the latest one
+			cur.PackageID = v.ID
+			cur.Version = v.PkgVersion
+			cur.Description = m.Description
+			cur.Summary = m.Summary
+			cur.Title = m.Title
+			cur.Authors = strings.Split(m.Authors, ",")
+			cur.Tags = strings.Fields(m.Tags)
+			cur.ProjectURL = m.ProjectURL
+			cur.LicenseURL = m.LicenseURL
+			cur.IconURL = m.IconURL
+			cur.Versions = append(cur.Versions, SearchVersion{
+				ID:      base + "/" + path.Join(path.Dir(RegistrationPath(v.ID)), strings.ToLower(v.PkgVersion)+".json"),
+				Version: v.PkgVersion,
+			})
+		}
+		res.TotalHits = len(res.Data)
+		if skip > len(res.Data) {
+			skip = len(res.Data)
+		}
+		res.Data = res.Data[skip:min(skip+take, len(res.Data))]
+		writeJSON(ctx, w, res)
+	}
+}
+
+func readRegistration(ctx context.Context, id string) (*RegistrationIndex, error) {
+	rc, err := storage.FromContext(ctx).Open(ctx, RegistrationPath(id))
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var i RegistrationIndex
+	if err := json.NewDecoder(rc).Decode(&i); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func writeJSON(ctx context.Context, w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.C(ctx).WithError(err).Error("failed to write response")
+	}
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/v3/index.json",
+			Handler: p.serviceIndex,
+		},
+		{
+			Method:  http.MethodPut,
+			Path:    "/api/v2/package",
+			Handler: p.publish,
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/api/v2/package/{id}/{version}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				v, err := NormalizeVersion(mux.Vars(r)["version"])
+				if err != nil {
+					v = mux.Vars(r)["version"]
+				}
+				return PackagePath(mux.Vars(r)["id"], v)
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + FlatContainer + "/{id}/" + IndexFile,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return VersionsPath(mux.Vars(r)["id"])
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + FlatContainer + "/{id}/{version}/{file}",
+			Handler: p.download,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + Registration + "/{id}/" + IndexFile,
+			Handler: p.registration,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + Registration + "/{id}/{version}.json",
+			Handler: p.registrationLeaf,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/v3/search",
+			Handler: p.search,
+		},
+	}
+}
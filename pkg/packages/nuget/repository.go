@@ -0,0 +1,229 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nuget
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	Registration = "v3/registration"
+	IndexFile    = "index.json"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "nuget"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "NuGet Feed", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// https://learn.microsoft.com/en-us/nuget/api/package-base-address-resource#enumerate-package-versions
+type VersionsIndex struct {
+	Versions []string `json:"versions"`
+}
+
+// The registration documents urls are stored relative to the repository root
+// and resolved against the request url when served.
+// https://learn.microsoft.com/en-us/nuget/api/registration-base-url-resource
+
+type RegistrationIndex struct {
+	ID    string             `json:"@id"`
+	Count int                `json:"count"`
+	Items []RegistrationPage `json:"items"`
+}
+
+type RegistrationPage struct {
+	ID    string             `json:"@id"`
+	Count int                `json:"count"`
+	Items []RegistrationLeaf `json:"items"`
+	Lower string             `json:"lower"`
+	Upper string             `json:"upper"`
+}
+
+type RegistrationLeaf struct {
+	ID             string       `json:"@id"`
+	CatalogEntry   CatalogEntry `json:"catalogEntry"`
+	PackageContent string       `json:"packageContent"`
+	Registration   string       `json:"registration,omitempty"`
+}
+
+type CatalogEntry struct {
+	ID                       string            `json:"@id"`
+	PackageID                string            `json:"id"`
+	Version                  string            `json:"version"`
+	Title                    string            `json:"title,omitempty"`
+	Authors                  string            `json:"authors,omitempty"`
+	Description              string            `json:"description,omitempty"`
+	Summary                  string            `json:"summary,omitempty"`
+	Tags                     []string          `json:"tags,omitempty"`
+	ProjectURL               string            `json:"projectUrl,omitempty"`
+	LicenseURL               string            `json:"licenseUrl,omitempty"`
+	LicenseExpression        string            `json:"licenseExpression,omitempty"`
+	IconURL                  string            `json:"iconUrl,omitempty"`
+	RequireLicenseAcceptance bool              `json:"requireLicenseAcceptance"`
+	Listed                   bool              `json:"listed"`
+	Published                time.Time         `json:"published"`
+	PackageContent           string            `json:"packageContent"`
+	DependencyGroups         []DependencyGroup `json:"dependencyGroups,omitempty"`
+}
+
+// Resolve makes the document urls absolute
+func (i *RegistrationIndex) Resolve(base string) {
+	i.ID = base + "/" + i.ID
+	for j := range i.Items {
+		p := &i.Items[j]
+		p.ID = base + "/" + p.ID
+		for k := range p.Items {
+			p.Items[k].Resolve(base)
+		}
+	}
+}
+
+// Resolve makes the leaf urls absolute
+func (l *RegistrationLeaf) Resolve(base string) {
+	l.ID = base + "/" + l.ID
+	l.PackageContent = base + "/" + l.PackageContent
+	l.Registration = base + "/" + l.Registration
+	l.CatalogEntry.ID = base + "/" + l.CatalogEntry.ID
+	l.CatalogEntry.PackageContent = base + "/" + l.CatalogEntry.PackageContent
+}
+
+// RegistrationPath returns the path of the registration index of the package
+func RegistrationPath(id string) string {
+	return path.Join(Registration, strings.ToLower(id), IndexFile)
+}
+
+// VersionsPath returns the path of the flat container versions index of the package
+func VersionsPath(id string) string {
+	return path.Join(FlatContainer, strings.ToLower(id), IndexFile)
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	ids := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return strings.ToLower(p.ID)
+	}))
+	sort.Strings(ids)
+	for _, id := range ids {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return strings.ToLower(p.ID) == id
+		})
+		sort.Slice(pkgs, func(i, j int) bool {
+			return CompareVersions(pkgs[i].PkgVersion, pkgs[j].PkgVersion) < 0
+		})
+		b, err := json.Marshal(VersionsIndex{Versions: slices.Map(pkgs, func(p *Package) string {
+			return strings.ToLower(p.PkgVersion)
+		})})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, storage.NewFile(VersionsPath(id), b))
+		if b, err = json.Marshal(newRegistrationIndex(pkgs)); err != nil {
+			return nil, err
+		}
+		out = append(out, storage.NewFile(RegistrationPath(id), b))
+	}
+	return out, nil
+}
+
+// newRegistrationIndex builds the registration index with all the leaves inlined in a single page,
+// the packages must be sorted by version
+func newRegistrationIndex(pkgs []*Package) RegistrationIndex {
+	index := RegistrationPath(pkgs[0].ID)
+	lower, upper := strings.ToLower(pkgs[0].PkgVersion), strings.ToLower(pkgs[len(pkgs)-1].PkgVersion)
+	page := RegistrationPage{
+		ID:    index + "#page/" + lower + "/" + upper,
+		Count: len(pkgs),
+		Lower: lower,
+		Upper: upper,
+	}
+	for _, p := range pkgs {
+		leaf := path.Join(path.Dir(index), strings.ToLower(p.PkgVersion)+".json")
+		m := p.Metadata
+		if m == nil {
+			m = &Metadata{}
+		}
+		page.Items = append(page.Items, RegistrationLeaf{
+			ID: leaf,
+			CatalogEntry: CatalogEntry{
+				ID:                       leaf,
+				PackageID:                p.ID,
+				Version:                  p.PkgVersion,
+				Title:                    m.Title,
+				Authors:                  m.Authors,
+				Description:              m.Description,
+				Summary:                  m.Summary,
+				Tags:                     strings.Fields(m.Tags),
+				ProjectURL:               m.ProjectURL,
+				LicenseURL:               m.LicenseURL,
+				LicenseExpression:        m.LicenseExpression,
+				IconURL:                  m.IconURL,
+				RequireLicenseAcceptance: m.RequireLicenseAcceptance,
+				Listed:                   true,
+				Published:                p.Published,
+				PackageContent:           p.Path(),
+				DependencyGroups:         m.DependencyGroups,
+			},
+			PackageContent: p.Path(),
+			Registration:   index,
+		})
+	}
+	return RegistrationIndex{
+		ID:    index,
+		Count: 1,
+		Items: []RegistrationPage{page},
+	}
+}
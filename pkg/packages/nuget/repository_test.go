@@ -0,0 +1,86 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nuget
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkg := func(id, version string) storage.Artifact {
+		return &Package{
+			ID:         id,
+			PkgVersion: version,
+			Metadata:   &Metadata{Tags: "example  test"},
+			Published:  now,
+			FilePath:   PackagePath(id, version),
+		}
+	}
+	out, err := (&repo{}).Index(context.Background(), "",
+		pkg("Example", "1.10.0"),
+		pkg("Example", "1.2.0-Beta"),
+		pkg("Example", "1.2.0"),
+		pkg("Other", "0.1.0"),
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	require.Len(t, files, 4)
+
+	var versions VersionsIndex
+	require.NoError(t, json.Unmarshal(files["v3-flatcontainer/example/index.json"], &versions))
+	assert.Equal(t, []string{"1.2.0-beta", "1.2.0", "1.10.0"}, versions.Versions)
+
+	var index RegistrationIndex
+	require.NoError(t, json.Unmarshal(files["v3/registration/example/index.json"], &index))
+	assert.Equal(t, "v3/registration/example/index.json", index.ID)
+	require.Len(t, index.Items, 1)
+	page := index.Items[0]
+	assert.Equal(t, "v3/registration/example/index.json#page/1.2.0-beta/1.10.0", page.ID)
+	assert.Equal(t, "1.2.0-beta", page.Lower)
+	assert.Equal(t, "1.10.0", page.Upper)
+	require.Len(t, page.Items, 3)
+	leaf := page.Items[0]
+	assert.Equal(t, "v3/registration/example/1.2.0-beta.json", leaf.ID)
+	assert.Equal(t, "v3/registration/example/index.json", leaf.Registration)
+	assert.Equal(t, "v3-flatcontainer/example/1.2.0-beta/example.1.2.0-beta.nupkg", leaf.PackageContent)
+	assert.Equal(t, "Example", leaf.CatalogEntry.PackageID)
+	assert.Equal(t, "1.2.0-Beta", leaf.CatalogEntry.Version)
+	assert.Equal(t, []string{"example", "test"}, leaf.CatalogEntry.Tags)
+	assert.True(t, leaf.CatalogEntry.Listed)
+
+	index.Resolve("https://example.org/nuget")
+	leaf = index.Items[0].Items[0]
+	assert.Equal(t, "https://example.org/nuget/v3/registration/example/index.json", index.ID)
+	assert.Equal(t, "https://example.org/nuget/v3/registration/example/1.2.0-beta.json", leaf.ID)
+	assert.Equal(t, "https://example.org/nuget/v3/registration/example/1.2.0-beta.json", leaf.CatalogEntry.ID)
+	assert.Equal(t, "https://example.org/nuget/v3/registration/example/index.json", leaf.Registration)
+	assert.Equal(t, "https://example.org/nuget/v3-flatcontainer/example/1.2.0-beta/example.1.2.0-beta.nupkg", leaf.PackageContent)
+	assert.Equal(t, leaf.PackageContent, leaf.CatalogEntry.PackageContent)
+}
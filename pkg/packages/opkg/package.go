@@ -0,0 +1,220 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/blakesmith/ar"
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/packages/deb"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	ArchAll = "all"
+
+	controlTar = "control.tar.gz"
+)
+
+var (
+	ErrInvalidPackage     = errors.New("ipk package is invalid")
+	ErrMissingControlFile = errors.New("control file is missing")
+	ErrInvalidFeed        = errors.New("feed name is invalid")
+
+	feedPattern = regexp.MustCompile(`\A[a-zA-Z0-9_.-]+\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName      string        `json:"name"`
+	PkgVersion   string        `json:"version"`
+	PkgSize      int64         `json:"size"`
+	Architecture string        `json:"architecture"`
+	Control      string        `json:"control"`
+	Metadata     *deb.Metadata `json:"metadata"`
+
+	Feed     string `json:"feed"`
+	FilePath string `json:"filePath"`
+
+	MD5    string `json:"md5"`
+	SHA256 string `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return p.Architecture
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// FileName returns the package file name
+func (p *Package) FileName() string {
+	return fmt.Sprintf("%s_%s_%s.ipk", p.PkgName, p.PkgVersion, p.Architecture)
+}
+
+// PackagePath returns the path of the package file in the feed from its file name,
+// e.g. base/x86_64/example_1.0.0-1_x86_64.ipk
+func PackagePath(feed, name string) string {
+	// neither the package name nor the version may contain an underscore, the architecture may (x86_64)
+	parts := strings.SplitN(strings.TrimSuffix(name, ".ipk"), "_", 3)
+	return path.Join(feed, parts[len(parts)-1], name)
+}
+
+// NewPackage parses the ipk package file, which is either an ar archive like the debian packages
+// or a gzipped tarball, both containing the control.tar.gz archive
+func NewPackage(r io.Reader, feed string) (*Package, error) {
+	if !feedPattern.MatchString(feed) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFeed, feed)
+	}
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	ctrl, err := parsePackage(io.NewSectionReader(reader, 0, reader.Size()))
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	pkg := &Package{
+		PkgName:      ctrl.PkgName,
+		PkgVersion:   ctrl.PkgVersion,
+		PkgSize:      reader.Size(),
+		Architecture: ctrl.Architecture,
+		Control:      ctrl.Control,
+		Metadata:     ctrl.Metadata,
+		Feed:         feed,
+		reader:       reader,
+	}
+	pkg.FilePath = path.Join(feed, pkg.Architecture, pkg.FileName())
+	md5, _, sha256, _ := reader.Sums()
+	pkg.MD5 = hex.EncodeToString(md5)
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+func parsePackage(r io.ReadSeeker) (*deb.Package, error) {
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.Equal(magic, []byte("!<arch>\n")):
+		arr := ar.NewReader(r)
+		for {
+			hd, err := arr.Next()
+			if err == io.EOF {
+				return nil, ErrMissingControlFile
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+			}
+			if strings.TrimSuffix(hd.Name, "/") == controlTar {
+				return parseControlTar(arr)
+			}
+		}
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		defer gzr.Close()
+		tr := tar.NewReader(gzr)
+		for {
+			hd, err := tr.Next()
+			if err == io.EOF {
+				return nil, ErrMissingControlFile
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+			}
+			if path.Clean(hd.Name) == controlTar {
+				return parseControlTar(tr)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported format", ErrInvalidPackage)
+	}
+}
+
+func parseControlTar(r io.Reader) (*deb.Package, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	defer gzr.Close()
+	tr := tar.NewReader(gzr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrMissingControlFile
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		if hd.Typeflag == tar.TypeReg && path.Clean(hd.Name) == "control" {
+			return deb.ParseControlFile(tr)
+		}
+	}
+}
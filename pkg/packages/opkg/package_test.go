@@ -0,0 +1,149 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/blakesmith/ar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testControl = `Package: example
+Version: 1.0.0-1
+Depends: libc
+Source: feeds/base/example
+Section: utils
+Architecture: x86_64
+Installed-Size: 1024
+Description: An example package
+`
+
+func testTarGz(t *testing.T, files map[string][]byte) []byte {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return b.Bytes()
+}
+
+// testIpk builds a package in the OpenWrt gzipped tarball format, or in the debian ar format
+func testIpk(t *testing.T, control string, isAr bool) []byte {
+	members := map[string][]byte{
+		"debian-binary": []byte("2.0\n"),
+		controlTar:      testTarGz(t, map[string][]byte{"./control": []byte(control)}),
+		"data.tar.gz":   testTarGz(t, map[string][]byte{"./usr/bin/example": []byte("#!/bin/sh\n")}),
+	}
+	if !isAr {
+		for k := range members {
+			members["./"+k] = members[k]
+			delete(members, k)
+		}
+		return testTarGz(t, members)
+	}
+	var b bytes.Buffer
+	w := ar.NewWriter(&b)
+	require.NoError(t, w.WriteGlobalHeader())
+	for _, name := range []string{"debian-binary", controlTar, "data.tar.gz"} {
+		require.NoError(t, w.WriteHeader(&ar.Header{Name: name, Size: int64(len(members[name])), Mode: 0644, ModTime: time.Unix(0, 0)}))
+		_, err := w.Write(members[name])
+		require.NoError(t, err)
+	}
+	return b.Bytes()
+}
+
+func TestPackagePath(t *testing.T) {
+	assert.Equal(t, "base/x86_64/example_1.0.0-1_x86_64.ipk", PackagePath("base", "example_1.0.0-1_x86_64.ipk"))
+	assert.Equal(t, "base/all/example_1.0.0-1_all.ipk", PackagePath("base", "example_1.0.0-1_all.ipk"))
+}
+
+func TestNewPackage(t *testing.T) {
+	tests := []struct {
+		name string
+		ar   bool
+	}{
+		{name: "tarball"},
+		{name: "ar", ar: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testIpk(t, testControl, tt.ar)
+			pkg, err := NewPackage(bytes.NewReader(b), "base")
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, "example", pkg.Name())
+			assert.Equal(t, "1.0.0-1", pkg.Version())
+			assert.Equal(t, "x86_64", pkg.Arch())
+			assert.Equal(t, "base", pkg.Feed)
+			assert.Equal(t, "base/x86_64/example_1.0.0-1_x86_64.ipk", pkg.Path())
+			assert.Equal(t, int64(len(b)), pkg.Size())
+			assert.Contains(t, pkg.Control, "Package: example")
+			require.NotNil(t, pkg.Metadata)
+			assert.Len(t, pkg.MD5, 32)
+			assert.Len(t, pkg.SHA256, 64)
+		})
+	}
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		feed    string
+		wantErr error
+	}{
+		{
+			name:    "invalid feed",
+			data:    testIpk(t, testControl, false),
+			feed:    "../base",
+			wantErr: ErrInvalidFeed,
+		},
+		{
+			name:    "missing control archive",
+			data:    testTarGz(t, map[string][]byte{"./data.tar.gz": nil}),
+			feed:    "base",
+			wantErr: ErrMissingControlFile,
+		},
+		{
+			name:    "missing control file",
+			data:    testTarGz(t, map[string][]byte{"./" + controlTar: testTarGz(t, map[string][]byte{"./postinst": nil})}),
+			feed:    "base",
+			wantErr: ErrMissingControlFile,
+		},
+		{
+			name:    "unsupported format",
+			data:    []byte("not a package"),
+			feed:    "base",
+			wantErr: ErrInvalidPackage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(tt.data), tt.feed)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
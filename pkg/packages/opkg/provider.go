@@ -0,0 +1,87 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opkg
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "opkg"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// filePath returns the path of the requested index or package file,
+// the "all" architecture packages being served from all the architectures' directories
+func filePath(r *http.Request) string {
+	feed, arch, file := mux.Vars(r)["feed"], mux.Vars(r)["architecture"], mux.Vars(r)["filename"]
+	if strings.HasPrefix(file, PackagesFile) {
+		return path.Join(feed, arch, file)
+	}
+	return PackagePath(feed, file)
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/" + RepositoryPublicKey,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return RepositoryPublicKey
+			}),
+		},
+		{
+			Method: http.MethodPut,
+			Path:   "/{feed}/push",
+			Handler: packages.Push(func(r *http.Request, reader io.Reader, key string) (storage.Artifact, error) {
+				return NewPackage(reader, mux.Vars(r)["feed"])
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/{feed}/{architecture}/{filename}",
+			Handler: packages.Pull(filePath),
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/{feed}/{architecture}/{filename}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				return PackagePath(mux.Vars(r)["feed"], mux.Vars(r)["filename"])
+			}),
+		},
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opkg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/usign"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	PackagesFile = "Packages"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "opkg"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return usign.GenerateKeypair("")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Index generates the Packages, Packages.gz and Packages.sig files of each feed's architecture,
+// the "all" architecture packages are listed in all the architectures' indices
+func (r *repo) Index(_ context.Context, priv string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	feeds := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.Feed
+	}))
+	sort.Strings(feeds)
+	for _, feed := range feeds {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.Feed == feed
+		})
+		architectures := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+			return p.Architecture
+		}))
+		sort.Strings(architectures)
+		for _, architecture := range architectures {
+			pkgs := slices.Filter(pkgs, func(p *Package) bool {
+				return p.Architecture == architecture || p.Architecture == ArchAll
+			})
+			files, err := buildPackagesIndices(feed, architecture, priv, pkgs...)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, files...)
+		}
+	}
+	return out, nil
+}
+
+// https://openwrt.org/docs/guide-developer/feeds#feed_index
+func buildPackagesIndices(feed, architecture, priv string, pkgs ...*Package) ([]storage.Artifact, error) {
+	sort.Slice(pkgs, func(i, j int) bool {
+		if pkgs[i].PkgName != pkgs[j].PkgName {
+			return pkgs[i].PkgName < pkgs[j].PkgName
+		}
+		return pkgs[i].PkgVersion < pkgs[j].PkgVersion
+	})
+	var content bytes.Buffer
+	for i, v := range pkgs {
+		if i > 0 {
+			fmt.Fprintln(&content)
+		}
+		fmt.Fprintf(&content, "%s\n", strings.TrimSpace(v.Control))
+		fmt.Fprintf(&content, "Filename: %s\n", v.FileName())
+		fmt.Fprintf(&content, "Size: %d\n", v.PkgSize)
+		fmt.Fprintf(&content, "SHA256sum: %s\n", v.SHA256)
+	}
+	var gz bytes.Buffer
+	gzw := gzip.NewWriter(&gz)
+	if _, err := gzw.Write(content.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, err
+	}
+	sig, err := usign.Sign(priv, bytes.NewReader(content.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	dir := path.Join(feed, architecture)
+	return []storage.Artifact{
+		storage.NewFile(path.Join(dir, PackagesFile), content.Bytes()),
+		storage.NewFile(path.Join(dir, PackagesFile+".gz"), gz.Bytes()),
+		storage.NewFile(path.Join(dir, PackagesFile+".sig"), []byte(sig)),
+	}, nil
+}
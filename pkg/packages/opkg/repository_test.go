@@ -0,0 +1,86 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opkg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/crypt/usign"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	priv, pub, err := (&repo{}).GenerateKeypair()
+	require.NoError(t, err)
+	pkg := func(feed, name, version, arch string) storage.Artifact {
+		return &Package{
+			PkgName:      name,
+			PkgVersion:   version,
+			PkgSize:      42,
+			Architecture: arch,
+			Control:      "Package: " + name + "\nVersion: " + version + "\nArchitecture: " + arch + "\n\n",
+			Feed:         feed,
+			SHA256:       "sum-" + name,
+		}
+	}
+	out, err := (&repo{}).Index(context.Background(), priv,
+		pkg("base", "example", "1.0.0-1", "x86_64"),
+		pkg("base", "data", "1.0.0-1", ArchAll),
+		pkg("base", "example", "1.0.0-1", "aarch64"),
+		pkg("packages", "other", "0.1.0-1", "x86_64"),
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	// the "all" architecture has its own index too
+	assert.Len(t, files, 4*3)
+
+	index := string(files["base/x86_64/Packages"])
+	assert.Equal(t, `Package: data
+Version: 1.0.0-1
+Architecture: all
+Filename: data_1.0.0-1_all.ipk
+Size: 42
+SHA256sum: sum-data
+
+Package: example
+Version: 1.0.0-1
+Architecture: x86_64
+Filename: example_1.0.0-1_x86_64.ipk
+Size: 42
+SHA256sum: sum-example
+`, index)
+	gzr, err := gzip.NewReader(bytes.NewReader(files["base/x86_64/Packages.gz"]))
+	require.NoError(t, err)
+	b, err := io.ReadAll(gzr)
+	require.NoError(t, err)
+	assert.Equal(t, index, string(b))
+	assert.NoError(t, usign.Verify(pub, string(files["base/x86_64/Packages.sig"]), []byte(index)))
+
+	assert.Contains(t, string(files["base/aarch64/Packages"]), "Filename: data_1.0.0-1_all.ipk")
+	assert.NotContains(t, string(files["packages/x86_64/Packages"]), "Package: data")
+	assert.Contains(t, files, "base/all/Packages")
+}
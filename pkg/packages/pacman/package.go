@@ -0,0 +1,302 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacman
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	"github.com/ulikunitz/xz"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	ExtZstd = ".pkg.tar.zst"
+	ExtXz   = ".pkg.tar.xz"
+
+	ArchAny = "any"
+)
+
+var (
+	ErrInvalidPackage  = errors.New("pacman package is invalid")
+	ErrMissingMetadata = errors.New("pacman package .PKGINFO not found")
+
+	// https://wiki.archlinux.org/title/Arch_package_guidelines#Package_naming
+	namePattern    = regexp.MustCompile(`\A[a-z0-9@_+][a-z0-9@._+-]*\z`)
+	versionPattern = regexp.MustCompile(`\A([0-9]+:)?[^:/\s-]+-[0-9.]+\z`)
+	archPattern    = regexp.MustCompile(`\A[a-zA-Z0-9_]+\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName       string   `json:"name"`
+	PkgBase       string   `json:"base,omitempty"`
+	PkgVersion    string   `json:"version"`
+	Description   string   `json:"description,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	BuildDate     int64    `json:"buildDate,omitempty"`
+	Packager      string   `json:"packager,omitempty"`
+	InstalledSize int64    `json:"installedSize,omitempty"`
+	Architecture  string   `json:"arch"`
+	Licenses      []string `json:"licenses,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
+	Depends       []string `json:"depends,omitempty"`
+	OptDepends    []string `json:"optDepends,omitempty"`
+	MakeDepends   []string `json:"makeDepends,omitempty"`
+	CheckDepends  []string `json:"checkDepends,omitempty"`
+	Provides      []string `json:"provides,omitempty"`
+	Conflicts     []string `json:"conflicts,omitempty"`
+	Replaces      []string `json:"replaces,omitempty"`
+	Files         []string `json:"files,omitempty"`
+
+	Published time.Time `json:"published"`
+	FileName  string    `json:"fileName"`
+	FilePath  string    `json:"filePath"`
+	PkgSize   int64     `json:"size"`
+
+	MD5    string `json:"md5"`
+	SHA256 string `json:"sha256"`
+	// PGPSig is the base64 encoded detached signature of the package file
+	PGPSig string `json:"pgpsig,omitempty"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return p.Architecture
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// PackagePath returns the path of the package file in the repository from its file name,
+// e.g. x86_64/example-1.0.0-1-x86_64.pkg.tar.zst
+func PackagePath(name string) string {
+	i := strings.Index(name, ".pkg.tar")
+	if i < 0 {
+		return name
+	}
+	base := name[:i]
+	return path.Join(base[strings.LastIndex(base, "-")+1:], name)
+}
+
+// NewPackage parses a zstd or xz compressed package and signs it with the repository key
+// https://wiki.archlinux.org/title/Arch_package_guidelines
+func NewPackage(r io.Reader, key string) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := parsePackage(reader, reader.Size())
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	if key != "" {
+		var sig bytes.Buffer
+		if err := openpgp.DetachSign(&sig, key, io.NewSectionReader(reader, 0, reader.Size())); err != nil {
+			reader.Close()
+			return nil, err
+		}
+		pkg.PGPSig = base64.StdEncoding.EncodeToString(sig.Bytes())
+	}
+	md5, _, sha256, _ := reader.Sums()
+	pkg.MD5 = hex.EncodeToString(md5)
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	pkg.PkgSize = reader.Size()
+	pkg.Published = time.Now().UTC()
+	pkg.reader = reader
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+func parsePackage(r io.ReaderAt, size int64) (*Package, error) {
+	magic := make([]byte, 6)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	var (
+		tr  *tar.Reader
+		ext string
+	)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		defer zr.Close()
+		tr, ext = tar.NewReader(zr), ExtZstd
+	case bytes.Equal(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		xr, err := xz.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		tr, ext = tar.NewReader(xr), ExtXz
+	default:
+		return nil, fmt.Errorf("%w: unsupported compression", ErrInvalidPackage)
+	}
+	var (
+		pkg   *Package
+		files []string
+	)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		name := strings.TrimPrefix(hd.Name, "./")
+		if name == ".PKGINFO" {
+			if pkg, err = parsePkgInfo(tr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// skip the package metadata files (.MTREE, .BUILDINFO, .INSTALL...)
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if hd.Typeflag == tar.TypeDir && !strings.HasSuffix(name, "/") {
+			name += "/"
+		}
+		files = append(files, name)
+	}
+	if pkg == nil {
+		return nil, ErrMissingMetadata
+	}
+	pkg.Files = files
+	pkg.FileName = fmt.Sprintf("%s-%s-%s%s", pkg.PkgName, pkg.PkgVersion, pkg.Architecture, ext)
+	pkg.FilePath = path.Join(pkg.Architecture, pkg.FileName)
+	return pkg, nil
+}
+
+// parsePkgInfo parses the .PKGINFO file made of "key = value" lines
+func parsePkgInfo(r io.Reader) (*Package, error) {
+	var p Package
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		switch k {
+		case "pkgname":
+			p.PkgName = v
+		case "pkgbase":
+			p.PkgBase = v
+		case "pkgver":
+			p.PkgVersion = v
+		case "pkgdesc":
+			p.Description = v
+		case "url":
+			p.URL = v
+		case "builddate":
+			p.BuildDate, _ = strconv.ParseInt(v, 10, 64)
+		case "packager":
+			p.Packager = v
+		case "size":
+			p.InstalledSize, _ = strconv.ParseInt(v, 10, 64)
+		case "arch":
+			p.Architecture = v
+		case "license":
+			p.Licenses = append(p.Licenses, v)
+		case "group":
+			p.Groups = append(p.Groups, v)
+		case "depend":
+			p.Depends = append(p.Depends, v)
+		case "optdepend":
+			p.OptDepends = append(p.OptDepends, v)
+		case "makedepend":
+			p.MakeDepends = append(p.MakeDepends, v)
+		case "checkdepend":
+			p.CheckDepends = append(p.CheckDepends, v)
+		case "provides":
+			p.Provides = append(p.Provides, v)
+		case "conflict":
+			p.Conflicts = append(p.Conflicts, v)
+		case "replaces":
+			p.Replaces = append(p.Replaces, v)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	if !namePattern.MatchString(p.PkgName) {
+		return nil, fmt.Errorf("%w: invalid name %q", ErrInvalidPackage, p.PkgName)
+	}
+	if !versionPattern.MatchString(p.PkgVersion) {
+		return nil, fmt.Errorf("%w: invalid version %q", ErrInvalidPackage, p.PkgVersion)
+	}
+	if !archPattern.MatchString(p.Architecture) {
+		return nil, fmt.Errorf("%w: invalid architecture %q", ErrInvalidPackage, p.Architecture)
+	}
+	return &p, nil
+}
@@ -0,0 +1,194 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacman
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+const testPkgInfo = `# Generated by makepkg
+pkgname = example
+pkgbase = example-base
+pkgver = 1:1.0.0-2
+pkgdesc = An example package
+url = https://example.org
+builddate = 1700000000
+packager = John Doe <john@example.org>
+size = 1024
+arch = x86_64
+license = MIT
+license = Apache
+depend = glibc
+depend = bash>=5
+optdepend = git: vcs support
+provides = example-bin
+`
+
+type testFile struct {
+	name    string
+	content string
+	dir     bool
+}
+
+func testPackage(t *testing.T, compress func(io.Writer) io.WriteCloser, files ...testFile) []byte {
+	var b bytes.Buffer
+	w := compress(&b)
+	tw := tar.NewWriter(w)
+	for _, f := range files {
+		hd := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content)), Typeflag: tar.TypeReg}
+		if f.dir {
+			hd.Mode, hd.Size, hd.Typeflag = 0755, 0, tar.TypeDir
+		}
+		require.NoError(t, tw.WriteHeader(hd))
+		_, err := tw.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func zstdWriter(t *testing.T) func(io.Writer) io.WriteCloser {
+	return func(w io.Writer) io.WriteCloser {
+		zw, err := zstd.NewWriter(w)
+		require.NoError(t, err)
+		return zw
+	}
+}
+
+func xzWriter(t *testing.T) func(io.Writer) io.WriteCloser {
+	return func(w io.Writer) io.WriteCloser {
+		xw, err := xz.NewWriter(w)
+		require.NoError(t, err)
+		return xw
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	assert.Equal(t, "x86_64/example-1.0.0-1-x86_64.pkg.tar.zst", PackagePath("example-1.0.0-1-x86_64.pkg.tar.zst"))
+	assert.Equal(t, "any/example-1.0.0-1-any.pkg.tar.xz.sig", PackagePath("example-1.0.0-1-any.pkg.tar.xz.sig"))
+	assert.Equal(t, "example.db", PackagePath("example.db"))
+}
+
+func TestNewPackage(t *testing.T) {
+	files := []testFile{
+		{name: ".PKGINFO", content: testPkgInfo},
+		{name: ".MTREE", content: "mtree"},
+		{name: ".BUILDINFO", content: "buildinfo"},
+		{name: "usr", dir: true},
+		{name: "usr/bin/", dir: true},
+		{name: "usr/bin/example", content: "#!/bin/sh\n"},
+	}
+	tests := []struct {
+		name     string
+		data     []byte
+		wantPath string
+	}{
+		{
+			name:     "zstd",
+			data:     testPackage(t, zstdWriter(t), files...),
+			wantPath: "x86_64/example-1:1.0.0-2-x86_64.pkg.tar.zst",
+		},
+		{
+			name:     "xz",
+			data:     testPackage(t, xzWriter(t), files...),
+			wantPath: "x86_64/example-1:1.0.0-2-x86_64.pkg.tar.xz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage(bytes.NewReader(tt.data), "")
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, tt.wantPath, pkg.Path())
+			assert.Equal(t, "example", pkg.Name())
+			assert.Equal(t, "example-base", pkg.PkgBase)
+			assert.Equal(t, "1:1.0.0-2", pkg.Version())
+			assert.Equal(t, "x86_64", pkg.Arch())
+			assert.Equal(t, int64(1700000000), pkg.BuildDate)
+			assert.Equal(t, int64(1024), pkg.InstalledSize)
+			assert.Equal(t, []string{"MIT", "Apache"}, pkg.Licenses)
+			assert.Equal(t, []string{"glibc", "bash>=5"}, pkg.Depends)
+			assert.Equal(t, []string{"git: vcs support"}, pkg.OptDepends)
+			assert.Equal(t, []string{"example-bin"}, pkg.Provides)
+			// the metadata files are not listed
+			assert.Equal(t, []string{"usr/", "usr/bin/", "usr/bin/example"}, pkg.Files)
+			assert.Equal(t, int64(len(tt.data)), pkg.Size())
+			assert.Len(t, pkg.MD5, 32)
+			assert.Len(t, pkg.SHA256, 64)
+			assert.Empty(t, pkg.PGPSig)
+		})
+	}
+}
+
+func TestNewPackageSigned(t *testing.T) {
+	priv, _, err := (&repo{}).GenerateKeypair()
+	require.NoError(t, err)
+	pkg, err := NewPackage(bytes.NewReader(testPackage(t, zstdWriter(t), testFile{name: ".PKGINFO", content: testPkgInfo})), priv)
+	require.NoError(t, err)
+	defer pkg.Close()
+	sig, err := base64.StdEncoding.DecodeString(pkg.PGPSig)
+	require.NoError(t, err)
+	assert.NotEmpty(t, sig)
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "missing metadata",
+			data:    testPackage(t, zstdWriter(t), testFile{name: "usr/bin/example"}),
+			wantErr: ErrMissingMetadata,
+		},
+		{
+			name:    "invalid name",
+			data:    testPackage(t, zstdWriter(t), testFile{name: ".PKGINFO", content: "pkgname = -example\npkgver = 1.0-1\narch = any\n"}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "missing release",
+			data:    testPackage(t, zstdWriter(t), testFile{name: ".PKGINFO", content: "pkgname = example\npkgver = 1.0\narch = any\n"}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "invalid architecture",
+			data:    testPackage(t, zstdWriter(t), testFile{name: ".PKGINFO", content: "pkgname = example\npkgver = 1.0-1\narch = ../any\n"}),
+			wantErr: ErrInvalidPackage,
+		},
+		{
+			name:    "unsupported compression",
+			data:    []byte("not a package"),
+			wantErr: ErrInvalidPackage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(tt.data), "")
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacman
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "pacman"
+
+var _ packages.Provider = (*provider)(nil)
+
+// dbPattern matches the database files requested by pacman, e.g. core.db, core.db.sig, core.files.tar.gz
+var dbPattern = regexp.MustCompile(`\A.+\.(db|files)(\.tar\.gz)?(\.sig)?\z`)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+func repoName(repo string, r *http.Request) string {
+	if repo != "" {
+		return strings.NewReplacer("/", "-").Replace(repo)
+	}
+	return strings.NewReplacer("/", "-", ".", "-").Replace(strings.TrimPrefix(strings.Split(r.Host, ":")[0], Name+"."))
+}
+
+// config serves the pacman.conf repository section
+func (p *provider) config(repo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if _, err := storage.FromContext(ctx).Stat(ctx, RepositoryPublicKey); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		host := strings.TrimSuffix(r.Host, "/")
+		if user, pass, ok := r.BasicAuth(); ok {
+			host = fmt.Sprintf("%s:%s@%s", user, pass, host)
+		}
+		url := strings.TrimSuffix(fmt.Sprintf("%s://%s/%s", packages.Scheme(r), host, strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, ".conf"), "/")), "/")
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := repoDefinition(w, repoName(repo, r), url); err != nil {
+			logger.C(r.Context()).WithError(err).Error("failed to execute template")
+		}
+	}
+}
+
+func (p *provider) setup(repo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if _, err := storage.FromContext(ctx).Stat(ctx, RepositoryPublicKey); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		user, pass, _ := r.BasicAuth()
+		args := SetupArgs{
+			Name:     repoName(repo, r),
+			User:     user,
+			Password: pass,
+			Scheme:   packages.Scheme(r),
+			Host:     r.Host,
+			Path:     strings.TrimSuffix(r.URL.Path, "/setup"),
+		}
+		if err := scriptTemplate.Execute(w, args); err != nil {
+			logger.C(r.Context()).WithError(err).Error("failed to execute template")
+		}
+	}
+}
+
+// download serves the databases whatever the requested repository name, the packages
+// and their signatures which are stored in the packages metadata
+func (p *provider) download(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		s := storage.FromContext(ctx)
+		arch, file := mux.Vars(r)["arch"], mux.Vars(r)["file"]
+		if m := dbPattern.FindStringSubmatch(file); m != nil {
+			name := DBName + DBExt
+			if m[1] == "files" {
+				name = DBName + FilesExt
+			}
+			if err := s.ServeFile(w, r, path.Join(arch, name+m[3])); err != nil {
+				storage.Error(w, err)
+			}
+			return
+		}
+		if !strings.HasSuffix(file, SigExt) {
+			if err := s.ServeFile(w, r, PackagePath(file)); err != nil {
+				storage.Error(w, err)
+			}
+			return
+		}
+		i, err := s.Stat(ctx, PackagePath(strings.TrimSuffix(file, SigExt)))
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		var pkg Package
+		if err := json.Unmarshal(i.Meta(), &pkg); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sig, err := base64.StdEncoding.DecodeString(pkg.PGPSig)
+		if err != nil || len(sig) == 0 {
+			http.Error(w, fmt.Sprintf("%s: signature not found", file), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pgp-signature")
+		w.Header().Set("Content-Length", fmt.Sprint(len(sig)))
+		w.Write(sig)
+	}
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method: http.MethodGet,
+			Path:   "/" + RepositoryPublicKey,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return RepositoryPublicKey
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    ".conf",
+			Handler: p.config,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/setup",
+			Handler: p.setup,
+		},
+		{
+			Method: http.MethodPut,
+			Path:   "/push",
+			Handler: packages.Push(func(r *http.Request, reader io.Reader, key string) (storage.Artifact, error) {
+				return NewPackage(reader, key)
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/{arch}/{file}",
+			Handler: p.download,
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/{arch}/{file}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				return PackagePath(mux.Vars(r)["file"])
+			}),
+		},
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacman
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	// DBName is the name of the stored databases, they are served whatever the name
+	// of the pacman repository section is.
+	DBName = "repo"
+
+	DBExt    = ".db.tar.gz"
+	FilesExt = ".files.tar.gz"
+	SigExt   = ".sig"
+
+	// DefaultArch is the architecture used to index the "any" packages when
+	// there is no architecture specific package in the repository
+	DefaultArch = "x86_64"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "pacman"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Pacman Registry", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+func (r *repo) Index(_ context.Context, key string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	// as with repo-add, the last published version of a package replaces the previous ones
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Published.Before(pkgs[j].Published)
+	})
+	archs := slices.Distinct(slices.Map(slices.Filter(pkgs, func(p *Package) bool {
+		return p.Architecture != ArchAny
+	}), func(p *Package) string {
+		return p.Architecture
+	}))
+	if len(archs) == 0 {
+		archs = []string{DefaultArch}
+	}
+	sort.Strings(archs)
+	for _, a := range archs {
+		latest := make(map[string]*Package)
+		for _, v := range pkgs {
+			if v.Architecture == a || v.Architecture == ArchAny {
+				latest[v.PkgName] = v
+			}
+		}
+		db, err := buildDB(latest, false)
+		if err != nil {
+			return nil, err
+		}
+		files, err := buildDB(latest, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range []struct {
+			name string
+			data []byte
+		}{
+			{name: DBName + DBExt, data: db},
+			{name: DBName + FilesExt, data: files},
+		} {
+			var sig bytes.Buffer
+			if err := openpgp.DetachSign(&sig, key, bytes.NewReader(v.data)); err != nil {
+				return nil, err
+			}
+			out = append(out,
+				storage.NewFile(path.Join(a, v.name), v.data),
+				storage.NewFile(path.Join(a, v.name+SigExt), sig.Bytes()),
+			)
+		}
+	}
+	return out, nil
+}
+
+// buildDB creates the gzipped tarball containing the {name}-{version}/desc entries,
+// and the {name}-{version}/files entries for the files database
+func buildDB(pkgs map[string]*Package, files bool) ([]byte, error) {
+	names := make([]string, 0, len(pkgs))
+	for k := range pkgs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, n := range names {
+		p := pkgs[n]
+		dir := fmt.Sprintf("%s-%s/", p.PkgName, p.PkgVersion)
+		if err := tw.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0755, ModTime: p.Published}); err != nil {
+			return nil, err
+		}
+		entries := map[string][]byte{"desc": desc(p)}
+		if files {
+			entries["files"] = []byte("%FILES%\n" + strings.Join(p.Files, "\n") + "\n")
+		}
+		for _, k := range []string{"desc", "files"} {
+			b, ok := entries[k]
+			if !ok {
+				continue
+			}
+			if err := tw.WriteHeader(&tar.Header{Name: dir + k, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(b)), ModTime: p.Published}); err != nil {
+				return nil, err
+			}
+			if _, err := tw.Write(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// desc returns the package's desc database entry
+// https://gitlab.archlinux.org/pacman/pacman/-/blob/master/scripts/repo-add.sh.in
+func desc(p *Package) []byte {
+	var b bytes.Buffer
+	write := func(k string, v ...string) {
+		v = slices.Filter(v, func(s string) bool { return s != "" })
+		if len(v) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "%%%s%%\n%s\n\n", k, strings.Join(v, "\n"))
+	}
+	write("FILENAME", p.FileName)
+	write("NAME", p.PkgName)
+	write("BASE", p.PkgBase)
+	write("VERSION", p.PkgVersion)
+	write("DESC", p.Description)
+	write("GROUPS", p.Groups...)
+	write("CSIZE", fmt.Sprint(p.PkgSize))
+	write("ISIZE", fmt.Sprint(p.InstalledSize))
+	write("MD5SUM", p.MD5)
+	write("SHA256SUM", p.SHA256)
+	write("PGPSIG", p.PGPSig)
+	write("URL", p.URL)
+	write("LICENSE", p.Licenses...)
+	write("ARCH", p.Architecture)
+	if p.BuildDate != 0 {
+		write("BUILDDATE", fmt.Sprint(p.BuildDate))
+	}
+	write("PACKAGER", p.Packager)
+	write("REPLACES", p.Replaces...)
+	write("CONFLICTS", p.Conflicts...)
+	write("PROVIDES", p.Provides...)
+	write("DEPENDS", p.Depends...)
+	write("OPTDEPENDS", p.OptDepends...)
+	write("MAKEDEPENDS", p.MakeDepends...)
+	write("CHECKDEPENDS", p.CheckDepends...)
+	return b.Bytes()
+}
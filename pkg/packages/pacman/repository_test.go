@@ -0,0 +1,164 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacman
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func readDB(t *testing.T, r io.Reader) map[string]string {
+	gr, err := gzip.NewReader(r)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	out := make(map[string]string)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return out
+		}
+		require.NoError(t, err)
+		b, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		out[hd.Name] = string(b)
+	}
+}
+
+func TestDesc(t *testing.T) {
+	p := &Package{
+		PkgName:       "example",
+		PkgVersion:    "1.0.0-1",
+		Description:   "An example package",
+		InstalledSize: 1024,
+		Architecture:  "x86_64",
+		Licenses:      []string{"MIT", "Apache"},
+		Depends:       []string{"glibc"},
+		BuildDate:     1700000000,
+		FileName:      "example-1.0.0-1-x86_64.pkg.tar.zst",
+		PkgSize:       42,
+		MD5:           "md5",
+		SHA256:        "sha256",
+	}
+	assert.Equal(t, `%FILENAME%
+example-1.0.0-1-x86_64.pkg.tar.zst
+
+%NAME%
+example
+
+%VERSION%
+1.0.0-1
+
+%DESC%
+An example package
+
+%CSIZE%
+42
+
+%ISIZE%
+1024
+
+%MD5SUM%
+md5
+
+%SHA256SUM%
+sha256
+
+%LICENSE%
+MIT
+Apache
+
+%ARCH%
+x86_64
+
+%BUILDDATE%
+1700000000
+
+%DEPENDS%
+glibc
+
+`, string(desc(p)))
+}
+
+func TestIndex(t *testing.T) {
+	priv, _, err := (&repo{}).GenerateKeypair()
+	require.NoError(t, err)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	pkg := func(name, version, arch string, published time.Time) storage.Artifact {
+		return &Package{PkgName: name, PkgVersion: version, Architecture: arch, Published: published, Files: []string{"usr/", "usr/bin/" + name}}
+	}
+	tests := []struct {
+		name string
+		pkgs []storage.Artifact
+		want map[string][]string
+	}{
+		{
+			name: "any only",
+			pkgs: []storage.Artifact{pkg("example", "1.0.0-1", ArchAny, now)},
+			want: map[string][]string{DefaultArch: {"example-1.0.0-1/"}},
+		},
+		{
+			name: "last published wins",
+			pkgs: []storage.Artifact{
+				pkg("example", "2.0.0-1", "x86_64", now.Add(time.Hour)),
+				pkg("example", "1.0.0-1", "x86_64", now),
+				pkg("example", "1.0.0-1", "aarch64", now),
+				pkg("data", "1.0.0-1", ArchAny, now),
+			},
+			want: map[string][]string{
+				"x86_64":  {"data-1.0.0-1/", "example-2.0.0-1/"},
+				"aarch64": {"data-1.0.0-1/", "example-1.0.0-1/"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := (&repo{}).Index(context.Background(), priv, tt.pkgs...)
+			require.NoError(t, err)
+			files := make(map[string][]byte)
+			for _, v := range out {
+				b, err := io.ReadAll(v)
+				require.NoError(t, err)
+				files[v.Path()] = b
+			}
+			assert.Len(t, files, 4*len(tt.want))
+			for arch, dirs := range tt.want {
+				for _, v := range []string{DBExt, FilesExt} {
+					require.Contains(t, files, arch+"/"+DBName+v)
+					assert.NotEmpty(t, files[arch+"/"+DBName+v+SigExt])
+				}
+				db := readDB(t, bytes.NewReader(files[arch+"/"+DBName+DBExt]))
+				fdb := readDB(t, bytes.NewReader(files[arch+"/"+DBName+FilesExt]))
+				assert.Len(t, db, 2*len(dirs))
+				assert.Len(t, fdb, 3*len(dirs))
+				for _, d := range dirs {
+					assert.Contains(t, db, d)
+					assert.Contains(t, db[d+"desc"], "%NAME%\n")
+					assert.NotContains(t, db, d+"files")
+					assert.Contains(t, fdb[d+"files"], "%FILES%\nusr/\n")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pacman
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/spf13/afero"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+)
+
+//go:embed setup.sh
+var script string
+
+const pacmanConf = "/etc/pacman.conf"
+
+var (
+	scriptTemplate = template.Must(template.New("setup.sh").Parse(script))
+	repoTemplate   = template.Must(template.New("repo").Parse(`[{{.Name}}]
+SigLevel = Required
+Server = {{.URL}}/$arch
+`))
+)
+
+type SetupArgs struct {
+	User     string
+	Password string
+	Scheme   string
+	Host     string
+	Path     string
+	Name     string
+}
+
+var fs = afero.NewOsFs()
+
+// importKey adds the key to the pacman keyring and locally signs it
+var importKey = func(ctx context.Context, file, fingerprint string) error {
+	for _, args := range [][]string{{"--add", file}, {"--lsign-key", fingerprint}} {
+		cmd := exec.CommandContext(ctx, "pacman-key", args...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("pacman-key %s: %w", args[0], err)
+		}
+	}
+	return nil
+}
+
+func (c *client) SetupLocal(ctx context.Context, force bool) error {
+	u, err := url.Parse(fmt.Sprintf("%s://%s", c.c.Options().Scheme(), c.base))
+	if err != nil {
+		return err
+	}
+
+	var name string
+	if c.repo != "" {
+		name = strings.NewReplacer("/", "-").Replace(c.repo)
+	} else {
+		name = strings.NewReplacer("/", "-", ".", "-").Replace(strings.TrimPrefix(strings.Split(u.Host, ":")[0], Name+"."))
+	}
+
+	// Check if the repository file already exists
+	f := filepath.Join("/etc/pacman.d", name+".conf")
+	if _, err := fs.Stat(f); err == nil && !force {
+		return packages.ErrAlreadyConfigured
+	}
+
+	pub, err := c.Key(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to download repository key: %w", err)
+	}
+	keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(pub))
+	if err != nil || len(keys) == 0 {
+		return fmt.Errorf("failed to parse repository key: %v", err)
+	}
+	k := filepath.Join("/etc/pacman.d", name+".key")
+	if err := afero.WriteFile(fs, k, []byte(pub), 0644); err != nil {
+		return fmt.Errorf("failed to write repository key file: %w", err)
+	}
+	if err := importKey(ctx, k, fmt.Sprintf("%X", keys[0].PrimaryKey.Fingerprint)); err != nil {
+		return fmt.Errorf("failed to import repository key: %w", err)
+	}
+
+	def, err := c.Repo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get repository definition: %w", err)
+	}
+	if err := afero.WriteFile(fs, f, []byte(def), 0644); err != nil {
+		return err
+	}
+
+	// Include the repository file in pacman.conf
+	conf, err := afero.ReadFile(fs, pacmanConf)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	include := "Include = " + f
+	for _, v := range strings.Split(string(conf), "\n") {
+		if strings.TrimSpace(v) == include {
+			return nil
+		}
+	}
+	if len(conf) > 0 && !bytes.HasSuffix(conf, []byte("\n")) {
+		conf = append(conf, '\n')
+	}
+	conf = append(conf, []byte("\n"+include+"\n")...)
+	return afero.WriteFile(fs, pacmanConf, conf, 0644)
+}
+
+func repoDefinition(w io.Writer, name, url string) error {
+	data := map[string]string{
+		"Name": name,
+		"URL":  url,
+	}
+	return repoTemplate.ExecuteTemplate(w, "repo", data)
+}
@@ -0,0 +1,73 @@
+#!/bin/bash
+
+# Copyright 2023 Linka Cloud  All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+set -e
+
+USER="{{ .User }}"
+PASSWORD="{{ .Password }}"
+
+SCHEME="{{ .Scheme }}"
+REPO_HOST="{{ .Host }}"
+REPO_PATH="{{ .Path }}"
+
+REPO_NAME="{{ .Name }}"
+
+[ -n "${DEBUG}" ] && set -x
+
+if  [ -z "${REPO_PATH}" ]; then
+    REPO_PATH="/"
+fi
+
+if [ -n "${USER}" ]; then
+    REPO_URL="${SCHEME}://${USER}:${PASSWORD}@${REPO_HOST}${REPO_PATH}"
+else
+    REPO_URL="${SCHEME}://${REPO_HOST}${REPO_PATH}"
+fi
+
+if [ "$(id -u)" -ne 0 ]; then
+    echo "Please run as root or sudo"
+    exit 1
+fi
+
+if [ -f "/etc/pacman.d/${REPO_NAME}.conf" ] && [ "$1" != "--force" ]; then
+    echo "Repository already configured."
+    echo "Use --force to overwrite."
+    exit 1
+fi
+
+if ! command -v curl > /dev/null; then
+    echo "curl is required to setup the repository."
+    exit 1
+fi
+
+if ! command -v pacman-key > /dev/null; then
+    echo "pacman-key is required to setup the repository."
+    exit 1
+fi
+
+curl -sf "${REPO_URL%/}/repository.key" -o "/etc/pacman.d/${REPO_NAME}.key"
+pacman-key --add "/etc/pacman.d/${REPO_NAME}.key"
+KEY_ID="$(gpg --with-colons --show-keys "/etc/pacman.d/${REPO_NAME}.key" | awk -F: '/^fpr/ { print $10; exit }')"
+pacman-key --lsign-key "${KEY_ID}"
+
+curl -sf "${REPO_URL%/}.conf" -o "/etc/pacman.d/${REPO_NAME}.conf"
+
+if ! grep -qx "Include = /etc/pacman.d/${REPO_NAME}.conf" /etc/pacman.conf; then
+    printf '\nInclude = /etc/pacman.d/%s.conf\n' "${REPO_NAME}" >> /etc/pacman.conf
+fi
+
+echo "pacman setup complete."
+echo "You can now run 'pacman -Sy' to update the package list."
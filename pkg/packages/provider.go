@@ -84,6 +84,9 @@ func Init(ctx context.Context, r *mux.Router, domain, repo string) error {
 				}
 				if repo == "" {
 					p = "/{repo:.+}" + vv.Path
+					if vv.Deep {
+						p = "/{repo:.+}/" + RepositorySeparator + vv.Path
+					}
 				}
 				if err := v.Path(p).Methods(vv.Method).HandlerFunc(makeHandler("", vv.Handler)).GetError(); err != nil {
 					return fmt.Errorf("%s: %q: %w", k, vv.Path, err)
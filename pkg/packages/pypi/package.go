@@ -0,0 +1,347 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pypi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+	"go.linka.cloud/artifact-registry/pkg/validation"
+)
+
+const (
+	TypeWheel = "bdist_wheel"
+	TypeSdist = "sdist"
+)
+
+var (
+	ErrMissingMetadataFile = errors.New("metadata file is missing")
+	ErrInvalidFilename     = errors.New("package filename is invalid")
+	ErrInvalidName         = errors.New("package name is invalid")
+	ErrInvalidVersion      = errors.New("package version is invalid")
+
+	// https://packaging.python.org/en/latest/specifications/name-normalization/
+	namePattern          = regexp.MustCompile(`(?i)\A([A-Z0-9]|[A-Z0-9][A-Z0-9._-]*[A-Z0-9])\z`)
+	normalizationPattern = regexp.MustCompile(`[-_.]+`)
+	// https://peps.python.org/pep-0440/#appendix-b-parsing-version-strings-with-regular-expressions
+	versionPattern = regexp.MustCompile(`(?i)\Av?(?:[0-9]+!)?[0-9]+(?:\.[0-9]+)*(?:[-_.]?(?:a|b|c|rc|alpha|beta|pre|preview)[-_.]?[0-9]*)?(?:-[0-9]+|[-_.]?(?:post|rev|r)[-_.]?[0-9]*)?(?:[-_.]?dev[-_.]?[0-9]*)?(?:\+[a-z0-9]+(?:[-_.][a-z0-9]+)*)?\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+// https://packaging.python.org/en/latest/specifications/core-metadata/
+
+type Package struct {
+	PkgName    string    `json:"name"`
+	PkgVersion string    `json:"version"`
+	Filename   string    `json:"filename"`
+	FileType   string    `json:"fileType"`
+	Platform   string    `json:"platform,omitempty"`
+	Metadata   *Metadata `json:"metadata"`
+
+	PkgSize  int64  `json:"size"`
+	FilePath string `json:"filePath"`
+
+	MD5    string `json:"md5"`
+	SHA256 string `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return p.Platform
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// Project returns the normalized project name as defined by PEP 503.
+func (p *Package) Project() string {
+	return NormalizeName(p.PkgName)
+}
+
+type Metadata struct {
+	Summary        string   `json:"summary,omitempty"`
+	Author         string   `json:"author,omitempty"`
+	License        string   `json:"license,omitempty"`
+	ProjectURL     string   `json:"projectURL,omitempty"`
+	RequiresPython string   `json:"requiresPython,omitempty"`
+	RequiresDist   []string `json:"requiresDist,omitempty"`
+}
+
+// NormalizeName returns the PEP 503 normalized form of a project name
+func NormalizeName(name string) string {
+	return strings.ToLower(normalizationPattern.ReplaceAllString(name, "-"))
+}
+
+// NewPackage parses a wheel or a source distribution.
+// If the filename is empty, it is computed from the archive content.
+// https://packaging.python.org/en/latest/specifications/binary-distribution-format/
+// https://packaging.python.org/en/latest/specifications/source-distribution-format/
+func NewPackage(r io.Reader, filename string) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if filename == "" {
+		if filename, err = detectFilename(reader, reader.Size()); err != nil {
+			return nil, err
+		}
+	}
+	filename = path.Base(filename)
+	var (
+		typ, platform string
+		mr            io.Reader
+	)
+	switch {
+	case strings.HasSuffix(filename, ".whl"):
+		// {distribution}-{version}(-{build tag})?-{python tag}-{abi tag}-{platform tag}.whl
+		parts := strings.Split(strings.TrimSuffix(filename, ".whl"), "-")
+		if len(parts) != 5 && len(parts) != 6 {
+			return nil, ErrInvalidFilename
+		}
+		typ, platform = TypeWheel, parts[len(parts)-1]
+		mr, err = openZip(reader, reader.Size(), ".dist-info/METADATA")
+	case strings.HasSuffix(filename, ".tar.gz"):
+		typ = TypeSdist
+		mr, err = openTarGz(io.NewSectionReader(reader, 0, reader.Size()), "/PKG-INFO")
+	case strings.HasSuffix(filename, ".zip"):
+		typ = TypeSdist
+		mr, err = openZip(reader, reader.Size(), "/PKG-INFO")
+	default:
+		return nil, ErrInvalidFilename
+	}
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := ParseMetadata(mr)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(NormalizeName(filename), pkg.Project()+"-") {
+		return nil, fmt.Errorf("%w: %s does not match package name %s", ErrInvalidFilename, filename, pkg.PkgName)
+	}
+	pkg.Filename = filename
+	pkg.FileType = typ
+	pkg.Platform = platform
+	pkg.FilePath = path.Join("files", filename)
+	pkg.reader = reader
+	pkg.PkgSize = reader.Size()
+	md5, _, sha256, _ := reader.Sums()
+	pkg.MD5 = hex.EncodeToString(md5)
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+// detectFilename computes the distribution filename from the archive content:
+// wheels are named after their .dist-info directory and WHEEL tags,
+// source distributions after their top level directory.
+func detectFilename(r io.ReaderAt, size int64) (string, error) {
+	magic := make([]byte, 2)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return "", err
+	}
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gzr, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return "", err
+		}
+		hd, err := tar.NewReader(gzr).Next()
+		if err != nil {
+			return "", err
+		}
+		return strings.Split(strings.TrimPrefix(hd.Name, "./"), "/")[0] + ".tar.gz", nil
+	}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", ErrInvalidFilename
+	}
+	for _, v := range zr.File {
+		dir, name, ok := strings.Cut(v.Name, "/")
+		if !ok || name != "WHEEL" || !strings.HasSuffix(dir, ".dist-info") {
+			continue
+		}
+		f, err := v.Open()
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		m, err := mail.ReadMessage(f)
+		if err != nil {
+			return "", err
+		}
+		var py, abi, plat []string
+		for _, t := range m.Header["Tag"] {
+			parts := strings.Split(strings.TrimSpace(t), "-")
+			if len(parts) != 3 {
+				return "", fmt.Errorf("invalid wheel tag %q", t)
+			}
+			py, abi, plat = appendDistinct(py, parts[0]), appendDistinct(abi, parts[1]), appendDistinct(plat, parts[2])
+		}
+		if len(py) == 0 {
+			return "", fmt.Errorf("missing wheel tags")
+		}
+		parts := []string{strings.TrimSuffix(dir, ".dist-info")}
+		if b := strings.TrimSpace(m.Header.Get("Build")); b != "" {
+			parts = append(parts, b)
+		}
+		parts = append(parts, strings.Join(py, "."), strings.Join(abi, "."), strings.Join(plat, "."))
+		return strings.Join(parts, "-") + ".whl", nil
+	}
+	for _, v := range zr.File {
+		if dir, _, ok := strings.Cut(v.Name, "/"); ok {
+			return dir + ".zip", nil
+		}
+	}
+	return "", ErrInvalidFilename
+}
+
+func appendDistinct(s []string, v string) []string {
+	for _, vv := range s {
+		if vv == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
+
+// openZip returns the content of the top level entry matching the suffix
+func openZip(r io.ReaderAt, size int64, suffix string) (io.Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range zr.File {
+		if strings.Count(v.Name, "/") != 1 || !strings.HasSuffix(v.Name, suffix) {
+			continue
+		}
+		return v.Open()
+	}
+	return nil, ErrMissingMetadataFile
+}
+
+// openTarGz returns the content of the top level entry matching the suffix
+func openTarGz(r io.Reader, suffix string) (io.Reader, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gzr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hd.Typeflag != tar.TypeReg {
+			continue
+		}
+		if strings.Count(strings.TrimPrefix(hd.Name, "./"), "/") != 1 || !strings.HasSuffix(hd.Name, suffix) {
+			continue
+		}
+		return tr, nil
+	}
+	return nil, ErrMissingMetadataFile
+}
+
+// ParseMetadata parses a METADATA or PKG-INFO file to retrieve the package metadata
+func ParseMetadata(r io.Reader) (*Package, error) {
+	m, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	h := m.Header
+	p := &Package{
+		PkgName:    strings.TrimSpace(h.Get("Name")),
+		PkgVersion: strings.TrimSpace(h.Get("Version")),
+		Metadata: &Metadata{
+			Summary:        h.Get("Summary"),
+			Author:         h.Get("Author"),
+			License:        h.Get("License"),
+			RequiresPython: h.Get("Requires-Python"),
+			RequiresDist:   h["Requires-Dist"],
+		},
+	}
+	if p.Metadata.Author == "" {
+		p.Metadata.Author = h.Get("Author-email")
+	}
+	if v := h.Get("Home-page"); validation.IsValidURL(v) {
+		p.Metadata.ProjectURL = v
+	}
+	// Project-URL: Homepage, https://example.org
+	for _, v := range h["Project-Url"] {
+		if p.Metadata.ProjectURL != "" {
+			break
+		}
+		if _, u, ok := strings.Cut(v, ","); ok && validation.IsValidURL(strings.TrimSpace(u)) {
+			p.Metadata.ProjectURL = strings.TrimSpace(u)
+		}
+	}
+	if !namePattern.MatchString(p.PkgName) {
+		return nil, ErrInvalidName
+	}
+	if !versionPattern.MatchString(p.PkgVersion) {
+		return nil, ErrInvalidVersion
+	}
+	return p, nil
+}
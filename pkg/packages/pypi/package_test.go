@@ -0,0 +1,212 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pypi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMetadata = `Metadata-Version: 2.1
+Name: Example_Pkg
+Version: 1.0.0
+Summary: An example package
+Author-email: John Doe <john@example.org>
+License: MIT
+Project-URL: Homepage, https://example.org
+Requires-Python: >=3.8
+Requires-Dist: requests>=2.0
+Requires-Dist: click
+
+A long description.
+`
+
+func testWheel(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func testSdist(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gzw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return b.Bytes()
+}
+
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		want     *Package
+		wantErr  error
+	}{
+		{
+			name:     "metadata",
+			metadata: testMetadata,
+			want: &Package{
+				PkgName:    "Example_Pkg",
+				PkgVersion: "1.0.0",
+				Metadata: &Metadata{
+					Summary:        "An example package",
+					Author:         "John Doe <john@example.org>",
+					License:        "MIT",
+					ProjectURL:     "https://example.org",
+					RequiresPython: ">=3.8",
+					RequiresDist:   []string{"requests>=2.0", "click"},
+				},
+			},
+		},
+		{
+			name:     "home page",
+			metadata: "Name: example\nVersion: 2.0rc1\nAuthor: Jane\nHome-page: https://example.com\nProject-URL: Source, https://example.org\n\n",
+			want: &Package{
+				PkgName:    "example",
+				PkgVersion: "2.0rc1",
+				Metadata: &Metadata{
+					Author:     "Jane",
+					ProjectURL: "https://example.com",
+				},
+			},
+		},
+		{
+			name:     "invalid name",
+			metadata: "Name: -example\nVersion: 1.0.0\n\n",
+			wantErr:  ErrInvalidName,
+		},
+		{
+			name:     "invalid version",
+			metadata: "Name: example\nVersion: one\n\n",
+			wantErr:  ErrInvalidVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMetadata(strings.NewReader(tt.metadata))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewPackage(t *testing.T) {
+	wheel := testWheel(t, map[string]string{
+		"example_pkg/__init__.py":                  "",
+		"example_pkg-1.0.0.dist-info/METADATA":     testMetadata,
+		"example_pkg-1.0.0.dist-info/WHEEL":        "Wheel-Version: 1.0\nTag: py3-none-any\n\n",
+		"example_pkg-1.0.0.dist-info/RECORD":       "",
+		"example_pkg-1.0.0.dist-info/sub/METADATA": "Name: other\n",
+	})
+	tests := []struct {
+		name     string
+		content  []byte
+		filename string
+		wantFile string
+		wantType string
+		wantPlat string
+		wantErr  error
+	}{
+		{
+			name:     "wheel",
+			content:  wheel,
+			filename: "example_pkg-1.0.0-py3-none-any.whl",
+			wantFile: "example_pkg-1.0.0-py3-none-any.whl",
+			wantType: TypeWheel,
+			wantPlat: "any",
+		},
+		{
+			name:     "wheel without filename",
+			content:  wheel,
+			wantFile: "example_pkg-1.0.0-py3-none-any.whl",
+			wantType: TypeWheel,
+			wantPlat: "any",
+		},
+		{
+			name:     "sdist",
+			content:  testSdist(t, map[string]string{"example_pkg-1.0.0/PKG-INFO": testMetadata, "example_pkg-1.0.0/setup.py": ""}),
+			filename: "example_pkg-1.0.0.tar.gz",
+			wantFile: "example_pkg-1.0.0.tar.gz",
+			wantType: TypeSdist,
+		},
+		{
+			name:     "sdist without filename",
+			content:  testSdist(t, map[string]string{"example_pkg-1.0.0/PKG-INFO": testMetadata}),
+			wantFile: "example_pkg-1.0.0.tar.gz",
+			wantType: TypeSdist,
+		},
+		{
+			name:     "filename not matching the package name",
+			content:  wheel,
+			filename: "other-1.0.0-py3-none-any.whl",
+			wantErr:  ErrInvalidFilename,
+		},
+		{
+			name:     "invalid wheel filename",
+			content:  wheel,
+			filename: "example_pkg-1.0.0.whl",
+			wantErr:  ErrInvalidFilename,
+		},
+		{
+			name:     "missing metadata",
+			content:  testSdist(t, map[string]string{"example_pkg-1.0.0/setup.py": ""}),
+			filename: "example_pkg-1.0.0.tar.gz",
+			wantErr:  ErrMissingMetadataFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewPackage(bytes.NewReader(tt.content), tt.filename)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, "Example_Pkg", pkg.PkgName)
+			assert.Equal(t, "example-pkg", pkg.Project())
+			assert.Equal(t, tt.wantFile, pkg.Filename)
+			assert.Equal(t, "files/"+tt.wantFile, pkg.Path())
+			assert.Equal(t, tt.wantType, pkg.FileType)
+			assert.Equal(t, tt.wantPlat, pkg.Platform)
+			assert.Equal(t, int64(len(tt.content)), pkg.Size())
+			assert.Len(t, pkg.SHA256, 64)
+		})
+	}
+}
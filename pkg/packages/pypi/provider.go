@@ -0,0 +1,213 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pypi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "pypi"
+
+// https://peps.python.org/pep-0691/#content-types
+const (
+	ContentTypeJSON       = "application/vnd.pypi.simple.v1+json"
+	ContentTypeHTML       = "application/vnd.pypi.simple.v1+html"
+	ContentTypeLegacyHTML = "text/html"
+)
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// simple serves the PEP 503 / PEP 691 index page matching the request's Accept header
+func (p *provider) simple(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		dir := "simple"
+		if project := mux.Vars(r)["project"]; project != "" {
+			// https://peps.python.org/pep-0503/#normalized-names
+			if n := NormalizeName(project); n != project {
+				http.Redirect(w, r, strings.TrimSuffix(r.URL.Path, project+"/")+n+"/", http.StatusMovedPermanently)
+				return
+			}
+			dir = path.Join(dir, project)
+		}
+		ctype := negotiate(r.Header.Get("Accept"))
+		if ctype == "" {
+			http.Error(w, "406 not acceptable", http.StatusNotAcceptable)
+			return
+		}
+		name := path.Join(dir, IndexHTML)
+		if ctype == ContentTypeJSON {
+			name = path.Join(dir, IndexJSON)
+		}
+		s := storage.FromContext(ctx)
+		i, err := s.Stat(ctx, name)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		rc, err := s.Open(ctx, name)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		defer rc.Close()
+		w.Header().Set("Content-Type", ctype)
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", i.Size()))
+		w.Header().Set("Vary", "Accept")
+		if _, err := io.Copy(w, rc); err != nil {
+			logger.C(ctx).WithError(err).Error("failed to write index")
+		}
+	}
+}
+
+// upload handles the legacy upload API used by twine
+// https://warehouse.pypa.io/api-reference/legacy.html#upload-api
+func (p *provider) upload(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a := r.FormValue(":action"); a != "file_upload" {
+			http.Error(w, fmt.Sprintf("unsupported action %q", a), http.StatusBadRequest)
+			return
+		}
+		file, h, err := r.FormFile("content")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		packages.Push(func(r *http.Request, _ io.Reader, _ string) (storage.Artifact, error) {
+			pkg, err := NewPackage(file, h.Filename)
+			if err != nil {
+				return nil, err
+			}
+			if d := r.FormValue("sha256_digest"); d != "" && !strings.EqualFold(d, pkg.SHA256) {
+				pkg.Close()
+				return nil, fmt.Errorf("sha256 digest mismatch: expected %s, got %s", d, pkg.SHA256)
+			}
+			if n := r.FormValue("name"); n != "" && NormalizeName(n) != pkg.Project() {
+				pkg.Close()
+				return nil, fmt.Errorf("package name mismatch: expected %s, got %s", n, pkg.PkgName)
+			}
+			return pkg, nil
+		})("")(w, r)
+	}
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/simple/",
+			Handler: p.simple,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/simple/{project}/",
+			Handler: p.simple,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/",
+			Handler: p.upload,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/legacy/",
+			Handler: p.upload,
+		},
+		{
+			Method: http.MethodPut,
+			Path:   "/push",
+			Handler: packages.Push(func(r *http.Request, reader io.Reader, key string) (storage.Artifact, error) {
+				return NewPackage(reader, "")
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/files/{filename}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				return path.Join("files", mux.Vars(r)["filename"])
+			}),
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/files/{filename}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				return path.Join("files", mux.Vars(r)["filename"])
+			}),
+		},
+	}
+}
+
+// negotiate returns the preferred supported content type from the Accept header,
+// the legacy html format is used when the header is missing.
+func negotiate(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return ContentTypeLegacyHTML
+	}
+	var (
+		best string
+		q    = 0.0
+	)
+	for _, v := range strings.Split(accept, ",") {
+		typ, params, _ := strings.Cut(strings.TrimSpace(v), ";")
+		vq := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && k == "q" {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					vq = f
+				}
+			}
+		}
+		switch typ = strings.TrimSpace(typ); typ {
+		case ContentTypeJSON, ContentTypeHTML, ContentTypeLegacyHTML:
+		case "application/*":
+			typ = ContentTypeJSON
+		case "*/*", "text/*":
+			typ = ContentTypeLegacyHTML
+		default:
+			continue
+		}
+		if vq > q {
+			best, q = typ, vq
+		}
+	}
+	return best
+}
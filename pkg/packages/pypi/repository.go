@@ -0,0 +1,196 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pypi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"path"
+	"sort"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	// https://peps.python.org/pep-0691/#versioning
+	APIVersion = "1.1"
+
+	IndexHTML = "index.html"
+	IndexJSON = "index.json"
+)
+
+var (
+	rootTemplate = template.Must(template.New("root").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta name="pypi:repository-version" content="{{ .Meta.APIVersion }}">
+    <title>Simple index</title>
+  </head>
+  <body>
+{{- range .Projects }}
+    <a href="{{ .Name }}/">{{ .Name }}</a>
+{{- end }}
+  </body>
+</html>
+`))
+	projectTemplate = template.Must(template.New("project").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <meta name="pypi:repository-version" content="{{ .Meta.APIVersion }}">
+    <title>Links for {{ .Name }}</title>
+  </head>
+  <body>
+    <h1>Links for {{ .Name }}</h1>
+{{- range .Files }}
+    <a href="{{ .URL }}#sha256={{ .Hashes.sha256 }}"{{ if .RequiresPython }} data-requires-python="{{ .RequiresPython }}"{{ end }}>{{ .Filename }}</a><br/>
+{{- end }}
+  </body>
+</html>
+`))
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "pypi"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "PyPI Registry", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// https://peps.python.org/pep-0691/#json-serialization
+
+type Meta struct {
+	APIVersion string `json:"api-version"`
+}
+
+type ProjectRef struct {
+	Name string `json:"name"`
+}
+
+type RootIndex struct {
+	Meta     Meta         `json:"meta"`
+	Projects []ProjectRef `json:"projects"`
+}
+
+type File struct {
+	Filename       string            `json:"filename"`
+	URL            string            `json:"url"`
+	Hashes         map[string]string `json:"hashes"`
+	RequiresPython string            `json:"requires-python,omitempty"`
+	Size           int64             `json:"size"`
+}
+
+type ProjectIndex struct {
+	Meta     Meta     `json:"meta"`
+	Name     string   `json:"name"`
+	Versions []string `json:"versions"`
+	Files    []File   `json:"files"`
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	projects := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.Project()
+	}))
+	sort.Strings(projects)
+	root := RootIndex{Meta: Meta{APIVersion: APIVersion}}
+	for _, project := range projects {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.Project() == project
+		})
+		sort.Slice(pkgs, func(i, j int) bool {
+			return pkgs[i].Filename < pkgs[j].Filename
+		})
+		versions := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+			return p.PkgVersion
+		}))
+		sort.Strings(versions)
+		i := ProjectIndex{
+			Meta:     root.Meta,
+			Name:     project,
+			Versions: versions,
+			Files: slices.Map(pkgs, func(p *Package) File {
+				return File{
+					Filename: p.Filename,
+					// relative to simple/{project}/
+					URL:            path.Join("..", "..", p.Path()),
+					Hashes:         map[string]string{"sha256": p.SHA256},
+					RequiresPython: p.Metadata.RequiresPython,
+					Size:           p.PkgSize,
+				}
+			}),
+		}
+		files, err := buildIndexFiles(path.Join("simple", project), projectTemplate, i)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, files...)
+		root.Projects = append(root.Projects, ProjectRef{Name: project})
+	}
+	files, err := buildIndexFiles("simple", rootTemplate, root)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, files...), nil
+}
+
+func buildIndexFiles(dir string, tpl *template.Template, v any) ([]storage.Artifact, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, v); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return []storage.Artifact{
+		storage.NewFile(path.Join(dir, IndexHTML), buf.Bytes()),
+		storage.NewFile(path.Join(dir, IndexJSON), b),
+	}, nil
+}
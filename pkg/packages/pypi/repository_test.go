@@ -0,0 +1,90 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pypi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	pkg := func(name, version, filename, requires string) storage.Artifact {
+		return &Package{
+			PkgName:    name,
+			PkgVersion: version,
+			Filename:   filename,
+			FilePath:   "files/" + filename,
+			PkgSize:    42,
+			SHA256:     "sum-" + filename,
+			Metadata:   &Metadata{RequiresPython: requires},
+		}
+	}
+	out, err := (&repo{}).Index(context.Background(), "",
+		pkg("Example_Pkg", "1.1.0", "example_pkg-1.1.0-py3-none-any.whl", ">=3.8"),
+		pkg("example-pkg", "1.0.0", "example_pkg-1.0.0.tar.gz", ""),
+		pkg("other", "0.1.0", "other-0.1.0.tar.gz", ""),
+	)
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = string(b)
+	}
+	assert.Len(t, files, 6)
+
+	var root RootIndex
+	require.NoError(t, json.Unmarshal([]byte(files["simple/index.json"]), &root))
+	assert.Equal(t, RootIndex{
+		Meta:     Meta{APIVersion: APIVersion},
+		Projects: []ProjectRef{{Name: "example-pkg"}, {Name: "other"}},
+	}, root)
+	assert.Contains(t, files["simple/index.html"], `<a href="example-pkg/">example-pkg</a>`)
+
+	var project ProjectIndex
+	require.NoError(t, json.Unmarshal([]byte(files["simple/example-pkg/index.json"]), &project))
+	assert.Equal(t, ProjectIndex{
+		Meta:     Meta{APIVersion: APIVersion},
+		Name:     "example-pkg",
+		Versions: []string{"1.0.0", "1.1.0"},
+		Files: []File{
+			{
+				Filename: "example_pkg-1.0.0.tar.gz",
+				URL:      "../../files/example_pkg-1.0.0.tar.gz",
+				Hashes:   map[string]string{"sha256": "sum-example_pkg-1.0.0.tar.gz"},
+				Size:     42,
+			},
+			{
+				Filename:       "example_pkg-1.1.0-py3-none-any.whl",
+				URL:            "../../files/example_pkg-1.1.0-py3-none-any.whl",
+				Hashes:         map[string]string{"sha256": "sum-example_pkg-1.1.0-py3-none-any.whl"},
+				RequiresPython: ">=3.8",
+				Size:           42,
+			},
+		},
+	}, project)
+	assert.Contains(t, files["simple/example-pkg/index.html"], `<a href="../../files/example_pkg-1.1.0-py3-none-any.whl#sha256=sum-example_pkg-1.1.0-py3-none-any.whl" data-requires-python="&gt;=3.8">example_pkg-1.1.0-py3-none-any.whl</a>`)
+
+	out, err = (&repo{}).Index(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
@@ -22,9 +22,17 @@ import (
 
 type HandlerFunc func(repo string) http.HandlerFunc
 
+// RepositorySeparator separates the repository name from the path of Deep routes
+// in multi-repositories mode, e.g. /go/my-repo/-/example.org/module/@v/list
+const RepositorySeparator = "-"
+
 type Route struct {
-	Method  string
-	Path    string
+	Method string
+	Path   string
+	// Deep marks a route whose path has an arbitrary depth, e.g. "/{module:.+}/@v/list".
+	// As the repository name may also contain slashes, in multi-repositories mode such paths
+	// are prefixed with the RepositorySeparator path element.
+	Deep    bool
 	Handler HandlerFunc
 }
 
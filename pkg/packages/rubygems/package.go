@@ -0,0 +1,254 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rubygems
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"sigs.k8s.io/yaml"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+var (
+	ErrInvalidGem      = errors.New("gem file is invalid")
+	ErrInvalidName     = errors.New("gem name is invalid")
+	ErrInvalidVersion  = errors.New("gem version is invalid")
+	ErrInvalidPlatform = errors.New("gem platform is invalid")
+	ErrMissingMetadata = errors.New("gem metadata not found")
+
+	// https://guides.rubygems.org/name-your-gem/
+	namePattern = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9._-]*\z`)
+	// https://github.com/rubygems/rubygems/blob/master/lib/rubygems/version.rb
+	versionPattern = regexp.MustCompile(`\A[0-9]+(\.[0-9a-zA-Z]+)*(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?\z`)
+	// https://github.com/rubygems/rubygems/blob/master/lib/rubygems/platform.rb
+	platformPattern = regexp.MustCompile(`\A[a-zA-Z0-9_.-]+\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	PkgName      string       `json:"name"`
+	PkgVersion   string       `json:"version"`
+	Platform     string       `json:"platform,omitempty"`
+	Authors      []string     `json:"authors,omitempty"`
+	Summary      string       `json:"summary,omitempty"`
+	Homepage     string       `json:"homepage,omitempty"`
+	Licenses     []string     `json:"licenses,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	Ruby         string       `json:"ruby,omitempty"`
+	Rubygems     string       `json:"rubygems,omitempty"`
+	Published    time.Time    `json:"published"`
+
+	PkgSize  int64  `json:"size"`
+	FilePath string `json:"filePath"`
+
+	SHA256 string `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+type Dependency struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Requirements []string `json:"requirements"`
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.PkgName
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return p.Platform
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// FullVersion returns the version as listed in the compact index, i.e. suffixed
+// by the platform for platform specific gems
+func (p *Package) FullVersion() string {
+	if p.Platform == "" {
+		return p.PkgVersion
+	}
+	return p.PkgVersion + "-" + p.Platform
+}
+
+// GemPath returns the path of the gem file in the repository
+func GemPath(name, version, platform string) string {
+	if platform != "" && platform != "ruby" {
+		version += "-" + platform
+	}
+	return path.Join("gems", fmt.Sprintf("%s-%s.gem", name, version))
+}
+
+// spec is the subset of the Gem::Specification yaml document we use
+// https://guides.rubygems.org/specification-reference/
+type spec struct {
+	Name    string `json:"name"`
+	Version struct {
+		Version string `json:"version"`
+	} `json:"version"`
+	Platform     string   `json:"platform"`
+	Authors      []string `json:"authors"`
+	Summary      string   `json:"summary"`
+	Homepage     string   `json:"homepage"`
+	Licenses     []string `json:"licenses"`
+	Dependencies []struct {
+		Name        string      `json:"name"`
+		Requirement requirement `json:"requirement"`
+		Type        string      `json:"type"`
+	} `json:"dependencies"`
+	RequiredRubyVersion     requirement `json:"required_ruby_version"`
+	RequiredRubygemsVersion requirement `json:"required_rubygems_version"`
+}
+
+// requirement is a Gem::Requirement: a list of [operator, Gem::Version] pairs
+type requirement struct {
+	Requirements [][]any `json:"requirements"`
+}
+
+func (r requirement) strings() (out []string) {
+	for _, v := range r.Requirements {
+		if len(v) != 2 {
+			continue
+		}
+		op, _ := v[0].(string)
+		m, _ := v[1].(map[string]any)
+		out = append(out, fmt.Sprintf("%s %v", op, m["version"]))
+	}
+	return out
+}
+
+// NewPackage parses a gem file: a tarball containing the gzipped yaml specification (metadata.gz)
+func NewPackage(r io.Reader) (*Package, error) {
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	s, err := readSpec(io.NewSectionReader(reader, 0, reader.Size()))
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	if !namePattern.MatchString(s.Name) {
+		reader.Close()
+		return nil, fmt.Errorf("%w: %s", ErrInvalidName, s.Name)
+	}
+	if !versionPattern.MatchString(s.Version.Version) {
+		reader.Close()
+		return nil, fmt.Errorf("%w: %s", ErrInvalidVersion, s.Version.Version)
+	}
+	// the platform is part of the gem file path
+	if s.Platform != "" && !platformPattern.MatchString(s.Platform) {
+		reader.Close()
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPlatform, s.Platform)
+	}
+	pkg := &Package{
+		PkgName:    s.Name,
+		PkgVersion: s.Version.Version,
+		Authors:    s.Authors,
+		Summary:    s.Summary,
+		Homepage:   s.Homepage,
+		Licenses:   s.Licenses,
+		Ruby:       strings.Join(s.RequiredRubyVersion.strings(), "&"),
+		Rubygems:   strings.Join(s.RequiredRubygemsVersion.strings(), "&"),
+		Published:  time.Now().UTC(),
+		PkgSize:    reader.Size(),
+		FilePath:   GemPath(s.Name, s.Version.Version, s.Platform),
+		reader:     reader,
+	}
+	if s.Platform != "ruby" {
+		pkg.Platform = s.Platform
+	}
+	for _, v := range s.Dependencies {
+		pkg.Dependencies = append(pkg.Dependencies, Dependency{
+			Name:         v.Name,
+			Type:         strings.TrimPrefix(v.Type, ":"),
+			Requirements: v.Requirement.strings(),
+		})
+	}
+	_, _, sha256, _ := reader.Sums()
+	pkg.SHA256 = hex.EncodeToString(sha256)
+	_, err = reader.Seek(0, io.SeekStart)
+	return pkg, err
+}
+
+func readSpec(r io.Reader) (*spec, error) {
+	tr := tar.NewReader(r)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrMissingMetadata
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGem, err)
+		}
+		if hd.Name != "metadata.gz" {
+			continue
+		}
+		gzr, err := gzip.NewReader(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGem, err)
+		}
+		b, err := io.ReadAll(gzr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGem, err)
+		}
+		var s spec
+		if err := yaml.Unmarshal(b, &s); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGem, err)
+		}
+		return &s, nil
+	}
+}
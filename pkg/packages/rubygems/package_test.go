@@ -0,0 +1,158 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rubygems
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `--- !ruby/object:Gem::Specification
+name: example
+version: !ruby/object:Gem::Version
+  version: 1.0.0
+platform: x86_64-linux
+authors:
+- John Doe
+summary: An example gem
+homepage: https://example.org
+licenses:
+- MIT
+dependencies:
+- !ruby/object:Gem::Dependency
+  name: rake
+  requirement: !ruby/object:Gem::Requirement
+    requirements:
+    - - ">="
+      - !ruby/object:Gem::Version
+        version: '12.0'
+    - - "<"
+      - !ruby/object:Gem::Version
+        version: '14'
+  type: :runtime
+- !ruby/object:Gem::Dependency
+  name: rspec
+  requirement: !ruby/object:Gem::Requirement
+    requirements:
+    - - "~>"
+      - !ruby/object:Gem::Version
+        version: '3.0'
+  type: :development
+required_ruby_version: !ruby/object:Gem::Requirement
+  requirements:
+  - - ">="
+    - !ruby/object:Gem::Version
+      version: 2.7.0
+required_rubygems_version: !ruby/object:Gem::Requirement
+  requirements:
+  - - ">="
+    - !ruby/object:Gem::Version
+      version: '0'
+`
+
+func testGem(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for name, content := range files {
+		data := []byte(content)
+		if name == "metadata.gz" {
+			var gz bytes.Buffer
+			gzw := gzip.NewWriter(&gz)
+			_, err := gzw.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, gzw.Close())
+			data = gz.Bytes()
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return b.Bytes()
+}
+
+func TestNewPackage(t *testing.T) {
+	pkg, err := NewPackage(bytes.NewReader(testGem(t, map[string]string{
+		"metadata.gz": testSpec,
+		"data.tar.gz": "",
+	})))
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, "example", pkg.Name())
+	assert.Equal(t, "1.0.0", pkg.Version())
+	assert.Equal(t, "x86_64-linux", pkg.Platform)
+	assert.Equal(t, "1.0.0-x86_64-linux", pkg.FullVersion())
+	assert.Equal(t, "gems/example-1.0.0-x86_64-linux.gem", pkg.Path())
+	assert.Equal(t, []string{"John Doe"}, pkg.Authors)
+	assert.Equal(t, []string{"MIT"}, pkg.Licenses)
+	assert.Equal(t, []Dependency{
+		{Name: "rake", Type: "runtime", Requirements: []string{">= 12.0", "< 14"}},
+		{Name: "rspec", Type: "development", Requirements: []string{"~> 3.0"}},
+	}, pkg.Dependencies)
+	assert.Equal(t, ">= 2.7.0", pkg.Ruby)
+	assert.Equal(t, ">= 0", pkg.Rubygems)
+	assert.Len(t, pkg.SHA256, 64)
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr error
+	}{
+		{
+			name:    "missing metadata",
+			files:   map[string]string{"data.tar.gz": ""},
+			wantErr: ErrMissingMetadata,
+		},
+		{
+			name:    "invalid name",
+			files:   map[string]string{"metadata.gz": "name: -example\nversion:\n  version: 1.0.0\n"},
+			wantErr: ErrInvalidName,
+		},
+		{
+			name:    "invalid version",
+			files:   map[string]string{"metadata.gz": "name: example\nversion:\n  version: latest\n"},
+			wantErr: ErrInvalidVersion,
+		},
+		{
+			name:    "traversing platform",
+			files:   map[string]string{"metadata.gz": "name: example\nversion:\n  version: 1.0.0\nplatform: ../../../other\n"},
+			wantErr: ErrInvalidPlatform,
+		},
+		{
+			name:    "invalid platform",
+			files:   map[string]string{"metadata.gz": "name: example\nversion:\n  version: 1.0.0\nplatform: \"x86_64 linux\"\n"},
+			wantErr: ErrInvalidPlatform,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(testGem(t, tt.files)))
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestGemPath(t *testing.T) {
+	assert.Equal(t, "gems/example-1.0.0.gem", GemPath("example", "1.0.0", ""))
+	assert.Equal(t, "gems/example-1.0.0.gem", GemPath("example", "1.0.0", "ruby"))
+	assert.Equal(t, "gems/example-1.0.0-java.gem", GemPath("example", "1.0.0", "java"))
+}
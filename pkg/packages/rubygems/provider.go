@@ -0,0 +1,151 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rubygems
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "rubygems"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// push handles the gem push requests.
+// As gem sends the api key as is in the Authorization header, the registry credentials
+// must be configured as a basic auth header value, e.g. GEM_HOST_API_KEY="Basic $(echo -n user:pass | base64)"
+// https://guides.rubygems.org/rubygems-org-api/#gem-methods
+func (p *provider) push(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		pkg, err := NewPackage(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer pkg.Close()
+		s := storage.FromContext(ctx)
+		if err := s.Init(ctx); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		if _, err := s.Stat(ctx, pkg.Path()); err == nil {
+			http.Error(w, fmt.Sprintf("gem %s (%s) already exists", pkg.PkgName, pkg.FullVersion()), http.StatusConflict)
+			return
+		} else if !storage.IsNotFound(err) {
+			storage.Error(w, err)
+			return
+		}
+		logger.C(ctx).WithFields("name", pkg.Name(), "version", pkg.FullVersion()).Infof("pushing gem")
+		if err := s.Write(ctx, pkg); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		fmt.Fprintf(w, "Successfully registered gem: %s (%s)", pkg.PkgName, pkg.FullVersion())
+	}
+}
+
+// yank removes the gem version from the repository
+// https://guides.rubygems.org/rubygems-org-api/#delete-apiv1gemsyank
+func (p *provider) yank(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		name, version := r.FormValue("gem_name"), r.FormValue("version")
+		if name == "" || version == "" {
+			http.Error(w, "gem_name and version are required", http.StatusBadRequest)
+			return
+		}
+		platform := r.FormValue("platform")
+		if !namePattern.MatchString(name) || !versionPattern.MatchString(version) || (platform != "" && !platformPattern.MatchString(platform)) {
+			http.Error(w, "invalid gem_name, version or platform", http.StatusBadRequest)
+			return
+		}
+		if err := storage.FromContext(ctx).Delete(ctx, GemPath(name, version, platform)); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		fmt.Fprintf(w, "Successfully deleted gem: %s (%s)", name, version)
+	}
+}
+
+func gemPath(r *http.Request) string {
+	return path.Join("gems", mux.Vars(r)["file"])
+}
+
+func infoPath(r *http.Request) string {
+	return path.Join(InfoDir, mux.Vars(r)["name"])
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/v1/gems",
+			Handler: p.push,
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/api/v1/gems/yank",
+			Handler: p.yank,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + VersionsFile,
+			Handler: packages.Pull(func(_ *http.Request) string { return VersionsFile }),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + NamesFile,
+			Handler: packages.Pull(func(_ *http.Request) string { return NamesFile }),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/" + InfoDir + "/{name}",
+			Handler: packages.Pull(infoPath),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/gems/{file}",
+			Handler: packages.Pull(gemPath),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/gems/{file}",
+			Handler: packages.Delete(gemPath),
+		},
+	}
+}
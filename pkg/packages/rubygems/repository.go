@@ -0,0 +1,134 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rubygems
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	VersionsFile = "versions"
+	NamesFile    = "names"
+	InfoDir      = "info"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "rubygems"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "RubyGems Registry", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Index generates the compact index files used by bundler
+// https://guides.rubygems.org/rubygems-org-compact-index-api/
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	// versions are listed in publication order
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Published.Before(pkgs[j].Published)
+	})
+	names := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.PkgName
+	}))
+	sort.Strings(names)
+	var last time.Time
+	for _, v := range pkgs {
+		if v.Published.After(last) {
+			last = v.Published
+		}
+	}
+	versions := bytes.NewBufferString(fmt.Sprintf("created_at: %s\n---\n", last.Format(time.RFC3339)))
+	for _, n := range names {
+		pkgs := slices.Filter(pkgs, func(v *Package) bool {
+			return v.PkgName == n
+		})
+		info := bytes.NewBufferString("---\n")
+		for _, v := range pkgs {
+			info.WriteString(infoLine(v) + "\n")
+		}
+		sum := md5.Sum(info.Bytes())
+		fmt.Fprintf(versions, "%s %s %s\n", n, strings.Join(slices.Map(pkgs, (*Package).FullVersion), ","), hex.EncodeToString(sum[:]))
+		out = append(out, storage.NewFile(path.Join(InfoDir, n), info.Bytes()))
+	}
+	out = append(out,
+		storage.NewFile(VersionsFile, versions.Bytes()),
+		storage.NewFile(NamesFile, []byte("---\n"+strings.Join(names, "\n")+"\n")),
+	)
+	return out, nil
+}
+
+// infoLine returns the gem version's line of the info file, e.g.
+// 1.0.0 rake:>= 12.0&< 14,json:>= 0|checksum:{sha256},ruby:>= 2.7
+func infoLine(p *Package) string {
+	var deps []string
+	for _, v := range p.Dependencies {
+		if v.Type != "runtime" {
+			continue
+		}
+		deps = append(deps, v.Name+":"+strings.Join(v.Requirements, "&"))
+	}
+	reqs := []string{"checksum:" + p.SHA256}
+	if p.Ruby != "" {
+		reqs = append(reqs, "ruby:"+p.Ruby)
+	}
+	if p.Rubygems != "" {
+		reqs = append(reqs, "rubygems:"+p.Rubygems)
+	}
+	return fmt.Sprintf("%s %s|%s", p.FullVersion(), strings.Join(deps, ","), strings.Join(reqs, ","))
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rubygems
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	out, err := (&repo{}).Index(context.Background(), "",
+		&Package{
+			PkgName:    "example",
+			PkgVersion: "1.1.0",
+			Platform:   "java",
+			Published:  now.Add(time.Hour),
+			SHA256:     "sum-1.1.0-java",
+		},
+		&Package{
+			PkgName:    "example",
+			PkgVersion: "1.0.0",
+			Dependencies: []Dependency{
+				{Name: "rake", Type: "runtime", Requirements: []string{">= 12.0", "< 14"}},
+				{Name: "rspec", Type: "development", Requirements: []string{"~> 3.0"}},
+				{Name: "json", Type: "runtime", Requirements: []string{">= 0"}},
+			},
+			Ruby:      ">= 2.7.0",
+			Rubygems:  "> 1.3.1",
+			Published: now,
+			SHA256:    "sum-1.0.0",
+		},
+		&Package{PkgName: "abc", PkgVersion: "0.1.0", Published: now.Add(2 * time.Hour), SHA256: "sum-0.1.0"},
+	)
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = string(b)
+	}
+	require.Len(t, files, 4)
+
+	info := "---\n" +
+		"1.0.0 rake:>= 12.0&< 14,json:>= 0|checksum:sum-1.0.0,ruby:>= 2.7.0,rubygems:> 1.3.1\n" +
+		"1.1.0-java |checksum:sum-1.1.0-java\n"
+	assert.Equal(t, info, files["info/example"])
+	assert.Equal(t, "---\n0.1.0 |checksum:sum-0.1.0\n", files["info/abc"])
+	assert.Equal(t, "---\nabc\nexample\n", files[NamesFile])
+
+	// the versions file references the info files by their md5 checksum
+	sum := func(s string) string {
+		h := md5.Sum([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+	assert.Equal(t, "created_at: 2023-01-01T02:00:00Z\n---\n"+
+		"abc 0.1.0 "+sum(files["info/abc"])+"\n"+
+		"example 1.0.0,1.1.0-java "+sum(info)+"\n", files[VersionsFile])
+}
@@ -0,0 +1,253 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	KindModule   = "module"
+	KindProvider = "provider"
+
+	ModulesDir   = "modules"
+	ProvidersDir = "providers"
+
+	DefaultProtocol = "5.0"
+)
+
+var (
+	ErrInvalidArchive = errors.New("archive is invalid")
+	ErrInvalidAddress = errors.New("address is invalid")
+	ErrInvalidVersion = errors.New("version is invalid")
+
+	// namePattern matches the namespaces, names, systems, provider types, os and architectures
+	namePattern     = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}\z`)
+	protocolPattern = regexp.MustCompile(`\A[0-9]+\.[0-9]+\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+// Package is either a module archive or a provider platform zip archive
+type Package struct {
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	PkgName    string `json:"name"`
+	PkgVersion string `json:"version"`
+
+	// System is the module target system, e.g. aws
+	System string `json:"system,omitempty"`
+
+	// OS, Architecture and Protocols are set for providers
+	OS           string   `json:"os,omitempty"`
+	Architecture string   `json:"architecture,omitempty"`
+	Protocols    []string `json:"protocols,omitempty"`
+
+	Published time.Time `json:"published"`
+	PkgSize   int64     `json:"size"`
+	FilePath  string    `json:"filePath"`
+	SHA256    string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	if p.Kind == KindModule {
+		return path.Join(p.Namespace, p.PkgName, p.System)
+	}
+	return path.Join(p.Namespace, p.PkgName)
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	if p.Kind == KindModule {
+		return ""
+	}
+	return p.OS + "_" + p.Architecture
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+func (p *Package) FileName() string {
+	return path.Base(p.FilePath)
+}
+
+// ProviderFileName returns the provider platform archive name, e.g. terraform-provider-example_1.0.0_linux_amd64.zip
+func ProviderFileName(typ, version, os, arch string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", typ, version, os, arch)
+}
+
+// SumsFileName returns the provider version checksums file name, e.g. terraform-provider-example_1.0.0_SHA256SUMS
+func SumsFileName(typ, version string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS", typ, version)
+}
+
+func ModuleDir(namespace, name, system string) string {
+	return path.Join(ModulesDir, namespace, name, system)
+}
+
+func ProviderDir(namespace, typ, version string) string {
+	return path.Join(ProvidersDir, namespace, typ, version)
+}
+
+// NewModule creates a module package from a tar.gz or zip archive
+func NewModule(r io.Reader, namespace, name, system, version string) (*Package, error) {
+	if err := validate(namespace, name, system); err != nil {
+		return nil, err
+	}
+	v, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	ext, err := archiveExt(reader)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	pkg := &Package{
+		Kind:       KindModule,
+		Namespace:  namespace,
+		PkgName:    name,
+		System:     system,
+		PkgVersion: v,
+		FilePath:   path.Join(ModuleDir(namespace, name, system), v+ext),
+	}
+	return pkg, pkg.setReader(reader)
+}
+
+// NewProvider creates a provider package from a platform zip archive
+func NewProvider(r io.Reader, namespace, typ, version, os, arch string, protocols ...string) (*Package, error) {
+	if err := validate(namespace, typ, os, arch); err != nil {
+		return nil, err
+	}
+	v, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	if len(protocols) == 0 {
+		protocols = []string{DefaultProtocol}
+	}
+	for _, p := range protocols {
+		if !protocolPattern.MatchString(p) {
+			return nil, fmt.Errorf("%w: invalid protocol %q", ErrInvalidAddress, p)
+		}
+	}
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if ext, err := archiveExt(reader); err != nil || ext != ".zip" {
+		reader.Close()
+		return nil, fmt.Errorf("%w: provider archive must be a zip file", ErrInvalidArchive)
+	}
+	pkg := &Package{
+		Kind:         KindProvider,
+		Namespace:    namespace,
+		PkgName:      typ,
+		PkgVersion:   v,
+		OS:           os,
+		Architecture: arch,
+		Protocols:    protocols,
+		FilePath:     path.Join(ProviderDir(namespace, typ, v), ProviderFileName(typ, v, os, arch)),
+	}
+	return pkg, pkg.setReader(reader)
+}
+
+func (p *Package) setReader(reader *buffer.HashedBuffer) error {
+	_, _, sha256, _ := reader.Sums()
+	p.SHA256 = hex.EncodeToString(sha256)
+	p.PkgSize = reader.Size()
+	p.Published = time.Now().UTC()
+	p.reader = reader
+	_, err := reader.Seek(0, io.SeekStart)
+	return err
+}
+
+func validate(parts ...string) error {
+	for _, v := range parts {
+		if !namePattern.MatchString(v) {
+			return fmt.Errorf("%w: %q", ErrInvalidAddress, v)
+		}
+	}
+	return nil
+}
+
+func parseVersion(version string) (string, error) {
+	v, err := semver.StrictNewVersion(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidVersion, err)
+	}
+	return v.String(), nil
+}
+
+func archiveExt(r io.ReaderAt) (string, error) {
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return ".tar.gz", nil
+	case bytes.Equal(magic, []byte{'P', 'K', 0x03, 0x04}):
+		return ".zip", nil
+	default:
+		return "", fmt.Errorf("%w: unsupported format", ErrInvalidArchive)
+	}
+}
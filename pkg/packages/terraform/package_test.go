@@ -0,0 +1,128 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testZip(t *testing.T) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	w, err := zw.Create("terraform-provider-example_v1.0.0")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("binary"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func testTarGz(t *testing.T) []byte {
+	var b bytes.Buffer
+	gzw := gzip.NewWriter(&b)
+	_, err := gzw.Write([]byte("tarball"))
+	require.NoError(t, err)
+	require.NoError(t, gzw.Close())
+	return b.Bytes()
+}
+
+func TestNewModule(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		namespace string
+		version   string
+		wantPath  string
+		wantErr   error
+	}{
+		{
+			name:      "tar.gz",
+			data:      testTarGz(t),
+			namespace: "example",
+			version:   "v1.0.0",
+			wantPath:  "modules/example/vpc/aws/1.0.0.tar.gz",
+		},
+		{
+			name:      "zip",
+			data:      testZip(t),
+			namespace: "example",
+			version:   "1.1.0-rc.1",
+			wantPath:  "modules/example/vpc/aws/1.1.0-rc.1.zip",
+		},
+		{
+			name:      "invalid namespace",
+			data:      testZip(t),
+			namespace: "../example",
+			version:   "1.0.0",
+			wantErr:   ErrInvalidAddress,
+		},
+		{
+			name:      "invalid version",
+			data:      testZip(t),
+			namespace: "example",
+			version:   "1.0",
+			wantErr:   ErrInvalidVersion,
+		},
+		{
+			name:      "invalid archive",
+			data:      []byte("not an archive"),
+			namespace: "example",
+			version:   "1.0.0",
+			wantErr:   ErrInvalidArchive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := NewModule(bytes.NewReader(tt.data), tt.namespace, "vpc", "aws", tt.version)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, KindModule, pkg.Kind)
+			assert.Equal(t, tt.wantPath, pkg.Path())
+			assert.Equal(t, int64(len(tt.data)), pkg.Size())
+			assert.Len(t, pkg.SHA256, 64)
+		})
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	pkg, err := NewProvider(bytes.NewReader(testZip(t)), "example", "example", "1.0.0", "linux", "amd64")
+	require.NoError(t, err)
+	defer pkg.Close()
+	assert.Equal(t, KindProvider, pkg.Kind)
+	assert.Equal(t, "providers/example/example/1.0.0/terraform-provider-example_1.0.0_linux_amd64.zip", pkg.Path())
+	assert.Equal(t, "terraform-provider-example_1.0.0_linux_amd64.zip", pkg.FileName())
+	assert.Equal(t, []string{DefaultProtocol}, pkg.Protocols)
+
+	pkg, err = NewProvider(bytes.NewReader(testZip(t)), "example", "example", "1.0.0", "linux", "amd64", "5.0", "6.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5.0", "6.0"}, pkg.Protocols)
+
+	_, err = NewProvider(bytes.NewReader(testZip(t)), "example", "example", "1.0.0", "linux", "amd64", "5")
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+	_, err = NewProvider(bytes.NewReader(testTarGz(t)), "example", "example", "1.0.0", "linux", "amd64")
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+	_, err = NewProvider(bytes.NewReader(testZip(t)), "example", "example", "1.0.0", "linux", "amd/64")
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+}
@@ -0,0 +1,390 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	Name = "terraform"
+
+	DiscoveryPath = "/.well-known/terraform.json"
+	ModulesPath   = "/v1/modules/"
+	ProvidersPath = "/v1/providers/"
+)
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// discovery serves the service discovery document, terraform only looks it up at the host root,
+// so the repository must be served on its own host, e.g. with the terraform subdomain in single repository mode.
+// As terraform sends the credentials as a bearer token, they must be configured as the base64 encoded "user:password"
+// https://developer.hashicorp.com/terraform/internals/remote-service-discovery
+func (p *provider) discovery(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		base := strings.TrimSuffix(r.URL.Path, DiscoveryPath)
+		writeJSON(w, http.StatusOK, map[string]string{
+			"modules.v1":   base + ModulesPath,
+			"providers.v1": base + ProvidersPath,
+		})
+	}
+}
+
+// https://developer.hashicorp.com/terraform/internals/module-registry-protocol#list-available-versions-for-a-specific-module
+func (p *provider) moduleVersions(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		pkgs, err := list(r.Context(), func(p *Package) bool {
+			return p.Kind == KindModule && p.Namespace == vars["namespace"] && p.PkgName == vars["name"] && p.System == vars["system"]
+		})
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		if len(pkgs) == 0 {
+			writeError(w, http.StatusNotFound, errors.New("module not found"))
+			return
+		}
+		versions := slices.Map(pkgs, func(p *Package) map[string]string {
+			return map[string]string{"version": p.PkgVersion}
+		})
+		writeJSON(w, http.StatusOK, map[string]any{
+			"modules": []map[string]any{{"versions": versions}},
+		})
+	}
+}
+
+// https://developer.hashicorp.com/terraform/internals/module-registry-protocol#download-source-code-for-a-specific-module-version
+func (p *provider) moduleDownload(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		pkgs, err := list(r.Context(), func(p *Package) bool {
+			return p.Kind == KindModule && p.Namespace == vars["namespace"] && p.PkgName == vars["name"] && p.System == vars["system"] && p.PkgVersion == vars["version"]
+		})
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		if len(pkgs) == 0 {
+			writeError(w, http.StatusNotFound, errors.New("module version not found"))
+			return
+		}
+		u, err := downloadURL(r, ModulesPath, pkgs[0].FilePath)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("X-Terraform-Get", u)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#list-available-versions
+func (p *provider) providerVersions(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		pkgs, err := list(r.Context(), func(p *Package) bool {
+			return p.Kind == KindProvider && p.Namespace == vars["namespace"] && p.PkgName == vars["type"]
+		})
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		if len(pkgs) == 0 {
+			writeError(w, http.StatusNotFound, errors.New("provider not found"))
+			return
+		}
+		type platform struct {
+			OS   string `json:"os"`
+			Arch string `json:"arch"`
+		}
+		type version struct {
+			Version   string     `json:"version"`
+			Protocols []string   `json:"protocols"`
+			Platforms []platform `json:"platforms"`
+		}
+		var versions []*version
+		for _, v := range pkgs {
+			if len(versions) == 0 || versions[len(versions)-1].Version != v.PkgVersion {
+				versions = append(versions, &version{Version: v.PkgVersion})
+			}
+			last := versions[len(versions)-1]
+			last.Protocols = slices.Distinct(append(last.Protocols, v.Protocols...))
+			last.Platforms = append(last.Platforms, platform{OS: v.OS, Arch: v.Architecture})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"versions": versions})
+	}
+}
+
+// https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#find-a-provider-package
+func (p *provider) providerDownload(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		s := storage.FromContext(ctx)
+		dir := ProviderDir(vars["namespace"], vars["type"], vars["version"])
+		i, err := s.Stat(ctx, path.Join(dir, ProviderFileName(vars["type"], vars["version"], vars["os"], vars["arch"])))
+		if err != nil {
+			if storage.IsNotFound(err) {
+				writeError(w, http.StatusNotFound, errors.New("provider package not found"))
+				return
+			}
+			storage.Error(w, err)
+			return
+		}
+		var pkg Package
+		if err := json.Unmarshal(i.Meta(), &pkg); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		rc, err := s.Open(ctx, RepositoryPublicKey)
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		defer rc.Close()
+		pub, err := io.ReadAll(rc)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		keys, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(pub))
+		if err != nil || len(keys) == 0 {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("invalid repository key: %v", err))
+			return
+		}
+		sums := path.Join(dir, SumsFileName(pkg.PkgName, pkg.PkgVersion))
+		urls := make([]string, 3)
+		for i, v := range []string{pkg.FilePath, sums, sums + SigExt} {
+			if urls[i], err = downloadURL(r, ProvidersPath, v); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"protocols":             pkg.Protocols,
+			"os":                    pkg.OS,
+			"arch":                  pkg.Architecture,
+			"filename":              pkg.FileName(),
+			"download_url":          urls[0],
+			"shasums_url":           urls[1],
+			"shasums_signature_url": urls[2],
+			"shasum":                pkg.SHA256,
+			"signing_keys": map[string]any{
+				"gpg_public_keys": []map[string]string{{
+					"key_id":      keys[0].PrimaryKey.KeyIdString(),
+					"ascii_armor": string(pub),
+				}},
+			},
+		})
+	}
+}
+
+// push stores the uploaded module or provider archive, which cannot be overwritten
+func (p *provider) push(fn func(r *http.Request, reader io.Reader) (*Package, error)) packages.HandlerFunc {
+	return func(_ string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			defer r.Body.Close()
+			pkg, err := fn(r, r.Body)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			defer pkg.Close()
+			s := storage.FromContext(ctx)
+			if err := s.Init(ctx); err != nil {
+				storage.Error(w, err)
+				return
+			}
+			if _, err := s.Stat(ctx, pkg.Path()); err == nil {
+				writeError(w, http.StatusConflict, fmt.Errorf("%s %s version %s already exists", pkg.Kind, pkg.Name(), pkg.PkgVersion))
+				return
+			} else if !storage.IsNotFound(err) {
+				storage.Error(w, err)
+				return
+			}
+			logger.C(ctx).WithFields("name", pkg.Name(), "filepath", pkg.Path(), "arch", pkg.Arch()).Infof("uploading artifact")
+			if err := s.Write(ctx, pkg); err != nil {
+				storage.Error(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}
+	}
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    DiscoveryPath,
+			Handler: p.discovery,
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + RepositoryPublicKey,
+			Handler: packages.Pull(func(r *http.Request) string {
+				return RepositoryPublicKey
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    ModulesPath + "{namespace}/{name}/{system}/versions",
+			Handler: p.moduleVersions,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    ModulesPath + "{namespace}/{name}/{system}/{version}/download",
+			Handler: p.moduleDownload,
+		},
+		{
+			Method: http.MethodPut,
+			Path:   ModulesPath + "{namespace}/{name}/{system}/{version}",
+			Handler: p.push(func(r *http.Request, reader io.Reader) (*Package, error) {
+				vars := mux.Vars(r)
+				return NewModule(reader, vars["namespace"], vars["name"], vars["system"], vars["version"])
+			}),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    ProvidersPath + "{namespace}/{type}/versions",
+			Handler: p.providerVersions,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    ProvidersPath + "{namespace}/{type}/{version}/download/{os}/{arch}",
+			Handler: p.providerDownload,
+		},
+		{
+			Method: http.MethodPut,
+			Path:   ProvidersPath + "{namespace}/{type}/{version}/{os}/{arch}",
+			Handler: p.push(func(r *http.Request, reader io.Reader) (*Package, error) {
+				vars := mux.Vars(r)
+				var protocols []string
+				if v := r.URL.Query().Get("protocols"); v != "" {
+					protocols = strings.Split(v, ",")
+				}
+				return NewProvider(reader, vars["namespace"], vars["type"], vars["version"], vars["os"], vars["arch"], protocols...)
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + ModulesDir + "/{namespace}/{name}/{system}/{filename}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				vars := mux.Vars(r)
+				return path.Join(ModuleDir(vars["namespace"], vars["name"], vars["system"]), vars["filename"])
+			}),
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/" + ModulesDir + "/{namespace}/{name}/{system}/{filename}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				vars := mux.Vars(r)
+				return path.Join(ModuleDir(vars["namespace"], vars["name"], vars["system"]), vars["filename"])
+			}),
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/" + ProvidersDir + "/{namespace}/{type}/{version}/{filename}",
+			Handler: packages.Pull(func(r *http.Request) string {
+				vars := mux.Vars(r)
+				return path.Join(ProviderDir(vars["namespace"], vars["type"], vars["version"]), vars["filename"])
+			}),
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/" + ProvidersDir + "/{namespace}/{type}/{version}/{filename}",
+			Handler: packages.Delete(func(r *http.Request) string {
+				vars := mux.Vars(r)
+				return path.Join(ProviderDir(vars["namespace"], vars["type"], vars["version"]), vars["filename"])
+			}),
+		},
+	}
+}
+
+// list returns the matching packages sorted by version
+func list(ctx context.Context, fn func(p *Package) bool) ([]*Package, error) {
+	as, err := storage.FromContext(ctx).Artifacts(ctx)
+	if err != nil {
+		if storage.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	pkgs := slices.Filter(storage.MustAs[*Package](as), fn)
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		return semver.MustParse(pkgs[i].PkgVersion).LessThan(semver.MustParse(pkgs[j].PkgVersion))
+	})
+	return pkgs, nil
+}
+
+// downloadURL returns the absolute url of the file, terraform does not send its credentials
+// when downloading the archives, so the url is signed with a token granting read access to the file only
+func downloadURL(r *http.Request, prefix, file string) (string, error) {
+	base := r.URL.Path[:strings.LastIndex(r.URL.Path, prefix)]
+	u := &url.URL{
+		Scheme: packages.Scheme(r),
+		Host:   r.Host,
+		Path:   path.Join(base, file),
+	}
+	return storage.SignURL(r.Context(), u.String())
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error in the format expected by terraform
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]any{
+		"errors": []string{err.Error()},
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/auth"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestDiscovery(t *testing.T) {
+	w := httptest.NewRecorder()
+	(&provider{}).discovery("")(w, httptest.NewRequest(http.MethodGet, "/terraform/repo"+DiscoveryPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, map[string]string{
+		"modules.v1":   "/terraform/repo" + ModulesPath,
+		"providers.v1": "/terraform/repo" + ProvidersPath,
+	}, got)
+}
+
+func TestDownloadURL(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "https://example.org/terraform/repo/v1/providers/example/example/1.0.0/download/linux/amd64", nil)
+	u, err := downloadURL(r, ProvidersPath, "providers/example/example/1.0.0/file.zip")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org/terraform/repo/providers/example/example/1.0.0/file.zip", u)
+
+	// the credentials are never part of the url, only a token granting read access to the file is
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	require.NoError(t, err)
+	r.SetBasicAuth("user", "pass")
+	r = r.WithContext(auth.Context(storage.WithOptions(r.Context(), storage.WithKey(key)), auth.FromRequest(r)))
+	u, err = downloadURL(r, ProvidersPath, "providers/example/example/1.0.0/file.zip")
+	require.NoError(t, err)
+	v, err := url.Parse(u)
+	require.NoError(t, err)
+	assert.Nil(t, v.User)
+	assert.NotContains(t, u, "pass")
+	token := v.Query().Get(auth.TokenParam)
+	a, err := auth.FromToken(key, token, http.MethodGet, "", v.Path)
+	require.NoError(t, err)
+	user, pass, ok := a.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", user)
+	assert.Equal(t, "pass", pass)
+	_, err = auth.FromToken(key, token, http.MethodPut, "", v.Path)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	_, err = auth.FromToken(key, token, http.MethodGet, "", "/terraform/repo/providers/example/example/1.0.0/terraform-provider-example_1.0.0_SHA256SUMS")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
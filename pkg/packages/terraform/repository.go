@@ -0,0 +1,99 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	SigExt = ".sig"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "terraform"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Terraform Registry", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Index generates the signed SHA256SUMS file of each provider version
+func (r *repo) Index(_ context.Context, priv string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := slices.Filter(storage.MustAs[*Package](as), func(p *Package) bool {
+		return p.Kind == KindProvider
+	})
+	dirs := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return ProviderDir(p.Namespace, p.PkgName, p.PkgVersion)
+	}))
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return ProviderDir(p.Namespace, p.PkgName, p.PkgVersion) == dir
+		})
+		sort.Slice(pkgs, func(i, j int) bool {
+			return pkgs[i].FileName() < pkgs[j].FileName()
+		})
+		var sums bytes.Buffer
+		for _, v := range pkgs {
+			fmt.Fprintf(&sums, "%s  %s\n", v.SHA256, v.FileName())
+		}
+		var sig bytes.Buffer
+		if err := openpgp.DetachSign(&sig, priv, bytes.NewReader(sums.Bytes())); err != nil {
+			return nil, err
+		}
+		name := path.Join(dir, SumsFileName(pkgs[0].PkgName, pkgs[0].PkgVersion))
+		out = append(out,
+			storage.NewFile(name, sums.Bytes()),
+			storage.NewFile(name+SigExt, sig.Bytes()),
+		)
+	}
+	return out, nil
+}
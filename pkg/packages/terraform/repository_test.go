@@ -0,0 +1,70 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+func TestIndex(t *testing.T) {
+	priv, pub, err := (&repo{}).GenerateKeypair()
+	require.NoError(t, err)
+	provider := func(version, os, arch string) storage.Artifact {
+		return &Package{
+			Kind:         KindProvider,
+			Namespace:    "example",
+			PkgName:      "example",
+			PkgVersion:   version,
+			OS:           os,
+			Architecture: arch,
+			FilePath:     ProviderDir("example", "example", version) + "/" + ProviderFileName("example", version, os, arch),
+			SHA256:       "sum-" + version + "-" + os + "-" + arch,
+		}
+	}
+	out, err := (&repo{}).Index(context.Background(), priv,
+		provider("1.0.0", "linux", "amd64"),
+		provider("1.0.0", "darwin", "arm64"),
+		provider("2.0.0", "linux", "amd64"),
+		// modules are not indexed
+		&Package{Kind: KindModule, Namespace: "example", PkgName: "vpc", System: "aws", PkgVersion: "1.0.0"},
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	require.Len(t, files, 4)
+
+	sums := files["providers/example/example/1.0.0/terraform-provider-example_1.0.0_SHA256SUMS"]
+	assert.Equal(t, "sum-1.0.0-darwin-arm64  terraform-provider-example_1.0.0_darwin_arm64.zip\n"+
+		"sum-1.0.0-linux-amd64  terraform-provider-example_1.0.0_linux_amd64.zip\n", string(sums))
+	keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(pub))
+	require.NoError(t, err)
+	_, err = openpgp.CheckDetachedSignature(keys, bytes.NewReader(sums), bytes.NewReader(files["providers/example/example/1.0.0/terraform-provider-example_1.0.0_SHA256SUMS.sig"]), nil)
+	assert.NoError(t, err)
+	assert.Contains(t, files, "providers/example/example/2.0.0/terraform-provider-example_2.0.0_SHA256SUMS.sig")
+}
@@ -0,0 +1,215 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vagrant
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/opencontainers/go-digest"
+
+	"go.linka.cloud/artifact-registry/pkg/buffer"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	VersionsDir = "versions"
+
+	metadataFile = "metadata.json"
+)
+
+var (
+	ErrInvalidBox      = errors.New("vagrant box is invalid")
+	ErrMissingMetadata = errors.New("metadata.json file is missing")
+	ErrInvalidName     = errors.New("box name is invalid")
+	ErrInvalidVersion  = errors.New("box version is invalid")
+	ErrInvalidProvider = errors.New("box provider is invalid")
+
+	namePattern = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9._-]*\z`)
+)
+
+var _ storage.Artifact = (*Package)(nil)
+
+type Package struct {
+	Box          string `json:"box"`
+	PkgVersion   string `json:"version"`
+	Provider     string `json:"provider"`
+	Architecture string `json:"architecture,omitempty"`
+
+	Published time.Time `json:"published"`
+	PkgSize   int64     `json:"size"`
+	FilePath  string    `json:"filePath"`
+	SHA256    string    `json:"sha256"`
+
+	reader io.ReadCloser
+}
+
+func (p *Package) Read(b []byte) (n int, err error) {
+	if p.reader == nil {
+		return 0, io.EOF
+	}
+	return p.reader.Read(b)
+}
+
+func (p *Package) Close() error {
+	if p.reader == nil {
+		return nil
+	}
+	return p.reader.Close()
+}
+
+func (p *Package) Name() string {
+	return p.Box
+}
+
+func (p *Package) Path() string {
+	return p.FilePath
+}
+
+func (p *Package) Arch() string {
+	return p.Architecture
+}
+
+func (p *Package) Version() string {
+	return p.PkgVersion
+}
+
+func (p *Package) Size() int64 {
+	return p.PkgSize
+}
+
+func (p *Package) Digest() digest.Digest {
+	return digest.NewDigestFromEncoded(digest.SHA256, p.SHA256)
+}
+
+// BoxDir returns the box directory, e.g. org/box
+func BoxDir(org, box string) string {
+	return path.Join(org, box)
+}
+
+// BoxFileName returns the box file name, e.g. virtualbox.box or libvirt-amd64.box
+func BoxFileName(provider, arch string) string {
+	if arch != "" {
+		provider += "-" + arch
+	}
+	return provider + ".box"
+}
+
+// BoxPath returns the box file path, e.g. org/box/versions/1.0.0/virtualbox.box
+func BoxPath(org, box, version, provider, arch string) string {
+	return path.Join(BoxDir(org, box), VersionsDir, version, BoxFileName(provider, arch))
+}
+
+// NewPackage creates a package from a box archive. The provider is read from the box metadata.json file
+// when not provided, and must match it otherwise.
+// https://developer.hashicorp.com/vagrant/docs/boxes/format
+func NewPackage(r io.Reader, org, box, version, provider, arch string) (*Package, error) {
+	for _, v := range []string{org, box} {
+		if !namePattern.MatchString(v) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidName, path.Join(org, box))
+		}
+	}
+	if _, err := semver.NewVersion(version); err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidVersion, version)
+	}
+	if arch != "" && !namePattern.MatchString(arch) {
+		return nil, fmt.Errorf("%w: invalid architecture %q", ErrInvalidBox, arch)
+	}
+	reader, err := buffer.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	m, err := readMetadata(reader)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	switch {
+	case provider == "":
+		provider = m.Provider
+	case m.Provider != "" && m.Provider != provider:
+		reader.Close()
+		return nil, fmt.Errorf("%w: %q does not match the box provider %q", ErrInvalidProvider, provider, m.Provider)
+	}
+	if !namePattern.MatchString(provider) {
+		reader.Close()
+		return nil, fmt.Errorf("%w: %q", ErrInvalidProvider, provider)
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	_, _, sha256, _ := reader.Sums()
+	return &Package{
+		Box:          path.Join(org, box),
+		PkgVersion:   version,
+		Provider:     provider,
+		Architecture: arch,
+		Published:    time.Now().UTC(),
+		PkgSize:      reader.Size(),
+		FilePath:     BoxPath(org, box, version, provider, arch),
+		SHA256:       hex.EncodeToString(sha256),
+		reader:       reader,
+	}, nil
+}
+
+type metadata struct {
+	Provider string `json:"provider"`
+}
+
+// readMetadata returns the metadata.json file found in the box tar archive, which may be gzip compressed
+func readMetadata(r io.ReadSeeker) (*metadata, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	var rd io.Reader = br
+	if b, err := br.Peek(2); err == nil && b[0] == 0x1f && b[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBox, err)
+		}
+		defer gr.Close()
+		rd = gr
+	}
+	tr := tar.NewReader(rd)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrMissingMetadata
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBox, err)
+		}
+		if path.Clean(h.Name) != metadataFile {
+			continue
+		}
+		var m metadata
+		if err := json.NewDecoder(tr).Decode(&m); err != nil {
+			return nil, fmt.Errorf("%w: invalid %s: %v", ErrInvalidBox, metadataFile, err)
+		}
+		return &m, nil
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vagrant
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBox(t *testing.T, compress bool, files map[string]string) []byte {
+	var b bytes.Buffer
+	var w io.WriteCloser = nopCloser{&b}
+	if compress {
+		w = gzip.NewWriter(&b)
+	}
+	tw := tar.NewWriter(w)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func TestNewPackage(t *testing.T) {
+	tests := []struct {
+		name         string
+		compress     bool
+		provider     string
+		arch         string
+		wantProvider string
+		wantPath     string
+	}{
+		{
+			name:         "tar",
+			wantProvider: "virtualbox",
+			wantPath:     "org/box/versions/1.0.0/virtualbox.box",
+		},
+		{
+			name:         "tar gzip",
+			compress:     true,
+			wantProvider: "virtualbox",
+			wantPath:     "org/box/versions/1.0.0/virtualbox.box",
+		},
+		{
+			name:         "provider and architecture",
+			provider:     "virtualbox",
+			arch:         "amd64",
+			wantProvider: "virtualbox",
+			wantPath:     "org/box/versions/1.0.0/virtualbox-amd64.box",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testBox(t, tt.compress, map[string]string{
+				"./metadata.json": `{"provider": "virtualbox"}`,
+				"box.ovf":         "",
+			})
+			pkg, err := NewPackage(bytes.NewReader(b), "org", "box", "1.0.0", tt.provider, tt.arch)
+			require.NoError(t, err)
+			defer pkg.Close()
+			assert.Equal(t, "org/box", pkg.Name())
+			assert.Equal(t, "1.0.0", pkg.Version())
+			assert.Equal(t, tt.wantProvider, pkg.Provider)
+			assert.Equal(t, tt.arch, pkg.Arch())
+			assert.Equal(t, tt.wantPath, pkg.Path())
+			assert.Equal(t, int64(len(b)), pkg.Size())
+			assert.Len(t, pkg.SHA256, 64)
+		})
+	}
+}
+
+func TestNewPackageErrors(t *testing.T) {
+	box := testBox(t, true, map[string]string{"metadata.json": `{"provider": "virtualbox"}`})
+	tests := []struct {
+		name     string
+		data     []byte
+		org      string
+		version  string
+		provider string
+		arch     string
+		wantErr  error
+	}{
+		{
+			name:    "invalid name",
+			data:    box,
+			org:     "-org",
+			version: "1.0.0",
+			wantErr: ErrInvalidName,
+		},
+		{
+			name:    "invalid version",
+			data:    box,
+			org:     "org",
+			version: "latest",
+			wantErr: ErrInvalidVersion,
+		},
+		{
+			name:    "invalid architecture",
+			data:    box,
+			org:     "org",
+			version: "1.0.0",
+			arch:    "amd64/v2",
+			wantErr: ErrInvalidBox,
+		},
+		{
+			name:    "missing metadata",
+			data:    testBox(t, true, map[string]string{"box.ovf": ""}),
+			org:     "org",
+			version: "1.0.0",
+			wantErr: ErrMissingMetadata,
+		},
+		{
+			name:    "invalid metadata",
+			data:    testBox(t, false, map[string]string{"metadata.json": `{`}),
+			org:     "org",
+			version: "1.0.0",
+			wantErr: ErrInvalidBox,
+		},
+		{
+			name:     "provider mismatch",
+			data:     box,
+			org:      "org",
+			version:  "1.0.0",
+			provider: "libvirt",
+			wantErr:  ErrInvalidProvider,
+		},
+		{
+			name:    "missing provider",
+			data:    testBox(t, true, map[string]string{"metadata.json": `{}`}),
+			org:     "org",
+			version: "1.0.0",
+			wantErr: ErrInvalidProvider,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPackage(bytes.NewReader(tt.data), tt.org, "box", tt.version, tt.provider, tt.arch)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tt.wantErr), err)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vagrant
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/gorilla/mux"
+	"go.linka.cloud/grpc-toolkit/logger"
+
+	"go.linka.cloud/artifact-registry/pkg/packages"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const Name = "vagrant"
+
+var _ packages.Provider = (*provider)(nil)
+
+func init() {
+	packages.Register(Name, newProvider)
+}
+
+func newProvider(_ context.Context) (packages.Provider, error) {
+	return &provider{}, nil
+}
+
+type provider struct{}
+
+func (p *provider) Repository() storage.Repository {
+	return &repo{}
+}
+
+// catalog serves the box metadata used by vagrant when the box is added from its url, e.g.
+// vagrant box add https://vagrant.example.org/org/box
+func (p *provider) catalog(_ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		rc, err := storage.FromContext(ctx).Open(ctx, CatalogPath(BoxDir(vars["org"], vars["box"])))
+		if err != nil {
+			storage.Error(w, err)
+			return
+		}
+		defer rc.Close()
+		var c Catalog
+		if err := json.NewDecoder(rc).Decode(&c); err != nil {
+			storage.Error(w, err)
+			return
+		}
+		c.Resolve(baseURL(r))
+		for _, v := range c.Versions {
+			for _, p := range v.Providers {
+				if p.URL, err = storage.SignURL(ctx, p.URL); err != nil {
+					storage.Error(w, err)
+					return
+				}
+			}
+		}
+		// vagrant relies on the content type to know that the url points to the box metadata and not to a box file
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			logger.C(ctx).WithError(err).Error("failed to write response")
+		}
+	}
+}
+
+// baseURL returns the catalog url
+func baseURL(r *http.Request) string {
+	u := &url.URL{
+		Scheme: packages.Scheme(r),
+		Host:   r.Host,
+		Path:   r.URL.Path,
+	}
+	return u.String()
+}
+
+func boxPath(r *http.Request) string {
+	vars := mux.Vars(r)
+	return path.Join(BoxDir(vars["org"], vars["box"]), VersionsDir, vars["version"], vars["file"])
+}
+
+func (p *provider) Routes() []*packages.Route {
+	return []*packages.Route{
+		// the box files routes must be registered before the catalog one as the repository name
+		// may contain slashes
+		{
+			Method:  http.MethodGet,
+			Path:    "/{org}/{box}/" + VersionsDir + "/{version}/{file}",
+			Handler: packages.Pull(boxPath),
+		},
+		{
+			Method:  http.MethodDelete,
+			Path:    "/{org}/{box}/" + VersionsDir + "/{version}/{file}",
+			Handler: packages.Delete(boxPath),
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/{org}/{box}",
+			Handler: p.catalog,
+		},
+		{
+			// the architecture may be passed as query parameter, e.g. ?architecture=amd64
+			Method: http.MethodPut,
+			Path:   "/{org}/{box}/{version}/{provider}",
+			Handler: packages.Push(func(r *http.Request, reader io.Reader, key string) (storage.Artifact, error) {
+				vars := mux.Vars(r)
+				return NewPackage(reader, vars["org"], vars["box"], vars["version"], vars["provider"], r.URL.Query().Get("architecture"))
+			}),
+		},
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vagrant
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+
+	"go.linka.cloud/artifact-registry/pkg/codec"
+	"go.linka.cloud/artifact-registry/pkg/crypt/openpgp"
+	"go.linka.cloud/artifact-registry/pkg/slices"
+	"go.linka.cloud/artifact-registry/pkg/storage"
+)
+
+const (
+	RepositoryPublicKey  = "repository.key"
+	RepositoryPrivateKey = "private.key"
+
+	CatalogFile = "catalog.json"
+)
+
+var _ storage.Repository = (*repo)(nil)
+
+type repo struct{}
+
+func (r *repo) Name() string {
+	return "vagrant"
+}
+
+func (r *repo) GenerateKeypair() (string, string, error) {
+	return openpgp.GenerateKeypair("Artifact Registry", "Vagrant Repository", "")
+}
+
+func (r *repo) KeyNames() (string, string) {
+	return RepositoryPrivateKey, RepositoryPublicKey
+}
+
+func (r *repo) Codec() storage.Codec {
+	return codec.Funcs[storage.Artifact]{
+		Format: "json",
+		EncodeFunc: func(v storage.Artifact) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		DecodeFunc: func(b []byte) (storage.Artifact, error) {
+			var v Package
+			err := json.Unmarshal(b, &v)
+			return &v, err
+		},
+	}
+}
+
+// Catalog is the box metadata served to vagrant
+// https://developer.hashicorp.com/vagrant/docs/boxes/format#box-metadata
+type Catalog struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Versions    []*Version `json:"versions"`
+}
+
+type Version struct {
+	Version   string      `json:"version"`
+	Status    string      `json:"status"`
+	Providers []*Provider `json:"providers"`
+}
+
+type Provider struct {
+	Name         string `json:"name"`
+	URL          string `json:"url"`
+	ChecksumType string `json:"checksum_type"`
+	Checksum     string `json:"checksum"`
+	Architecture string `json:"architecture,omitempty"`
+}
+
+// Resolve makes the box urls absolute
+func (c *Catalog) Resolve(base string) {
+	for _, v := range c.Versions {
+		for _, p := range v.Providers {
+			p.URL = base + "/" + p.URL
+		}
+	}
+}
+
+// CatalogPath returns the box catalog path, e.g. org/box/catalog.json
+func CatalogPath(name string) string {
+	return path.Join(name, CatalogFile)
+}
+
+func (r *repo) Index(_ context.Context, _ string, as ...storage.Artifact) (out []storage.Artifact, err error) {
+	pkgs := storage.MustAs[*Package](as)
+	// Delete the index if there are no packages
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	names := slices.Distinct(slices.Map(pkgs, func(p *Package) string {
+		return p.Box
+	}))
+	sort.Strings(names)
+	for _, name := range names {
+		pkgs := slices.Filter(pkgs, func(p *Package) bool {
+			return p.Box == name
+		})
+		// the versions are listed from the latest to the oldest one
+		sort.Slice(pkgs, func(i, j int) bool {
+			vi, vj := semver.MustParse(pkgs[i].PkgVersion), semver.MustParse(pkgs[j].PkgVersion)
+			if !vi.Equal(vj) {
+				return vi.GreaterThan(vj)
+			}
+			if pkgs[i].PkgVersion != pkgs[j].PkgVersion {
+				return pkgs[i].PkgVersion > pkgs[j].PkgVersion
+			}
+			return BoxFileName(pkgs[i].Provider, pkgs[i].Architecture) < BoxFileName(pkgs[j].Provider, pkgs[j].Architecture)
+		})
+		c := Catalog{Name: name, Versions: []*Version{}}
+		for _, p := range pkgs {
+			if len(c.Versions) == 0 || c.Versions[len(c.Versions)-1].Version != p.PkgVersion {
+				c.Versions = append(c.Versions, &Version{Version: p.PkgVersion, Status: "active"})
+			}
+			v := c.Versions[len(c.Versions)-1]
+			v.Providers = append(v.Providers, &Provider{
+				Name: p.Provider,
+				// the url is relative to the catalog url, e.g. /org/box
+				URL:          path.Join(VersionsDir, p.PkgVersion, path.Base(p.FilePath)),
+				ChecksumType: "sha256",
+				Checksum:     p.SHA256,
+				Architecture: p.Architecture,
+			})
+		}
+		b, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, storage.NewFile(CatalogPath(name), b))
+	}
+	return out, nil
+}
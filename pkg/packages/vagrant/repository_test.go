@@ -0,0 +1,94 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vagrant
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPackage(box, version, provider, arch string) *Package {
+	return &Package{
+		Box:          box,
+		PkgVersion:   version,
+		Provider:     provider,
+		Architecture: arch,
+		FilePath:     path.Join(box, VersionsDir, version, BoxFileName(provider, arch)),
+		SHA256:       "sum-" + version + "-" + BoxFileName(provider, arch),
+	}
+}
+
+func TestIndex(t *testing.T) {
+	out, err := (&repo{}).Index(context.Background(), "",
+		testPackage("org/box", "1.0.0", "virtualbox", ""),
+		testPackage("org/box", "1.10.0", "virtualbox", ""),
+		testPackage("org/box", "1.10.0", "libvirt", "arm64"),
+		testPackage("org/box", "1.10.0", "libvirt", "amd64"),
+		testPackage("org/other", "0.1.0", "docker", ""),
+	)
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, v := range out {
+		b, err := io.ReadAll(v)
+		require.NoError(t, err)
+		files[v.Path()] = b
+	}
+	require.Len(t, files, 2)
+
+	var c Catalog
+	require.NoError(t, json.Unmarshal(files["org/box/catalog.json"], &c))
+	assert.Equal(t, Catalog{
+		Name: "org/box",
+		Versions: []*Version{
+			{
+				Version: "1.10.0",
+				Status:  "active",
+				Providers: []*Provider{
+					{Name: "libvirt", URL: "versions/1.10.0/libvirt-amd64.box", ChecksumType: "sha256", Checksum: "sum-1.10.0-libvirt-amd64.box", Architecture: "amd64"},
+					{Name: "libvirt", URL: "versions/1.10.0/libvirt-arm64.box", ChecksumType: "sha256", Checksum: "sum-1.10.0-libvirt-arm64.box", Architecture: "arm64"},
+					{Name: "virtualbox", URL: "versions/1.10.0/virtualbox.box", ChecksumType: "sha256", Checksum: "sum-1.10.0-virtualbox.box"},
+				},
+			},
+			{
+				Version: "1.0.0",
+				Status:  "active",
+				Providers: []*Provider{
+					{Name: "virtualbox", URL: "versions/1.0.0/virtualbox.box", ChecksumType: "sha256", Checksum: "sum-1.0.0-virtualbox.box"},
+				},
+			},
+		},
+	}, c)
+
+	c = Catalog{}
+	require.NoError(t, json.Unmarshal(files["org/other/catalog.json"], &c))
+	require.Len(t, c.Versions, 1)
+	assert.Equal(t, "0.1.0", c.Versions[0].Version)
+
+	out, err = (&repo{}).Index(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestCatalogResolve(t *testing.T) {
+	c := Catalog{Versions: []*Version{{Version: "1.0.0", Providers: []*Provider{{Name: "virtualbox", URL: "versions/1.0.0/virtualbox.box"}}}}}
+	c.Resolve("https://vagrant.example.org/repo/org/box")
+	assert.Equal(t, "https://vagrant.example.org/repo/org/box/versions/1.0.0/virtualbox.box", c.Versions[0].Providers[0].URL)
+}
@@ -31,3 +31,9 @@ func FromContext(ctx context.Context) Storage {
 	}
 	return s
 }
+
+// Lookup returns the storage from the context, if any.
+func Lookup(ctx context.Context) (Storage, bool) {
+	s, ok := ctx.Value(storageKey{}).(Storage)
+	return s, ok
+}
@@ -15,7 +15,9 @@
 package storage
 
 import (
+	"context"
 	"net/http"
+	"net/url"
 
 	"github.com/gorilla/mux"
 	"go.linka.cloud/grpc-toolkit/logger"
@@ -29,7 +31,7 @@ func Middleware(ar Repository) MiddlewareFunc {
 	return func(repoVar string) mux.MiddlewareFunc {
 		return func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				ctx := auth.Context(r.Context(), r)
+				ctx := r.Context()
 				name := mux.Vars(r)[repoVar]
 				if name == "" {
 					n := Options(ctx).repo
@@ -39,6 +41,16 @@ func Middleware(ar Repository) MiddlewareFunc {
 					}
 					name = n
 				}
+				a := auth.FromRequest(r)
+				// the signed urls carry a token granting read access to their path only
+				if _, _, ok := a.BasicAuth(); !ok && r.URL.Query().Has(auth.TokenParam) {
+					var err error
+					if a, err = auth.FromToken(Options(ctx).Key(), r.URL.Query().Get(auth.TokenParam), r.Method, name, r.URL.Path); err != nil {
+						http.Error(w, err.Error(), http.StatusUnauthorized)
+						return
+					}
+				}
+				ctx = repoNameContext(auth.Context(ctx, a), name)
 				ctx = logger.Set(ctx, logger.C(ctx).WithField("repo", name).WithField("type", ar.Name()))
 				s, err := NewStorage(ctx, name, ar)
 				if err != nil {
@@ -51,3 +63,27 @@ func Middleware(ar Repository) MiddlewareFunc {
 		}
 	}
 }
+
+type repoNameKey struct{}
+
+func repoNameContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, repoNameKey{}, name)
+}
+
+// SignURL adds to the url a short-lived token granting read only access to its path in the context repository,
+// the url is returned as is when the request does not hold any credentials
+func SignURL(ctx context.Context, u string) (string, error) {
+	v, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	name, _ := ctx.Value(repoNameKey{}).(string)
+	token, err := auth.Sign(ctx, Options(ctx).Key(), name, v.Path)
+	if err != nil || token == "" {
+		return u, err
+	}
+	q := v.Query()
+	q.Set(auth.TokenParam, token)
+	v.RawQuery = q.Encode()
+	return v.String(), nil
+}
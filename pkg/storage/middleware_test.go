@@ -0,0 +1,80 @@
+// Copyright 2023 Linka Cloud  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.linka.cloud/artifact-registry/pkg/auth"
+)
+
+func TestSignURL(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	ctx := WithOptions(context.Background(), WithKey(key))
+
+	u, err := SignURL(repoNameContext(ctx, "user/repo"), "https://example.org/generic/user/repo/-/file")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org/generic/user/repo/-/file", u, "no credentials, no token")
+
+	ctx = repoNameContext(auth.Context(ctx, auth.FromRequest(basicRequest())), "user/repo")
+	u, err = SignURL(ctx, "https://example.org/generic/user/repo/-/file")
+	require.NoError(t, err)
+	v, err := url.Parse(u)
+	require.NoError(t, err)
+	assert.Nil(t, v.User)
+	assert.NotContains(t, u, "pass")
+
+	r := mux.NewRouter()
+	r.Use(Middleware(nil)("repo"))
+	r.PathPrefix("/generic/{repo:.+}/-/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	serve := func(method, path string) int {
+		req := httptest.NewRequest(method, path+"?"+v.RawQuery, nil).WithContext(WithOptions(context.Background(), WithKey(key)))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{name: "put", method: http.MethodPut, path: v.Path},
+		{name: "delete", method: http.MethodDelete, path: v.Path},
+		{name: "other file", method: http.MethodGet, path: "/generic/user/repo/-/other"},
+		{name: "other repository", method: http.MethodGet, path: "/generic/user/other/-/file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, http.StatusUnauthorized, serve(tt.method, tt.path))
+		})
+	}
+}
+
+func basicRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("user", "pass")
+	return r
+}
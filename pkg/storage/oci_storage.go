@@ -26,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -120,10 +121,7 @@ func (s *storage) Open(ctx context.Context, path string) (io.ReadCloser, error)
 	return rd, nil
 }
 
-func (s *storage) Write(ctx context.Context, pkg Artifact) error {
-	log := logger.C(ctx).WithField("artifact", pkg.Name())
-	ctx = logger.Set(ctx, log)
-
+func (s *storage) Write(ctx context.Context, pkgs ...Artifact) error {
 	if err := s.Init(ctx); err != nil {
 		return err
 	}
@@ -131,25 +129,59 @@ func (s *storage) Write(ctx context.Context, pkg Artifact) error {
 	s.lock(ctx)
 	defer s.unlock(ctx)
 
-	log.Infof("uploading %s", pkg.Path())
-	if prv, pb := s.repo.KeyNames(); pkg.Path() == prv || pkg.Path() == pb {
-		return fmt.Errorf("%s: %w", pkg.Path(), os.ErrExist)
-	}
 	store, err := file.New(s.tmp)
 	if err != nil {
 		return err
 	}
-	pkgb, err := json.Marshal(pkg)
+	// all the artifacts are published with a single manifest update
+	var layers []ocispec.Descriptor
+	paths := make(map[string]struct{})
+	for _, pkg := range pkgs {
+		ls, err := s.push(ctx, store, pkg)
+		if err != nil {
+			return err
+		}
+		for _, v := range ls {
+			paths[v.Annotations[ocispec.AnnotationTitle]] = struct{}{}
+		}
+		layers = append(layers, ls...)
+	}
+	m, err := s.manifest(ctx)
 	if err != nil {
 		return err
 	}
+	var ls []ocispec.Descriptor
+	for _, v := range m.Layers {
+		if n := v.Annotations[ocispec.AnnotationTitle]; hasKey(paths, n) {
+			logger.C(ctx).Infof("updating layer %s (%s)", n, v.Digest)
+			continue
+		}
+		ls = append(ls, v)
+	}
+	m.Layers = ls
+	return s.updateIndex(ctx, store, m, pkgs, layers)
+}
+
+// push pushes the artifact and its files to the local store and returns their layers.
+func (s *storage) push(ctx context.Context, store *file.Store, pkg Artifact) ([]ocispec.Descriptor, error) {
+	log := logger.C(ctx).WithField("artifact", pkg.Name())
+	ctx = logger.Set(ctx, log)
+
+	log.Infof("uploading %s", pkg.Path())
+	if prv, pb := s.repo.KeyNames(); pkg.Path() == prv || pkg.Path() == pb {
+		return nil, fmt.Errorf("%s: %w", pkg.Path(), os.ErrExist)
+	}
+	pkgb, err := json.Marshal(pkg)
+	if err != nil {
+		return nil, err
+	}
 	cfg := ocispec.Descriptor{
 		MediaType: s.MediaTypeArtifactConfig(),
 		Digest:    digest.FromBytes(pkgb),
 		Size:      int64(len(pkgb)),
 	}
 	if err := store.Push(ctx, cfg, bytes.NewReader(pkgb)); err != nil {
-		return err
+		return nil, err
 	}
 	layer := ocispec.Descriptor{
 		MediaType: s.MediaTypeArtifactLayer(),
@@ -162,82 +194,93 @@ func (s *storage) Write(ctx context.Context, pkg Artifact) error {
 	}
 	if err := store.Push(ctx, layer, pkg); err != nil {
 		if errors.Is(err, file.ErrDuplicateName) {
-			return fmt.Errorf("%s: %w", pkg.Path(), os.ErrExist)
+			return nil, fmt.Errorf("%s: %w", pkg.Path(), os.ErrExist)
 		}
-		return err
+		return nil, err
+	}
+	layers := []ocispec.Descriptor{layer}
+	for _, v := range artifactFiles(pkg) {
+		l := ocispec.Descriptor{
+			MediaType: s.MediaTypeArtifactFileLayer(),
+			Digest:    v.Digest(),
+			Size:      v.Size(),
+			Annotations: map[string]string{
+				ocispec.AnnotationTitle: v.Path(),
+			},
+		}
+		if err := store.Push(ctx, l, v); err != nil {
+			if errors.Is(err, file.ErrDuplicateName) {
+				return nil, fmt.Errorf("%s: %w", v.Path(), os.ErrExist)
+			}
+			return nil, err
+		}
+		layers = append(layers, l)
+	}
+	if !s.opts.artifactTags || pkg.Name() == "" {
+		return layers, nil
 	}
 	opts := oras.PackManifestOptions{
 		ConfigDescriptor: &cfg,
-		Layers:           []ocispec.Descriptor{layer},
+		Layers:           layers,
 	}
 	img, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1_RC4, s.ArtefactTypeRegistry(), opts)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if s.opts.artifactTags {
-		repo := s.artifactName(pkg)
-		ref := strings.NewReplacer("~", "-", "+", "-").Replace(repo + ":" + defaults(pkg.Version(), "latest"))
-		log.Infof("tagging artifact %s", ref)
-		if err := store.Tag(ctx, img, img.Digest.String()); err != nil {
-			return err
-		}
-		rrepo, err := s.opts.NewRepository(ctx, repo)
-		if err != nil {
-			return err
-		}
-		img, err = oras.Copy(ctx, store, img.Digest.String(), rrepo, ref, copts(repo))
-		if err != nil {
-			return err
-		}
+	repo := s.artifactName(pkg)
+	ref := strings.NewReplacer("~", "-", "+", "-").Replace(repo + ":" + defaults(pkg.Version(), "latest"))
+	log.Infof("tagging artifact %s", ref)
+	if err := store.Tag(ctx, img, img.Digest.String()); err != nil {
+		return nil, err
 	}
-	m, err := s.manifest(ctx)
+	rrepo, err := s.opts.NewRepository(ctx, repo)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	var ls []ocispec.Descriptor
-	for _, v := range m.Layers {
-		if v.Annotations[ocispec.AnnotationTitle] == pkg.Path() {
-			logger.C(ctx).Infof("updating layer %s (%s)", pkg.Path(), v.Digest)
-			continue
-		}
-		ls = append(ls, v)
+	if _, err := oras.Copy(ctx, store, img.Digest.String(), rrepo, ref, copts(repo)); err != nil {
+		return nil, err
 	}
-	m.Layers = ls
-	return s.updateIndex(ctx, store, m, []Artifact{pkg}, []ocispec.Descriptor{layer})
+	return layers, nil
 }
 
-func (s *storage) Delete(ctx context.Context, name string) error {
-	logger.C(ctx).Infof("deleting %s", name)
-	s.lock(ctx)
-	defer s.unlock(ctx)
-	if prv, pb := s.repo.KeyNames(); name == prv || name == pb {
-		return fmt.Errorf("%s: %w", name, os.ErrNotExist)
-	}
-	desc, err := s.find(ctx, name)
-	if err != nil {
+func (s *storage) CheckWrite(ctx context.Context) error {
+	// pushing the empty blob requires the push permission without storing anything meaningful
+	err := s.rrepo.Push(ctx, ocispec.DescriptorEmptyJSON, bytes.NewReader(ocispec.DescriptorEmptyJSON.Data))
+	if err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
 		return err
 	}
-	pkg, err := s.repo.Codec().Decode(desc.Data)
-	if err != nil {
-		return err
-	}
-	if s.opts.artifactTags {
-		repo := s.artifactName(pkg)
-		ref := strings.NewReplacer("~", "-", "+", "-").Replace(repo + ":" + defaults(pkg.Version(), "latest"))
-		rrepo, err := s.opts.NewRepository(ctx, repo)
+	return nil
+}
+
+func (s *storage) Delete(ctx context.Context, names ...string) error {
+	logger.C(ctx).Infof("deleting %s", strings.Join(names, ", "))
+	s.lock(ctx)
+	defer s.unlock(ctx)
+	// all the artifacts are resolved before anything is removed so that the deletion is all or nothing
+	var pkgs []Artifact
+	paths := make(map[string]struct{})
+	for _, name := range names {
+		if prv, pb := s.repo.KeyNames(); name == prv || name == pb {
+			return fmt.Errorf("%s: %w", name, os.ErrNotExist)
+		}
+		desc, err := s.find(ctx, name)
 		if err != nil {
 			return err
 		}
-		del := true
-		pdesc, err := rrepo.Resolve(ctx, ref)
+		// only the artifacts can be deleted, their files are removed with them
+		if desc.MediaType != s.MediaTypeArtifactLayer() {
+			return fmt.Errorf("%s: %w", name, os.ErrNotExist)
+		}
+		pkg, err := s.repo.Codec().Decode(desc.Data)
 		if err != nil {
-			if !errors.Is(err, errdef.ErrNotFound) {
-				return err
-			}
-			del = false
+			return err
 		}
-		if del {
-			if err := rrepo.Delete(ctx, pdesc); err != nil {
+		pkgs = append(pkgs, pkg)
+		paths[pkg.Path()] = struct{}{}
+	}
+	if s.opts.artifactTags {
+		for _, pkg := range pkgs {
+			if err := s.untag(ctx, pkg); err != nil {
 				return err
 			}
 		}
@@ -248,8 +291,8 @@ func (s *storage) Delete(ctx context.Context, name string) error {
 	}
 	var ls []ocispec.Descriptor
 	for _, v := range m.Layers {
-		if v.Annotations[ocispec.AnnotationTitle] == pkg.Path() {
-			logger.C(ctx).Infof("updating layer %s (%s-", pkg.Path(), v.Digest)
+		if n := v.Annotations[ocispec.AnnotationTitle]; hasKey(paths, n) {
+			logger.C(ctx).Infof("removing layer %s (%s)", n, v.Digest)
 			continue
 		}
 		ls = append(ls, v)
@@ -262,6 +305,27 @@ func (s *storage) Delete(ctx context.Context, name string) error {
 	return s.updateIndex(ctx, store, m, nil, nil)
 }
 
+// untag deletes the artifact's tag from its own repository.
+func (s *storage) untag(ctx context.Context, pkg Artifact) error {
+	if pkg.Name() == "" {
+		return nil
+	}
+	repo := s.artifactName(pkg)
+	ref := strings.NewReplacer("~", "-", "+", "-").Replace(repo + ":" + defaults(pkg.Version(), "latest"))
+	rrepo, err := s.opts.NewRepository(ctx, repo)
+	if err != nil {
+		return err
+	}
+	desc, err := rrepo.Resolve(ctx, ref)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return rrepo.Delete(ctx, desc)
+}
+
 func (s *storage) Artifacts(ctx context.Context) ([]Artifact, error) {
 	logger.C(ctx).Infof("listing artifacts")
 	m, err := s.manifest(ctx)
@@ -300,12 +364,19 @@ func (s *storage) ServeFile(w http.ResponseWriter, r *http.Request, path string)
 	}
 	defer rd.Close()
 	ctype := mime.TypeByExtension(filepath.Ext(path))
-	if ctype != "" {
-		w.Header().Set("Content-Type", ctype)
+	if ctype == "" {
+		ctype = "application/octet-stream"
 	}
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", desc.Size))
+	w.Header().Set("Content-Type", ctype)
 	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename=%s`, name))
 	w.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+	// the blob reader is seekable when the registry supports range requests,
+	// which allows the clients to resume the download of large files
+	if rs, ok := rd.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, time.Time{}, rs)
+		return nil
+	}
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", desc.Size))
 	_, err = io.Copy(w, rd)
 	return err
 }
@@ -338,12 +409,17 @@ func (s *storage) Close() error {
 
 func (s *storage) updateIndex(ctx context.Context, store *file.Store, m ocispec.Manifest, pkgs []Artifact, layers []ocispec.Descriptor) error {
 	pvn, pbn := s.repo.KeyNames()
+	var fls []ocispec.Descriptor
 	for i := range m.Layers {
 		v := m.Layers[i]
 		if n := v.Annotations[ocispec.AnnotationTitle]; n == pvn || n == pbn {
 			layers = append(layers, v)
 			continue
 		}
+		if v.MediaType == s.MediaTypeArtifactFileLayer() {
+			fls = append(fls, v)
+			continue
+		}
 		if v.MediaType != s.MediaTypeArtifactLayer() {
 			continue
 		}
@@ -354,6 +430,20 @@ func (s *storage) updateIndex(ctx context.Context, store *file.Store, m ocispec.
 		pkgs = append(pkgs, p)
 		layers = append(layers, v)
 	}
+	// keep only the files still referenced by an artifact
+	refs := make(map[string]struct{})
+	for _, v := range pkgs {
+		for _, f := range artifactFiles(v) {
+			refs[f.Path()] = struct{}{}
+		}
+	}
+	for _, v := range fls {
+		if n := v.Annotations[ocispec.AnnotationTitle]; !hasKey(refs, n) {
+			logger.C(ctx).Infof("removing unreferenced file %s (%s)", n, v.Digest)
+			continue
+		}
+		layers = append(layers, v)
+	}
 	logger.C(ctx).Infof("updating index")
 	files, err := s.repo.Index(ctx, s.key, pkgs...)
 	if err != nil {
@@ -557,3 +647,6 @@ func (s *storage) MediaTypeRegistryLayerMetadata(name string) string {
 func (s *storage) MediaTypeArtifactLayer() string {
 	return "application/vnd.lk.registry.layer.v1." + s.repo.Name()
 }
+func (s *storage) MediaTypeArtifactFileLayer() string {
+	return "application/vnd.lk.registry.file.layer.v1." + s.repo.Name()
+}
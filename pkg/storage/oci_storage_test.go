@@ -65,8 +65,11 @@ func newRegistry(t *testing.T, ctx context.Context, auth map[string]configuratio
 
 var _ Artifact = (*mockArtifact)(nil)
 
+var _ MultiFileArtifact = (*mockArtifact)(nil)
+
 type mockArtifact struct {
-	S string `json:"s"`
+	S string   `json:"s"`
+	F []string `json:"f,omitempty"`
 	r io.Reader
 }
 
@@ -109,6 +112,21 @@ func (m *mockArtifact) Digest() digest.Digest {
 	return digest.FromString(m.S)
 }
 
+func (m *mockArtifact) Files() []Artifact {
+	return slices.Map(m.F, func(f string) Artifact {
+		return NewFile(f, []byte(f))
+	})
+}
+
+// unnamedArtifact is an artifact without a name, e.g. a staged upload
+type unnamedArtifact struct {
+	*mockArtifact
+}
+
+func (u unnamedArtifact) Name() string {
+	return ""
+}
+
 var _ Repository = (*mockRepository)(nil)
 
 type mockRepository struct{}
@@ -337,6 +355,94 @@ func TestStorage(t *testing.T) {
 				assert.ErrorIs(t, err, os.ErrNotExist)
 			},
 		},
+		{
+			name: "write stores the artifact files",
+			fn: func(t *testing.T, ctx context.Context, s *storage, reg registry2.Repository) {
+				require.NoError(t, s.Write(ctx, &mockArtifact{S: "src.txt", F: []string{"shared.txt", "own.txt"}}))
+				require.NoError(t, s.Write(ctx, &mockArtifact{S: "src2.txt", F: []string{"shared.txt"}}))
+				desc, err := s.find(ctx, "shared.txt")
+				require.NoError(t, err)
+				assert.Equal(t, s.MediaTypeArtifactFileLayer(), desc.MediaType)
+				rc, err := s.Open(ctx, "own.txt")
+				require.NoError(t, err)
+				defer rc.Close()
+				b, err := io.ReadAll(rc)
+				require.NoError(t, err)
+				assert.Equal(t, "own.txt", string(b))
+				as, err := s.Artifacts(ctx)
+				require.NoError(t, err)
+				assert.Len(t, as, 3)
+			},
+		},
+		{
+			name: "write stores multiple artifacts at once",
+			fn: func(t *testing.T, ctx context.Context, s *storage, reg registry2.Repository) {
+				require.NoError(t, s.Write(ctx, newMockArtifact("batch.txt"), newMockArtifact("batch2.txt")))
+				rc, err := s.Open(ctx, "index.txt")
+				require.NoError(t, err)
+				defer rc.Close()
+				b, err := io.ReadAll(rc)
+				require.NoError(t, err)
+				assert.Contains(t, string(b), "batch.txt\nbatch2.txt")
+			},
+		},
+		{
+			name: "check write",
+			fn: func(t *testing.T, ctx context.Context, s *storage, reg registry2.Repository) {
+				require.NoError(t, s.CheckWrite(ctx))
+				require.NoError(t, s.CheckWrite(ctx))
+			},
+		},
+		{
+			name: "delete is atomic",
+			fn: func(t *testing.T, ctx context.Context, s *storage, reg registry2.Repository) {
+				assert.ErrorIs(t, s.Delete(ctx, "batch.txt", "missing.txt"), os.ErrNotExist)
+				_, err := s.find(ctx, "batch.txt")
+				require.NoError(t, err)
+				require.NoError(t, s.Delete(ctx, "batch.txt", "batch2.txt"))
+				_, err = s.find(ctx, "batch.txt")
+				assert.ErrorIs(t, err, os.ErrNotExist)
+				_, err = s.find(ctx, "batch2.txt")
+				assert.ErrorIs(t, err, os.ErrNotExist)
+			},
+		},
+		{
+			name: "artifact files cannot be deleted",
+			fn: func(t *testing.T, ctx context.Context, s *storage, reg registry2.Repository) {
+				assert.ErrorIs(t, s.Delete(ctx, "own.txt"), os.ErrNotExist)
+			},
+		},
+		{
+			name: "delete removes the unreferenced files",
+			fn: func(t *testing.T, ctx context.Context, s *storage, reg registry2.Repository) {
+				require.NoError(t, s.Delete(ctx, "src.txt"))
+				_, err := s.find(ctx, "own.txt")
+				assert.ErrorIs(t, err, os.ErrNotExist)
+				_, err = s.find(ctx, "shared.txt")
+				assert.NoError(t, err)
+				require.NoError(t, s.Delete(ctx, "src2.txt"))
+				_, err = s.find(ctx, "shared.txt")
+				assert.ErrorIs(t, err, os.ErrNotExist)
+			},
+		},
+		{
+			name: "unnamed artifacts are not tagged",
+			fn: func(t *testing.T, ctx context.Context, s *storage, reg registry2.Repository) {
+				s.opts.artifactTags = true
+				defer func() { s.opts.artifactTags = false }()
+				require.NoError(t, s.Write(ctx, newMockArtifact("tagged.txt")))
+				tagged, err := Options(ctx).NewRepository(ctx, s.artifactName(newMockArtifact("tagged.txt")))
+				require.NoError(t, err)
+				_, err = tagged.Resolve(ctx, "none")
+				require.NoError(t, err)
+
+				require.NoError(t, s.Write(ctx, unnamedArtifact{newMockArtifact("unnamed.txt")}))
+				untagged, err := Options(ctx).NewRepository(ctx, s.artifactName(unnamedArtifact{newMockArtifact("unnamed.txt")}))
+				require.NoError(t, err)
+				_, err = untagged.Resolve(ctx, "none")
+				assert.ErrorContains(t, err, "not found")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -37,6 +37,7 @@ type Codec = codec.Codec[Artifact]
 type Artifact interface {
 	io.ReadCloser
 	// Name is the name of the artifact, e.g. "jq".
+	// Artifacts without a name are not tagged in their own repository.
 	Name() string
 	// Path is the path of the artifact in the repository.
 	Path() string
@@ -49,6 +50,17 @@ type Artifact interface {
 	Digest() digest.Digest
 }
 
+// MultiFileArtifact is an Artifact made of more than one file, e.g. a debian source package
+// and the tarballs it references.
+// The artifact itself is stored as the package layer, the additional files are stored alongside it
+// and are removed once no artifact references them anymore.
+type MultiFileArtifact interface {
+	Artifact
+	// Files returns the additional files of the artifact.
+	// The files only need to be readable when the artifact is written.
+	Files() []Artifact
+}
+
 type ArtifactInfo interface {
 	Name() string
 	Version() string
@@ -71,8 +83,13 @@ type Storage interface {
 	Init(ctx context.Context) error
 	Stat(ctx context.Context, file string) (ArtifactInfo, error)
 	Open(ctx context.Context, name string) (io.ReadCloser, error)
-	Write(ctx context.Context, a Artifact) error
-	Delete(ctx context.Context, name string) error
+	// Write stores the artifacts and updates the repository index once they are all written.
+	Write(ctx context.Context, as ...Artifact) error
+	// CheckWrite verifies that the credentials allow to write to the repository, it is used to reject the uploads
+	// before they are buffered.
+	CheckWrite(ctx context.Context) error
+	// Delete removes the artifacts and their files and updates the repository index once they are all removed.
+	Delete(ctx context.Context, names ...string) error
 	Artifacts(ctx context.Context) ([]Artifact, error)
 	ServeFile(w http.ResponseWriter, r *http.Request, name string) error
 	Size(ctx context.Context) (int64, error)
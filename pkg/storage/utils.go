@@ -21,3 +21,15 @@ func defaults[T comparable](v T, d T) T {
 	}
 	return v
 }
+
+func artifactFiles(a Artifact) []Artifact {
+	if v, ok := a.(MultiFileArtifact); ok {
+		return v.Files()
+	}
+	return nil
+}
+
+func hasKey[K comparable, V any](m map[K]V, k K) bool {
+	_, ok := m[k]
+	return ok
+}